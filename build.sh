@@ -10,15 +10,13 @@ mkdir -p output/bin
 echo "Building user-service..."
 go build -o output/bin/user-service ./cmd/user-service
 
-# Build video-upload-service (if main.go exists and has content)
-if [ -s "cmd/video-upload-service/main.go" ]; then
-    echo "Building video-upload-service..."
-    if go build -o output/bin/video-upload-service ./cmd/video-upload-service 2>/dev/null; then
-        echo "✓ video-upload-service built successfully"
-    else
-        echo "✗ Failed to build video-upload-service (main.go may be empty or invalid)"
-    fi
-fi
+# Build channel-service
+echo "Building channel-service..."
+go build -o output/bin/channel-service ./cmd/channel-service
+
+# Build video-upload-service
+echo "Building video-upload-service..."
+go build -o output/bin/video-upload-service ./cmd/video-upload-service
 
 # Build metadata-service (if main.go exists and has content)
 if [ -s "cmd/metadata-service/main.go" ]; then
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"log"
+
+	_ "kube/docs" // This is generated by swag init
+	"kube/internal/auth"
+	"kube/internal/config"
+	"kube/internal/database"
+	"kube/internal/middleware"
+	"kube/pkg/server"
+	"kube/services/channel"
+	"time"
+)
+
+// @title Channel Service API
+// @version 1.0
+// @description This is a channel management service API built with Hertz framework.
+
+// @contact.name API Support
+// @contact.url https://github.com/your-username/kube
+// @contact.email support@example.com
+
+// @license.name Apache 2.0
+// @license.url http://www.apache.org/licenses/LICENSE-2.0.html
+
+// @host localhost:8089
+// @BasePath /
+// @schemes http https
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Type "Bearer" followed by a space and JWT token.
+
+func main() {
+	cfg := config.Load()
+	db := database.Init(cfg.Database)
+	redisClient := database.InitRedis(cfg.Redis)
+
+	// Videos are migrated here too, channel deletion removes their files
+	if err := database.Migrate(db); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+
+	verifier, err := auth.NewVerifierFromConfig(cfg.JWT)
+	if err != nil {
+		log.Fatal("Failed to load JWT verification keys:", err)
+	}
+
+	channelService := channel.NewService(db, cfg)
+
+	authOptions := []middleware.AuthOption{
+		middleware.WithTokenVerifier(verifier),
+		middleware.WithRevocationStore(auth.NewRedisRevocationStore(redisClient)),
+	}
+	if cfg.Auth.APIKeyIntrospectionURL != "" {
+		// API keys are owned and validated by the user service
+		authOptions = append(authOptions, middleware.WithAPIKeyValidator(
+			middleware.NewAPIKeyIntrospector(cfg.Auth.APIKeyIntrospectionURL, cfg.Auth.APIKeyIntrospectionSecret)))
+	}
+	authMiddleware := middleware.AuthMiddleware(cfg.JWT.SecretKey, authOptions...)
+
+	serverConfig := server.ServerConfig{
+		Port:         "8089",
+		ServiceName:  "channel-service",
+		SwaggerURL:   "http://localhost:8089",
+		RateLimit:    100,
+		RateDuration: time.Minute,
+	}
+
+	srv := server.NewServer(serverConfig)
+	channel.RegisterRoutes(srv.Hertz, channelService, authMiddleware)
+	srv.Start()
+}
@@ -1,15 +1,21 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	_ "kube/docs" // This is generated by swag init
+	"kube/internal/auth"
 	"kube/internal/config"
 	"kube/internal/database"
-	"kube/pkg/models"
+	"kube/internal/mailer"
+	"kube/internal/middleware"
+	"kube/internal/storage"
 	"kube/pkg/server"
 	"kube/services/user"
 	"time"
+
+	"github.com/cloudwego/hertz/pkg/app"
 )
 
 // @title User Service API
@@ -30,17 +36,52 @@ import (
 // @securityDefinitions.apikey BearerAuth
 // @in header
 // @name Authorization
-// @description Type "Bearer" followed by a space and JWT token.
+// @description Type "Bearer" followed by a space and JWT token, or "ApiKey" followed by a space and an API key.
 
 func main() {
 	cfg := config.Load()
 	db := database.Init(cfg.Database)
+	redisClient := database.InitRedis(cfg.Redis)
 
-	if err := db.AutoMigrate(&models.User{}); err != nil {
+	if err := database.Migrate(db); err != nil {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
-	userService := user.NewService(db, cfg.JWT.SecretKey)
+	signer, err := auth.NewSignerFromConfig(cfg.JWT)
+	if err != nil {
+		log.Fatal("Failed to load JWT signing keys:", err)
+	}
+
+	passwords, err := auth.NewPasswordHasherFromConfig(cfg.Password)
+	if err != nil {
+		log.Fatal("Invalid password hashing configuration:", err)
+	}
+
+	passwordPolicy, err := auth.NewPasswordPolicyFromConfig(cfg.Password)
+	if err != nil {
+		log.Fatal("Invalid password policy configuration:", err)
+	}
+
+	revocations := auth.NewRedisRevocationStore(redisClient)
+	userService := user.NewService(db, cfg,
+		user.WithTokenSigner(signer),
+		user.WithPasswordHasher(passwords),
+		user.WithPasswordPolicy(passwordPolicy),
+		user.WithRevocationStore(revocations),
+		user.WithAttemptTracker(auth.NewRedisAttemptTracker(redisClient)),
+		user.WithMailer(mailer.New(cfg.Mail)),
+		user.WithOIDCStateStore(auth.NewRedisStateStore(redisClient)),
+	)
+	if err := userService.SeedRoles(); err != nil {
+		log.Fatal("Failed to seed roles:", err)
+	}
+	go userService.RunPurger(context.Background())
+
+	authMiddleware := middleware.AuthMiddleware(cfg.JWT.SecretKey,
+		middleware.WithTokenVerifier(signer),
+		middleware.WithRevocationStore(revocations),
+		middleware.WithAPIKeyValidator(userService),
+	)
 
 	serverConfig := server.ServerConfig{
 		Port:         "8081",
@@ -51,6 +92,10 @@ func main() {
 	}
 
 	srv := server.NewServer(serverConfig)
-	user.RegisterRoutes(srv.Hertz, userService)
+	user.RegisterRoutes(srv.Hertz, userService, authMiddleware)
+	if cfg.Storage.Driver == storage.DriverLocal {
+		// Serve uploaded files such as avatars at STORAGE_PUBLIC_URL
+		srv.Hertz.StaticFS("/media", &app.FS{Root: cfg.Storage.LocalDir, PathRewrite: app.NewPathSlashesStripper(1)})
+	}
 	srv.Start()
 }
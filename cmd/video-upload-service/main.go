@@ -1 +1,78 @@
 package main
+
+import (
+	"context"
+	"log"
+
+	_ "kube/docs" // This is generated by swag init
+	"kube/internal/auth"
+	"kube/internal/config"
+	"kube/internal/database"
+	"kube/internal/middleware"
+	"kube/pkg/server"
+	videoupload "kube/services/video-upload"
+	"time"
+)
+
+// @title Video Upload Service API
+// @version 1.0
+// @description This is a video upload service API built with Hertz framework.
+
+// @contact.name API Support
+// @contact.url https://github.com/your-username/kube
+// @contact.email support@example.com
+
+// @license.name Apache 2.0
+// @license.url http://www.apache.org/licenses/LICENSE-2.0.html
+
+// @host localhost:8082
+// @BasePath /
+// @schemes http https
+
+// @securityDefinitions.apikey BearerAuth
+// @in header
+// @name Authorization
+// @description Type "Bearer" followed by a space and JWT token.
+
+func main() {
+	cfg := config.Load()
+	db := database.Init(cfg.Database)
+	redisClient := database.InitRedis(cfg.Redis)
+
+	if err := database.Migrate(db); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+
+	verifier, err := auth.NewVerifierFromConfig(cfg.JWT)
+	if err != nil {
+		log.Fatal("Failed to load JWT verification keys:", err)
+	}
+
+	videoService := videoupload.NewService(db, cfg)
+	go videoService.RunSweeper(context.Background())
+
+	authOptions := []middleware.AuthOption{
+		middleware.WithTokenVerifier(verifier),
+		middleware.WithRevocationStore(auth.NewRedisRevocationStore(redisClient)),
+	}
+	if cfg.Auth.APIKeyIntrospectionURL != "" {
+		// API keys are owned and validated by the user service
+		authOptions = append(authOptions, middleware.WithAPIKeyValidator(
+			middleware.NewAPIKeyIntrospector(cfg.Auth.APIKeyIntrospectionURL, cfg.Auth.APIKeyIntrospectionSecret)))
+	}
+	authMiddleware := middleware.AuthMiddleware(cfg.JWT.SecretKey, authOptions...)
+
+	serverConfig := server.ServerConfig{
+		Port:         "8082",
+		ServiceName:  "video-upload-service",
+		SwaggerURL:   "http://localhost:8082",
+		RateLimit:    100,
+		RateDuration: time.Minute,
+		// Uploads are streamed to storage, see videoupload.Handler.UploadVideo
+		StreamRequestBody: true,
+	}
+
+	srv := server.NewServer(serverConfig)
+	videoupload.RegisterRoutes(srv.Hertz, videoService, authMiddleware)
+	srv.Start()
+}
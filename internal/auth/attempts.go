@@ -0,0 +1,126 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// AttemptTracker counts failed attempts per key within a fixed window, for
+// example failed logins per client IP
+type AttemptTracker interface {
+	// RecordFailure adds a failure for key. The window starts with the first
+	// failure and the count resets once it elapses.
+	RecordFailure(ctx context.Context, key string, window time.Duration) (int, error)
+	// Failures returns the failures in the current window and the time left
+	// until the window resets
+	Failures(ctx context.Context, key string) (int, time.Duration, error)
+	// Reset clears the failures for key
+	Reset(ctx context.Context, key string) error
+}
+
+// MemoryAttemptTracker is an in-process AttemptTracker for tests and
+// single-instance development setups
+type MemoryAttemptTracker struct {
+	mu      sync.Mutex
+	windows map[string]*attemptWindow
+}
+
+type attemptWindow struct {
+	count     int
+	expiresAt time.Time
+}
+
+// NewMemoryAttemptTracker creates an empty in-memory attempt tracker
+func NewMemoryAttemptTracker() *MemoryAttemptTracker {
+	return &MemoryAttemptTracker{windows: make(map[string]*attemptWindow)}
+}
+
+func (t *MemoryAttemptTracker) RecordFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	w, ok := t.windows[key]
+	if !ok || now.After(w.expiresAt) {
+		w = &attemptWindow{expiresAt: now.Add(window)}
+		t.windows[key] = w
+	}
+	w.count++
+	return w.count, nil
+}
+
+func (t *MemoryAttemptTracker) Failures(ctx context.Context, key string) (int, time.Duration, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	w, ok := t.windows[key]
+	if !ok {
+		return 0, 0, nil
+	}
+	remaining := time.Until(w.expiresAt)
+	if remaining <= 0 {
+		delete(t.windows, key)
+		return 0, 0, nil
+	}
+	return w.count, remaining, nil
+}
+
+func (t *MemoryAttemptTracker) Reset(ctx context.Context, key string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.windows, key)
+	return nil
+}
+
+// RedisAttemptTracker is an AttemptTracker shared by every service instance
+type RedisAttemptTracker struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisAttemptTracker creates an attempt tracker backed by Redis
+func NewRedisAttemptTracker(client *redis.Client) *RedisAttemptTracker {
+	return &RedisAttemptTracker{
+		client: client,
+		prefix: "auth:attempts:",
+	}
+}
+
+func (t *RedisAttemptTracker) RecordFailure(ctx context.Context, key string, window time.Duration) (int, error) {
+	redisKey := t.prefix + key
+	count, err := t.client.Incr(ctx, redisKey).Result()
+	if err != nil {
+		return 0, err
+	}
+	if count == 1 {
+		if err := t.client.Expire(ctx, redisKey, window).Err(); err != nil {
+			return 0, err
+		}
+	}
+	return int(count), nil
+}
+
+func (t *RedisAttemptTracker) Failures(ctx context.Context, key string) (int, time.Duration, error) {
+	redisKey := t.prefix + key
+	count, err := t.client.Get(ctx, redisKey).Int()
+	if err == redis.Nil {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	ttl, err := t.client.TTL(ctx, redisKey).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+	return count, ttl, nil
+}
+
+func (t *RedisAttemptTracker) Reset(ctx context.Context, key string) error {
+	return t.client.Del(ctx, t.prefix+key).Err()
+}
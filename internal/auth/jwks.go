@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// jwksMinRefreshInterval limits refetches triggered by unknown key IDs
+const jwksMinRefreshInterval = 30 * time.Second
+
+// JWKSClient verifies tokens with public keys fetched from a JWKS endpoint.
+// Keys are cached for the configured TTL and refetched early when a token
+// references an unknown key ID, which happens right after a key rotation.
+type JWKSClient struct {
+	url        string
+	ttl        time.Duration
+	httpClient *http.Client
+
+	mu        sync.RWMutex
+	keys      map[string]jwksKey
+	fetchedAt time.Time
+}
+
+type jwksKey struct {
+	algorithm string
+	public    interface{}
+}
+
+// NewJWKSClient creates a client for the JWKS document at url
+func NewJWKSClient(url string, ttl time.Duration) *JWKSClient {
+	return &JWKSClient{
+		url:        url,
+		ttl:        ttl,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+		keys:       make(map[string]jwksKey),
+	}
+}
+
+func (c *JWKSClient) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, found, stale := c.lookup(kid)
+	if !found || stale {
+		if err := c.refresh(context.Background(), !found); err != nil && !found {
+			return nil, err
+		}
+		key, found, _ = c.lookup(kid)
+	}
+
+	if !found {
+		return nil, fmt.Errorf("unknown key ID %q", kid)
+	}
+	if key.algorithm != "" && key.algorithm != token.Method.Alg() {
+		return nil, fmt.Errorf("key %q does not sign with %s", kid, token.Method.Alg())
+	}
+	return key.public, nil
+}
+
+func (c *JWKSClient) Algorithms() []string {
+	return []string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodES256.Alg(), jwt.SigningMethodEdDSA.Alg()}
+}
+
+func (c *JWKSClient) lookup(kid string) (jwksKey, bool, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	key, found := c.keys[kid]
+	return key, found, time.Since(c.fetchedAt) > c.ttl
+}
+
+// refresh refetches the key set. Refreshes caused by unknown key IDs are
+// rate limited so that forged tokens cannot hammer the issuer.
+func (c *JWKSClient) refresh(ctx context.Context, unknownKey bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	sinceFetch := time.Since(c.fetchedAt)
+	if sinceFetch < jwksMinRefreshInterval || (!unknownKey && sinceFetch <= c.ttl) {
+		return nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("fetch JWKS: unexpected status %d", resp.StatusCode)
+	}
+
+	var set JWKS
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return fmt.Errorf("decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]jwksKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		public, err := jwk.PublicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.KeyID] = jwksKey{algorithm: jwk.Algorithm, public: public}
+	}
+
+	c.keys = keys
+	c.fetchedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,90 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// jwksServer publishes the JWKS of a key set that can be swapped
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	keys    *KeySet
+	fetches atomic.Int32
+}
+
+func newJWKSServer(t *testing.T, keys *KeySet) *jwksServer {
+	t.Helper()
+	srv := &jwksServer{keys: keys}
+	srv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		srv.fetches.Add(1)
+		srv.mu.Lock()
+		defer srv.mu.Unlock()
+		json.NewEncoder(w).Encode(srv.keys.JWKS())
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func (s *jwksServer) publish(keys *KeySet) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func TestJWKSClientPicksUpRotatedKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	writeKey(t, dir, "2025-01", rsaKey)
+	before, err := LoadKeySet(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := newJWKSServer(t, before)
+	client := NewJWKSClient(srv.URL, time.Hour)
+
+	if err := verifyTestToken(client, signTestToken(t, before)); err != nil {
+		t.Fatalf("RS256 token rejected: %v", err)
+	}
+
+	writeKey(t, dir, "2025-02", newEd25519Key(t))
+	after, err := LoadKeySet(dir, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv.publish(after)
+	rotated := signTestToken(t, after)
+
+	// Unknown key IDs right after a fetch do not hit the issuer again
+	if err := verifyTestToken(client, rotated); err == nil {
+		t.Error("token of an unpublished key accepted")
+	}
+	if n := srv.fetches.Load(); n != 1 {
+		t.Errorf("JWKS fetched %d times, want 1", n)
+	}
+
+	client.mu.Lock()
+	client.fetchedAt = time.Now().Add(-jwksMinRefreshInterval)
+	client.mu.Unlock()
+
+	if err := verifyTestToken(client, rotated); err != nil {
+		t.Errorf("token of the rotated key rejected: %v", err)
+	}
+	if err := verifyTestToken(client, signTestToken(t, before)); err != nil {
+		t.Errorf("token of the previous key rejected: %v", err)
+	}
+	if n := srv.fetches.Load(); n != 2 {
+		t.Errorf("JWKS fetched %d times, want 2", n)
+	}
+}
@@ -0,0 +1,117 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// writeKey stores key in dir as <id>.pem
+func writeKey(t *testing.T, dir, id string, key interface{}) {
+	t.Helper()
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(filepath.Join(dir, id+".pem"), data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newEd25519Key(t *testing.T) ed25519.PrivateKey {
+	t.Helper()
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func signTestToken(t *testing.T, signer TokenSigner) string {
+	t.Helper()
+	token, err := signer.Sign(jwt.RegisteredClaims{Subject: "1", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func verifyTestToken(verifier TokenVerifier, token string) error {
+	_, err := jwt.ParseWithClaims(token, &jwt.RegisteredClaims{}, verifier.Keyfunc, jwt.WithValidMethods(verifier.Algorithms()))
+	return err
+}
+
+func TestKeySetRotation(t *testing.T) {
+	dir := t.TempDir()
+	writeKey(t, dir, "2025-01", newEd25519Key(t))
+
+	before, err := LoadKeySet(dir, "")
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+	oldToken := signTestToken(t, before)
+
+	// Without an explicit active key the last key ID signs
+	writeKey(t, dir, "2025-02", newEd25519Key(t))
+	after, err := LoadKeySet(dir, "")
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+	newToken := signTestToken(t, after)
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(newToken, &jwt.RegisteredClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := parsed.Header["kid"]; kid != "2025-02" {
+		t.Errorf("new tokens signed with key %v, want 2025-02", kid)
+	}
+	if err := verifyTestToken(after, oldToken); err != nil {
+		t.Errorf("token signed before the rotation rejected: %v", err)
+	}
+	if err := verifyTestToken(after, newToken); err != nil {
+		t.Errorf("token signed after the rotation rejected: %v", err)
+	}
+
+	// Once the old key is removed its tokens stop verifying
+	if err := os.Remove(filepath.Join(dir, "2025-01.pem")); err != nil {
+		t.Fatal(err)
+	}
+	retired, err := LoadKeySet(dir, "2025-02")
+	if err != nil {
+		t.Fatalf("LoadKeySet: %v", err)
+	}
+	if err := verifyTestToken(retired, oldToken); err == nil {
+		t.Error("token of a removed key accepted")
+	}
+}
+
+func TestLoadKeySetRejectsBadConfiguration(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := LoadKeySet(dir, ""); err == nil {
+		t.Error("empty key directory accepted")
+	}
+
+	writeKey(t, dir, "2025-01", newEd25519Key(t))
+	if _, err := LoadKeySet(dir, "2024-12"); err == nil {
+		t.Error("missing active key accepted")
+	}
+
+	weak, err := rsa.GenerateKey(rand.Reader, 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeKey(t, dir, "2025-02", weak)
+	if _, err := LoadKeySet(dir, "2025-01"); err == nil {
+		t.Error("1024 bit RSA key accepted")
+	}
+}
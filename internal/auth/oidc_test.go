@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"kube/internal/auth/oidctest"
+	"kube/internal/config"
+)
+
+const testRedirectURL = "http://app.test/api/v1/users/oauth/stub/callback"
+
+func newTestOIDCProvider(t *testing.T) (*OIDCProvider, *oidctest.Provider) {
+	t.Helper()
+
+	stub := oidctest.NewProvider("client-id", "client-secret")
+	t.Cleanup(stub.Close)
+
+	provider := NewOIDCProvider(config.OIDCProviderConfig{
+		Name:         "stub",
+		IssuerURL:    stub.Issuer(),
+		ClientID:     stub.ClientID,
+		ClientSecret: stub.ClientSecret,
+	}, testRedirectURL)
+	return provider, stub
+}
+
+// login runs the authorization code flow for identity and returns the
+// verified claims. nonce is the value the client expects in the ID token.
+func login(t *testing.T, provider *OIDCProvider, stub *oidctest.Provider, identity oidctest.Identity, nonce string) (*OIDCClaims, error) {
+	t.Helper()
+
+	verifier, challenge, err := GeneratePKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := provider.AuthCodeURL(context.Background(), "state", nonce, challenge)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	code, _, err := stub.Authorize(authURL, identity)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	return provider.Exchange(context.Background(), code, verifier, nonce)
+}
+
+func TestGeneratePKCE(t *testing.T) {
+	verifier, challenge, err := GeneratePKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// RFC 7636 section 4.1: 43 to 128 characters from the unreserved set
+	if len(verifier) < 43 || len(verifier) > 128 || strings.ContainsAny(verifier, "+/=") {
+		t.Errorf("verifier %q is not a valid code verifier", verifier)
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	if want := base64.RawURLEncoding.EncodeToString(sum[:]); challenge != want {
+		t.Errorf("challenge = %s, want S256 of the verifier %s", challenge, want)
+	}
+
+	other, _, err := GeneratePKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == verifier {
+		t.Error("GeneratePKCE returned the same verifier twice")
+	}
+}
+
+func TestOIDCAuthCodeURL(t *testing.T) {
+	provider, stub := newTestOIDCProvider(t)
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "the-state", "the-nonce", "the-challenge")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := parsed.Scheme + "://" + parsed.Host + parsed.Path; got != stub.Issuer()+"/authorize" {
+		t.Errorf("endpoint = %s, want the discovered authorization endpoint", got)
+	}
+
+	want := map[string]string{
+		"response_type":         "code",
+		"client_id":             "client-id",
+		"redirect_uri":          testRedirectURL,
+		"scope":                 "openid email profile",
+		"state":                 "the-state",
+		"nonce":                 "the-nonce",
+		"code_challenge":        "the-challenge",
+		"code_challenge_method": "S256",
+	}
+	for name, value := range want {
+		if got := parsed.Query().Get(name); got != value {
+			t.Errorf("%s = %q, want %q", name, got, value)
+		}
+	}
+}
+
+func TestOIDCExchange(t *testing.T) {
+	provider, stub := newTestOIDCProvider(t)
+
+	claims, err := login(t, provider, stub, oidctest.Identity{
+		Subject:       "subject-1",
+		Email:         "Jane@Example.com",
+		EmailVerified: true,
+		GivenName:     "Jane",
+	}, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+
+	if claims.Subject != "subject-1" || claims.Email != "Jane@Example.com" || claims.GivenName != "Jane" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+	if !claims.IsEmailVerified() {
+		t.Error("email should be verified")
+	}
+}
+
+func TestOIDCExchangeEmailVerifiedAsString(t *testing.T) {
+	provider, stub := newTestOIDCProvider(t)
+
+	claims, err := login(t, provider, stub, oidctest.Identity{
+		Subject: "subject-1",
+		Email:   "jane@example.com",
+		Claims:  map[string]interface{}{"email_verified": "true"},
+	}, "nonce-1")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	if !claims.IsEmailVerified() {
+		t.Error(`email_verified "true" should be accepted`)
+	}
+}
+
+func TestOIDCExchangeRejectsWrongCodeVerifier(t *testing.T) {
+	provider, stub := newTestOIDCProvider(t)
+
+	_, challenge, err := GeneratePKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherVerifier, _, err := GeneratePKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "state", "nonce", challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _, err := stub.Authorize(authURL, oidctest.Identity{Subject: "subject-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := provider.Exchange(context.Background(), code, otherVerifier, "nonce"); err == nil {
+		t.Error("Exchange accepted a code verifier that does not match the challenge")
+	}
+}
+
+func TestOIDCExchangeRejectsReusedCode(t *testing.T) {
+	provider, stub := newTestOIDCProvider(t)
+
+	verifier, challenge, err := GeneratePKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	authURL, err := provider.AuthCodeURL(context.Background(), "state", "nonce", challenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, _, err := stub.Authorize(authURL, oidctest.Identity{Subject: "subject-1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := provider.Exchange(context.Background(), code, verifier, "nonce"); err != nil {
+		t.Fatalf("first Exchange: %v", err)
+	}
+	if _, err := provider.Exchange(context.Background(), code, verifier, "nonce"); err == nil {
+		t.Error("Exchange accepted a code twice")
+	}
+}
+
+func TestOIDCExchangeRejectsInvalidIDTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+	}{
+		{"wrong nonce", map[string]interface{}{"nonce": "other-nonce"}},
+		{"missing nonce", map[string]interface{}{"nonce": ""}},
+		{"wrong issuer", map[string]interface{}{"iss": "https://attacker.example"}},
+		{"wrong audience", map[string]interface{}{"aud": "other-client"}},
+		{"expired", map[string]interface{}{"exp": time.Now().Add(-5 * time.Minute).Unix()}},
+		{"no expiry", map[string]interface{}{"exp": nil}},
+		{"missing subject", map[string]interface{}{"sub": ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, stub := newTestOIDCProvider(t)
+
+			_, err := login(t, provider, stub, oidctest.Identity{
+				Subject:       "subject-1",
+				Email:         "jane@example.com",
+				EmailVerified: true,
+				Claims:        tt.claims,
+			}, "nonce-1")
+			if err == nil {
+				t.Error("Exchange accepted the ID token")
+			}
+		})
+	}
+}
+
+func TestOIDCExpiredIDTokenWithinLeeway(t *testing.T) {
+	provider, stub := newTestOIDCProvider(t)
+
+	_, err := login(t, provider, stub, oidctest.Identity{
+		Subject: "subject-1",
+		Claims:  map[string]interface{}{"exp": time.Now().Add(-10 * time.Second).Unix()},
+	}, "nonce-1")
+	if err != nil {
+		t.Errorf("Exchange rejected a token within the clock skew leeway: %v", err)
+	}
+}
+
+func TestOIDCDiscoveryRejectsIssuerMismatch(t *testing.T) {
+	stub := oidctest.NewProvider("client-id", "client-secret")
+	defer stub.Close()
+
+	// The discovery document announces stub.Issuer(), not the configured URL
+	provider := NewOIDCProvider(config.OIDCProviderConfig{
+		Name:      "stub",
+		IssuerURL: strings.Replace(stub.Issuer(), "127.0.0.1", "localhost", 1),
+		ClientID:  stub.ClientID,
+	}, testRedirectURL)
+
+	if _, err := provider.AuthCodeURL(context.Background(), "state", "nonce", "challenge"); err == nil {
+		t.Error("discovery accepted a document for another issuer")
+	}
+}
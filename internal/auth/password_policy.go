@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"kube/internal/config"
+	apperrors "kube/pkg/errors"
+)
+
+// Password policy rules reported in violations
+const (
+	PasswordRuleMinLength  = "min_length"
+	PasswordRuleMaxLength  = "max_length"
+	PasswordRuleUppercase  = "uppercase"
+	PasswordRuleLowercase  = "lowercase"
+	PasswordRuleDigit      = "digit"
+	PasswordRuleSymbol     = "symbol"
+	PasswordRuleSimilarity = "similarity"
+	PasswordRuleBreached   = "breached"
+)
+
+// bcryptMaxBytes is the length after which bcrypt ignores the password
+const bcryptMaxBytes = 72
+
+// minSimilarityLength is the shortest identifier checked for similarity, so
+// that short usernames do not rule out most passwords
+const minSimilarityLength = 3
+
+// PasswordPolicy decides which new passwords are acceptable
+type PasswordPolicy struct {
+	MinLength       int // characters
+	MaxBytes        int // bytes, bcrypt ignores everything after 72
+	RequireUpper    bool
+	RequireLower    bool
+	RequireDigit    bool
+	RequireSymbol   bool
+	CheckSimilarity bool               // reject passwords resembling the username or email
+	Breached        *BreachedPasswords // nil skips the breach check
+}
+
+// DefaultPasswordPolicy requires 8 to 72 bytes, no resemblance to the
+// account identifiers and no character classes
+func DefaultPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength:       8,
+		MaxBytes:        bcryptMaxBytes,
+		CheckSimilarity: true,
+	}
+}
+
+// NewPasswordPolicyFromConfig builds the policy from cfg and loads the
+// breached password file if one is configured
+func NewPasswordPolicyFromConfig(cfg config.PasswordConfig) (*PasswordPolicy, error) {
+	if cfg.MinLength < 1 || cfg.MaxBytes < cfg.MinLength {
+		return nil, fmt.Errorf("password length limits %d-%d are invalid", cfg.MinLength, cfg.MaxBytes)
+	}
+	if cfg.Algorithm == PasswordAlgorithmBcrypt && cfg.MaxBytes > bcryptMaxBytes {
+		return nil, fmt.Errorf("bcrypt only supports passwords up to %d bytes", bcryptMaxBytes)
+	}
+
+	policy := &PasswordPolicy{
+		MinLength:       cfg.MinLength,
+		MaxBytes:        cfg.MaxBytes,
+		RequireUpper:    cfg.RequireUpper,
+		RequireLower:    cfg.RequireLower,
+		RequireDigit:    cfg.RequireDigit,
+		RequireSymbol:   cfg.RequireSymbol,
+		CheckSimilarity: cfg.CheckSimilarity,
+	}
+
+	if cfg.BreachedFile != "" {
+		breached, err := LoadBreachedPasswords(cfg.BreachedFile)
+		if err != nil {
+			return nil, err
+		}
+		policy.Breached = breached
+	}
+	return policy, nil
+}
+
+// Check returns every rule the password violates. identifiers are values
+// the password must not resemble, such as the username and email.
+func (p *PasswordPolicy) Check(password string, identifiers ...string) []apperrors.RuleViolation {
+	var violations []apperrors.RuleViolation
+	add := func(rule, message string) {
+		violations = append(violations, apperrors.RuleViolation{Rule: rule, Message: message})
+	}
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		add(PasswordRuleMinLength, fmt.Sprintf("must be at least %d characters long", p.MinLength))
+	}
+	if p.MaxBytes > 0 && len(password) > p.MaxBytes {
+		add(PasswordRuleMaxLength, fmt.Sprintf("must be at most %d bytes long", p.MaxBytes))
+	}
+
+	var hasUpper, hasLower, hasDigit, hasSymbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			hasUpper = true
+		case unicode.IsLower(r):
+			hasLower = true
+		case unicode.IsDigit(r):
+			hasDigit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r) || unicode.IsSpace(r):
+			hasSymbol = true
+		}
+	}
+	if p.RequireUpper && !hasUpper {
+		add(PasswordRuleUppercase, "must contain an uppercase letter")
+	}
+	if p.RequireLower && !hasLower {
+		add(PasswordRuleLowercase, "must contain a lowercase letter")
+	}
+	if p.RequireDigit && !hasDigit {
+		add(PasswordRuleDigit, "must contain a digit")
+	}
+	if p.RequireSymbol && !hasSymbol {
+		add(PasswordRuleSymbol, "must contain a symbol or space")
+	}
+
+	if p.CheckSimilarity && resemblesAny(password, identifiers) {
+		add(PasswordRuleSimilarity, "must not contain or resemble your username or email")
+	}
+
+	if p.Breached != nil && p.Breached.Contains(password) {
+		add(PasswordRuleBreached, "appears in a list of breached passwords, choose another one")
+	}
+
+	return violations
+}
+
+// Validate returns an ErrCodeWeakPassword error listing every violated rule,
+// or nil when the password is acceptable
+func (p *PasswordPolicy) Validate(password string, identifiers ...string) error {
+	violations := p.Check(password, identifiers...)
+	if len(violations) == 0 {
+		return nil
+	}
+	return apperrors.NewPolicyError(apperrors.ErrCodeWeakPassword, "Password does not meet the requirements", violations)
+}
+
+// resemblesAny reports whether the password contains one of the
+// identifiers, or is contained in one, ignoring case. Emails are also
+// checked by their local part.
+func resemblesAny(password string, identifiers []string) bool {
+	password = strings.ToLower(password)
+
+	var candidates []string
+	for _, identifier := range identifiers {
+		identifier = strings.ToLower(strings.TrimSpace(identifier))
+		candidates = append(candidates, identifier)
+		if at := strings.LastIndex(identifier, "@"); at > 0 {
+			candidates = append(candidates, identifier[:at])
+		}
+	}
+
+	for _, candidate := range candidates {
+		if len(candidate) < minSimilarityLength {
+			continue
+		}
+		if strings.Contains(password, candidate) || strings.Contains(candidate, password) {
+			return true
+		}
+	}
+	return false
+}
+
+// breachPrefixLength is the number of hex digits of the SHA-1 hash used as
+// the bucket key, as in the k-anonymity range API of Have I Been Pwned
+const breachPrefixLength = 5
+
+// BreachedPasswords is an in-memory set of SHA-1 hashes of known breached
+// passwords, bucketed by hash prefix. Passwords are never stored.
+type BreachedPasswords struct {
+	buckets map[string][]string // prefix -> sorted suffixes
+	count   int
+}
+
+// LoadBreachedPasswords reads a file with one uppercase or lowercase hex
+// SHA-1 hash per line, optionally followed by ":<count>" as in the Pwned
+// Passwords downloads. Empty lines and lines starting with "#" are skipped.
+func LoadBreachedPasswords(path string) (*BreachedPasswords, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached password file: %w", err)
+	}
+	defer file.Close()
+
+	breached := &BreachedPasswords{buckets: make(map[string][]string)}
+
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		hash, _, _ := strings.Cut(text, ":")
+		hash = strings.ToUpper(hash)
+		if len(hash) != sha1.Size*2 {
+			return nil, fmt.Errorf("breached password file line %d: not a SHA-1 hash", line)
+		}
+		if _, err := hex.DecodeString(hash); err != nil {
+			return nil, fmt.Errorf("breached password file line %d: not a SHA-1 hash", line)
+		}
+
+		prefix := hash[:breachPrefixLength]
+		breached.buckets[prefix] = append(breached.buckets[prefix], hash[breachPrefixLength:])
+		breached.count++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read breached password file: %w", err)
+	}
+
+	for _, suffixes := range breached.buckets {
+		sort.Strings(suffixes)
+	}
+	return breached, nil
+}
+
+// Contains reports whether the password is in the breached set
+func (b *BreachedPasswords) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes := b.buckets[hash[:breachPrefixLength]]
+	suffix := hash[breachPrefixLength:]
+	i := sort.SearchStrings(suffixes, suffix)
+	return i < len(suffixes) && suffixes[i] == suffix
+}
+
+// Len returns the number of breached password hashes loaded
+func (b *BreachedPasswords) Len() int {
+	return b.count
+}
@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"kube/internal/config"
+)
+
+// rules lists the rules violated by the password
+func rules(policy *PasswordPolicy, password string, identifiers ...string) string {
+	var names []string
+	for _, violation := range policy.Check(password, identifiers...) {
+		names = append(names, violation.Rule)
+	}
+	return fmt.Sprint(names)
+}
+
+func writeBreachedFile(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func sha1Hex(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestPasswordPolicyCheck(t *testing.T) {
+	strict := &PasswordPolicy{MinLength: 10, MaxBytes: 72, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true, CheckSimilarity: true}
+
+	for _, tt := range []struct {
+		name        string
+		policy      *PasswordPolicy
+		password    string
+		identifiers []string
+		want        string
+	}{
+		{"default accepts a passphrase", DefaultPasswordPolicy(), "correct horse battery", []string{"jane", "jane@example.com"}, "[]"},
+		{"too short", DefaultPasswordPolicy(), "short", nil, "[min_length]"},
+		{"length counts characters", DefaultPasswordPolicy(), "pässwört", nil, "[]"},
+		{"longer than bcrypt supports", DefaultPasswordPolicy(), strings.Repeat("a", 73), nil, "[max_length]"},
+		{"all violations at once", strict, "abc", nil, "[min_length uppercase digit symbol]"},
+		{"meets every class", strict, "Correct horse 42", nil, "[]"},
+		{"contains the username", DefaultPasswordPolicy(), "JaneDoe2024!", []string{"janedoe", "jd@example.com"}, "[similarity]"},
+		{"contains the email local part", DefaultPasswordPolicy(), "mr.smith-rules", []string{"bob", "mr.smith@example.com"}, "[similarity]"},
+		{"short usernames are ignored", DefaultPasswordPolicy(), "bo the builder", []string{"bo", "x@example.com"}, "[]"},
+		{"similarity check disabled", &PasswordPolicy{MinLength: 8}, "janedoe2024", []string{"janedoe"}, "[]"},
+	} {
+		if got := rules(tt.policy, tt.password, tt.identifiers...); got != tt.want {
+			t.Errorf("%s: violations %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestBreachedPasswords(t *testing.T) {
+	path := writeBreachedFile(t,
+		"# Pwned Passwords sample",
+		strings.ToUpper(sha1Hex("password123"))+":2254650",
+		"",
+		sha1Hex("letmein!!"),
+	)
+	breached, err := LoadBreachedPasswords(path)
+	if err != nil {
+		t.Fatalf("LoadBreachedPasswords: %v", err)
+	}
+	if breached.Len() != 2 {
+		t.Errorf("Len = %d, want 2", breached.Len())
+	}
+	for password, want := range map[string]bool{"password123": true, "letmein!!": true, "correct horse battery": false} {
+		if got := breached.Contains(password); got != want {
+			t.Errorf("Contains(%q) = %v, want %v", password, got, want)
+		}
+	}
+
+	policy := DefaultPasswordPolicy()
+	policy.Breached = breached
+	if got := rules(policy, "password123"); got != "[breached]" {
+		t.Errorf("violations %s, want [breached]", got)
+	}
+
+	if _, err := LoadBreachedPasswords(writeBreachedFile(t, "not a hash")); err == nil {
+		t.Error("malformed breached password file accepted")
+	}
+}
+
+func TestNewPasswordPolicyFromConfig(t *testing.T) {
+	breachedFile := writeBreachedFile(t, sha1Hex("password123"))
+
+	for _, tt := range []struct {
+		name string
+		cfg  config.PasswordConfig
+		ok   bool
+	}{
+		{"defaults", config.PasswordConfig{Algorithm: "bcrypt", MinLength: 8, MaxBytes: 72}, true},
+		{"breached file", config.PasswordConfig{Algorithm: "bcrypt", MinLength: 8, MaxBytes: 72, BreachedFile: breachedFile}, true},
+		{"missing breached file", config.PasswordConfig{Algorithm: "bcrypt", MinLength: 8, MaxBytes: 72, BreachedFile: breachedFile + ".missing"}, false},
+		{"no minimum", config.PasswordConfig{Algorithm: "bcrypt", MaxBytes: 72}, false},
+		{"maximum below minimum", config.PasswordConfig{Algorithm: "bcrypt", MinLength: 8, MaxBytes: 6}, false},
+		{"long passwords with bcrypt", config.PasswordConfig{Algorithm: "bcrypt", MinLength: 8, MaxBytes: 128}, false},
+		{"long passwords with argon2id", config.PasswordConfig{Algorithm: "argon2id", MinLength: 8, MaxBytes: 128}, true},
+	} {
+		policy, err := NewPasswordPolicyFromConfig(tt.cfg)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: error = %v", tt.name, err)
+		}
+		if err == nil && (tt.cfg.BreachedFile != "") != (policy.Breached != nil) {
+			t.Errorf("%s: breached list loaded = %v", tt.name, policy.Breached != nil)
+		}
+	}
+}
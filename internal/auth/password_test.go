@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"kube/internal/config"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestPasswordHashers(t *testing.T) {
+	for _, hasher := range []PasswordHasher{
+		NewBcryptHasher(bcrypt.MinCost),
+		NewArgon2idHasher(64, 1, 1),
+	} {
+		encoded, err := hasher.Hash("correct horse battery")
+		if err != nil {
+			t.Fatalf("%T.Hash: %v", hasher, err)
+		}
+		if again, _ := hasher.Hash("correct horse battery"); again == encoded {
+			t.Errorf("%T hashes are not salted", hasher)
+		}
+
+		if ok, err := hasher.Verify(encoded, "correct horse battery"); !ok || err != nil {
+			t.Errorf("%T.Verify(right password) = (%v, %v)", hasher, ok, err)
+		}
+		if ok, err := hasher.Verify(encoded, "wrong horse battery"); ok || err != nil {
+			t.Errorf("%T.Verify(wrong password) = (%v, %v), want (false, nil)", hasher, ok, err)
+		}
+		if hasher.NeedsRehash(encoded) {
+			t.Errorf("%T wants to rehash its own hash", hasher)
+		}
+	}
+
+	if _, err := VerifyPassword("plaintext", "plaintext"); err != ErrUnknownHashFormat {
+		t.Errorf("VerifyPassword(unknown format) error = %v, want ErrUnknownHashFormat", err)
+	}
+}
+
+func TestNeedsRehash(t *testing.T) {
+	bcryptHash, _ := NewBcryptHasher(bcrypt.MinCost).Hash("correct horse battery")
+	argon2Hash, _ := NewArgon2idHasher(64, 1, 1).Hash("correct horse battery")
+
+	for _, tt := range []struct {
+		name    string
+		hasher  PasswordHasher
+		encoded string
+		want    bool
+	}{
+		{"bcrypt with a higher cost", NewBcryptHasher(bcrypt.MinCost + 1), bcryptHash, true},
+		{"bcrypt to argon2id", NewArgon2idHasher(64, 1, 1), bcryptHash, true},
+		{"argon2id to bcrypt", NewBcryptHasher(bcrypt.MinCost), argon2Hash, true},
+		{"argon2id with more memory", NewArgon2idHasher(128, 1, 1), argon2Hash, true},
+		{"argon2id with more iterations", NewArgon2idHasher(64, 2, 1), argon2Hash, true},
+		{"same argon2id parameters", NewArgon2idHasher(64, 1, 1), argon2Hash, false},
+		// Other implementations write "$2b$" and "$2y$"
+		{"bcrypt in another variant", NewBcryptHasher(bcrypt.MinCost), "$2y$" + strings.TrimPrefix(bcryptHash, "$2a$"), false},
+	} {
+		if got := tt.hasher.NeedsRehash(tt.encoded); got != tt.want {
+			t.Errorf("%s: NeedsRehash = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestNewPasswordHasherFromConfig(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		cfg  config.PasswordConfig
+		ok   bool
+	}{
+		{"bcrypt", config.PasswordConfig{Algorithm: "bcrypt", BcryptCost: 10}, true},
+		{"bcrypt cost too low", config.PasswordConfig{Algorithm: "bcrypt", BcryptCost: 3}, false},
+		{"argon2id", config.PasswordConfig{Algorithm: "argon2id", Argon2Memory: 65536, Argon2Iterations: 3, Argon2Parallelism: 2}, true},
+		{"argon2id without iterations", config.PasswordConfig{Algorithm: "argon2id", Argon2Memory: 65536, Argon2Parallelism: 2}, false},
+		{"argon2id with too little memory", config.PasswordConfig{Algorithm: "argon2id", Argon2Memory: 8, Argon2Iterations: 3, Argon2Parallelism: 2}, false},
+		{"unknown algorithm", config.PasswordConfig{Algorithm: "md5"}, false},
+	} {
+		_, err := NewPasswordHasherFromConfig(tt.cfg)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: error = %v", tt.name, err)
+		}
+	}
+}
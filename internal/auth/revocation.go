@@ -0,0 +1,191 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RevocationStore keeps track of access tokens that must be rejected before
+// they expire. Entries only need to live as long as the tokens they revoke.
+type RevocationStore interface {
+	// Revoke rejects the token with the given ID until expiresAt
+	Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error
+	// RevokeIfAbsent revokes the token like Revoke unless it is already
+	// revoked, and reports whether this call revoked it. Single-use tokens
+	// are consumed with it, so that concurrent uses cannot both succeed.
+	RevokeIfAbsent(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error)
+	// IsRevoked reports whether the token with the given ID has been revoked
+	IsRevoked(ctx context.Context, tokenID string) (bool, error)
+	// RevokeUser rejects every token issued to the user before now. The
+	// cut-off has millisecond precision and is remembered until expiresAt.
+	RevokeUser(ctx context.Context, userID uint, expiresAt time.Time) error
+	// UserRevokedAt returns the cut-off set by RevokeUser, or the zero time
+	UserRevokedAt(ctx context.Context, userID uint) (time.Time, error)
+}
+
+// IsClaimsRevoked checks the token ID, the session ID and the per-user
+// cut-off. Session IDs share the token ID namespace since both are UUIDs.
+// Tokens issued in the same millisecond as the cut-off are accepted, so that
+// a login right after a password reset or logout-all keeps working.
+func IsClaimsRevoked(ctx context.Context, store RevocationStore, tokenID, sessionID string, userID uint, issuedAt time.Time) (bool, error) {
+	for _, id := range []string{tokenID, sessionID} {
+		if id == "" {
+			continue
+		}
+		revoked, err := store.IsRevoked(ctx, id)
+		if err != nil || revoked {
+			return revoked, err
+		}
+	}
+
+	cutoff, err := store.UserRevokedAt(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return !cutoff.IsZero() && issuedAt.Before(cutoff), nil
+}
+
+// MemoryRevocationStore is an in-process RevocationStore for tests and
+// single-instance development setups
+type MemoryRevocationStore struct {
+	mu     sync.Mutex
+	tokens map[string]time.Time
+	users  map[uint]memoryCutoff
+}
+
+type memoryCutoff struct {
+	at        time.Time
+	expiresAt time.Time
+}
+
+// NewMemoryRevocationStore creates an empty in-memory revocation store
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		tokens: make(map[string]time.Time),
+		users:  make(map[uint]memoryCutoff),
+	}
+}
+
+func (s *MemoryRevocationStore) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[tokenID] = expiresAt
+	return nil
+}
+
+func (s *MemoryRevocationStore) RevokeIfAbsent(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if current, ok := s.tokens[tokenID]; ok && !time.Now().After(current) {
+		return false, nil
+	}
+	s.tokens[tokenID] = expiresAt
+	return true, nil
+}
+
+func (s *MemoryRevocationStore) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	expiresAt, ok := s.tokens[tokenID]
+	if !ok {
+		return false, nil
+	}
+	if time.Now().After(expiresAt) {
+		delete(s.tokens, tokenID)
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s *MemoryRevocationStore) RevokeUser(ctx context.Context, userID uint, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.users[userID] = memoryCutoff{at: time.Now().Truncate(time.Millisecond), expiresAt: expiresAt}
+	return nil
+}
+
+func (s *MemoryRevocationStore) UserRevokedAt(ctx context.Context, userID uint) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff, ok := s.users[userID]
+	if !ok {
+		return time.Time{}, nil
+	}
+	if time.Now().After(cutoff.expiresAt) {
+		delete(s.users, userID)
+		return time.Time{}, nil
+	}
+	return cutoff.at, nil
+}
+
+// RedisRevocationStore is a RevocationStore shared by every service instance
+type RedisRevocationStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisRevocationStore creates a revocation store backed by Redis
+func NewRedisRevocationStore(client *redis.Client) *RedisRevocationStore {
+	return &RedisRevocationStore{
+		client: client,
+		prefix: "auth:revoked:",
+	}
+}
+
+func (s *RedisRevocationStore) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return s.client.Set(ctx, s.prefix+"token:"+tokenID, 1, ttl).Err()
+}
+
+func (s *RedisRevocationStore) RevokeIfAbsent(ctx context.Context, tokenID string, expiresAt time.Time) (bool, error) {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		// An expired token is rejected anyway, there is nothing to consume
+		return false, nil
+	}
+	return s.client.SetNX(ctx, s.prefix+"token:"+tokenID, 1, ttl).Result()
+}
+
+func (s *RedisRevocationStore) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	n, err := s.client.Exists(ctx, s.prefix+"token:"+tokenID).Result()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (s *RedisRevocationStore) RevokeUser(ctx context.Context, userID uint, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return s.client.Set(ctx, s.userKey(userID), time.Now().UnixMilli(), ttl).Err()
+}
+
+func (s *RedisRevocationStore) UserRevokedAt(ctx context.Context, userID uint) (time.Time, error) {
+	value, err := s.client.Get(ctx, s.userKey(userID)).Int64()
+	if err == redis.Nil {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.UnixMilli(value), nil
+}
+
+func (s *RedisRevocationStore) userKey(userID uint) string {
+	return s.prefix + "user:" + strconv.FormatUint(uint64(userID), 10)
+}
@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryRevocationStoreRevokeIfAbsent(t *testing.T) {
+	store := NewMemoryRevocationStore()
+	ctx := context.Background()
+
+	ok, err := store.RevokeIfAbsent(ctx, "token", time.Now().Add(time.Minute))
+	if err != nil || !ok {
+		t.Fatalf("first RevokeIfAbsent = (%v, %v), want (true, nil)", ok, err)
+	}
+	if ok, _ := store.RevokeIfAbsent(ctx, "token", time.Now().Add(time.Minute)); ok {
+		t.Error("RevokeIfAbsent revoked an already revoked token")
+	}
+	if revoked, _ := store.IsRevoked(ctx, "token"); !revoked {
+		t.Error("token not revoked")
+	}
+
+	// Expired entries no longer count
+	store.Revoke(ctx, "expired", time.Now().Add(-time.Second))
+	if ok, _ := store.RevokeIfAbsent(ctx, "expired", time.Now().Add(time.Minute)); !ok {
+		t.Error("RevokeIfAbsent refused a token whose revocation expired")
+	}
+}
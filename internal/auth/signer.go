@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+
+	"kube/internal/config"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// TokenVerifier resolves the keys used to verify access tokens
+type TokenVerifier interface {
+	// Keyfunc returns the verification key for a parsed token
+	Keyfunc(token *jwt.Token) (interface{}, error)
+	// Algorithms lists the accepted "alg" header values
+	Algorithms() []string
+}
+
+// TokenSigner signs access tokens and verifies the tokens it issued
+type TokenSigner interface {
+	TokenVerifier
+	Sign(claims jwt.Claims) (string, error)
+}
+
+// NewSignerFromConfig returns an HMAC signer for HS256 and a key set loaded
+// from cfg.KeysDir for asymmetric algorithms
+func NewSignerFromConfig(cfg config.JWTConfig) (TokenSigner, error) {
+	if cfg.Algorithm == "" || cfg.Algorithm == jwt.SigningMethodHS256.Alg() {
+		return NewHMACSigner(cfg.SecretKey), nil
+	}
+
+	keys, err := LoadKeySet(cfg.KeysDir, cfg.ActiveKeyID)
+	if err != nil {
+		return nil, err
+	}
+	if alg := keys.ActiveAlgorithm(); alg != cfg.Algorithm {
+		return nil, fmt.Errorf("active key %q uses %s but JWT_ALGORITHM is %s", cfg.ActiveKeyID, alg, cfg.Algorithm)
+	}
+	return keys, nil
+}
+
+// NewVerifierFromConfig returns the verifier a service should use for access
+// tokens. Services configured with a JWKS URL only need the issuer's public
+// keys; otherwise the local signer configuration is used.
+func NewVerifierFromConfig(cfg config.JWTConfig) (TokenVerifier, error) {
+	if cfg.JWKSURL != "" {
+		return NewJWKSClient(cfg.JWKSURL, time.Duration(cfg.JWKSCacheTTL)*time.Second), nil
+	}
+	return NewSignerFromConfig(cfg)
+}
+
+// HMACSigner signs and verifies HS256 tokens with a shared secret
+type HMACSigner struct {
+	secret []byte
+}
+
+func NewHMACSigner(secret string) *HMACSigner {
+	return &HMACSigner{secret: []byte(secret)}
+}
+
+func (s *HMACSigner) Sign(claims jwt.Claims) (string, error) {
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secret)
+}
+
+func (s *HMACSigner) Keyfunc(token *jwt.Token) (interface{}, error) {
+	return s.secret, nil
+}
+
+func (s *HMACSigner) Algorithms() []string {
+	return []string{jwt.SigningMethodHS256.Alg()}
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// StateStore keeps short-lived, single-use values such as the PKCE verifier
+// of an OAuth authorization request between redirect and callback
+type StateStore interface {
+	// Save stores value under key until ttl elapses
+	Save(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Consume returns and deletes the value stored under key. ok is false when
+	// the key is unknown or has expired.
+	Consume(ctx context.Context, key string) (value []byte, ok bool, err error)
+}
+
+// MemoryStateStore is an in-process StateStore for tests and single-instance
+// development setups
+type MemoryStateStore struct {
+	mu     sync.Mutex
+	values map[string]memoryState
+}
+
+type memoryState struct {
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemoryStateStore creates an empty in-memory state store
+func NewMemoryStateStore() *MemoryStateStore {
+	return &MemoryStateStore{values: make(map[string]memoryState)}
+}
+
+func (s *MemoryStateStore) Save(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, state := range s.values {
+		if now.After(state.expiresAt) {
+			delete(s.values, k)
+		}
+	}
+
+	s.values[key] = memoryState{value: value, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStateStore) Consume(ctx context.Context, key string) ([]byte, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, ok := s.values[key]
+	if !ok {
+		return nil, false, nil
+	}
+	delete(s.values, key)
+
+	if time.Now().After(state.expiresAt) {
+		return nil, false, nil
+	}
+	return state.value, true, nil
+}
+
+// RedisStateStore is a StateStore shared by every service instance, so that
+// the callback may be served by a different instance than the redirect
+type RedisStateStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisStateStore creates a state store backed by Redis
+func NewRedisStateStore(client *redis.Client) *RedisStateStore {
+	return &RedisStateStore{
+		client: client,
+		prefix: "auth:state:",
+	}
+}
+
+func (s *RedisStateStore) Save(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, s.prefix+key, value, ttl).Err()
+}
+
+func (s *RedisStateStore) Consume(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := s.client.GetDel(ctx, s.prefix+key).Bytes()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
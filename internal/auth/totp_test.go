@@ -0,0 +1,146 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secrets are the seeds of the RFC 6238 Appendix B test vectors
+var rfc6238Secrets = map[string][]byte{
+	"SHA1":   []byte("12345678901234567890"),
+	"SHA256": []byte("12345678901234567890123456789012"),
+	"SHA512": []byte("1234567890123456789012345678901234567890123456789012345678901234"),
+}
+
+func rfc6238TOTP(algorithm string) *TOTP {
+	return &TOTP{
+		Secret:    rfc6238Secrets[algorithm],
+		Period:    30 * time.Second,
+		Digits:    8,
+		Algorithm: algorithm,
+	}
+}
+
+func TestTOTPCodeRFC6238Vectors(t *testing.T) {
+	tests := []struct {
+		unix      int64
+		algorithm string
+		code      string
+	}{
+		{59, "SHA1", "94287082"},
+		{59, "SHA256", "46119246"},
+		{59, "SHA512", "90693936"},
+		{1111111109, "SHA1", "07081804"},
+		{1111111109, "SHA256", "68084774"},
+		{1111111109, "SHA512", "25091201"},
+		{1111111111, "SHA1", "14050471"},
+		{1111111111, "SHA256", "67062674"},
+		{1111111111, "SHA512", "99943326"},
+		{1234567890, "SHA1", "89005924"},
+		{1234567890, "SHA256", "91819424"},
+		{1234567890, "SHA512", "93441116"},
+		{2000000000, "SHA1", "69279037"},
+		{2000000000, "SHA256", "90698825"},
+		{2000000000, "SHA512", "38618901"},
+		{20000000000, "SHA1", "65353130"},
+		{20000000000, "SHA256", "77737706"},
+		{20000000000, "SHA512", "47863826"},
+	}
+
+	for _, tt := range tests {
+		totp := rfc6238TOTP(tt.algorithm)
+		at := time.Unix(tt.unix, 0)
+		if got := totp.Code(at); got != tt.code {
+			t.Errorf("%s Code(%d) = %s, want %s", tt.algorithm, tt.unix, got, tt.code)
+		}
+		if _, ok := totp.Validate(tt.code, at, 0); !ok {
+			t.Errorf("%s Validate(%s, %d) rejected the RFC code", tt.algorithm, tt.code, tt.unix)
+		}
+	}
+}
+
+func TestTOTPDefaultDigitsTruncateCode(t *testing.T) {
+	totp := NewTOTP(rfc6238Secrets["SHA1"])
+	if got, want := totp.Code(time.Unix(59, 0)), "287082"; got != want {
+		t.Errorf("Code = %s, want %s", got, want)
+	}
+}
+
+func TestTOTPValidateSkew(t *testing.T) {
+	totp := rfc6238TOTP("SHA1")
+	issued := time.Unix(1111111109, 0) // step 37037036
+	code := totp.Code(issued)
+	period := 30 * time.Second
+
+	tests := []struct {
+		name     string
+		at       time.Time
+		skew     int
+		wantOK   bool
+		wantStep int64
+	}{
+		{"same step", issued, 0, true, 37037036},
+		{"next step without skew", issued.Add(period), 0, false, 0},
+		{"next step within skew", issued.Add(period), 1, true, 37037036},
+		{"previous step within skew", issued.Add(-period), 1, true, 37037036},
+		{"two steps later with skew 1", issued.Add(2 * period), 1, false, 0},
+		{"two steps later with skew 2", issued.Add(2 * period), 2, true, 37037036},
+	}
+
+	for _, tt := range tests {
+		step, ok := totp.Validate(code, tt.at, tt.skew)
+		if ok != tt.wantOK || step != tt.wantStep {
+			t.Errorf("%s: Validate = (%d, %v), want (%d, %v)", tt.name, step, ok, tt.wantStep, tt.wantOK)
+		}
+	}
+}
+
+func TestTOTPValidateRejectsMalformedCodes(t *testing.T) {
+	totp := rfc6238TOTP("SHA1")
+	at := time.Unix(59, 0)
+
+	for _, code := range []string{"", "9428708", "942870820", "00000000"} {
+		if _, ok := totp.Validate(code, at, 1); ok {
+			t.Errorf("Validate(%q) accepted an invalid code", code)
+		}
+	}
+}
+
+func TestTOTPValidateAfterRejectsReusedStep(t *testing.T) {
+	totp := rfc6238TOTP("SHA1")
+	at := time.Unix(1234567890, 0)
+	code := totp.Code(at)
+
+	step, ok := totp.ValidateAfter(code, at, 1, 0)
+	if !ok {
+		t.Fatal("first use of the code was rejected")
+	}
+	if _, ok := totp.ValidateAfter(code, at, 1, step); ok {
+		t.Error("the code was accepted again for an already used step")
+	}
+	// Still inside the skew window, but the step was consumed
+	if _, ok := totp.ValidateAfter(code, at.Add(30*time.Second), 1, step); ok {
+		t.Error("the code was accepted again in the next period")
+	}
+
+	next := totp.Code(at.Add(30 * time.Second))
+	if nextStep, ok := totp.ValidateAfter(next, at.Add(30*time.Second), 1, step); !ok || nextStep != step+1 {
+		t.Errorf("code of the following step = (%d, %v), want (%d, true)", nextStep, ok, step+1)
+	}
+}
+
+func TestTOTPSecretRoundTrip(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := DecodeTOTPSecret(strings.ToLower(secret[:4] + " " + secret[4:]))
+	if err != nil {
+		t.Fatalf("DecodeTOTPSecret: %v", err)
+	}
+	if len(decoded) != TOTPDefaultSecretSize {
+		t.Errorf("decoded secret has %d bytes, want %d", len(decoded), TOTPDefaultSecretSize)
+	}
+}
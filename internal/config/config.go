@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -11,6 +12,14 @@ type Config struct {
 	Database DatabaseConfig
 	Redis    RedisConfig
 	JWT      JWTConfig
+	Auth     AuthConfig
+	Password PasswordConfig
+	Mail     MailConfig
+	OIDC     OIDCConfig
+	Account  AccountConfig
+	Storage  StorageConfig
+	Avatar   AvatarConfig
+	Video    VideoConfig
 }
 
 type DatabaseConfig struct {
@@ -30,8 +39,109 @@ type RedisConfig struct {
 }
 
 type JWTConfig struct {
-	SecretKey string
-	ExpiresIn int // hours
+	SecretKey        string
+	ExpiresIn        int    // hours
+	RefreshExpiresIn int    // hours
+	Algorithm        string // "HS256", "RS256" or "EdDSA"
+	KeysDir          string // directory of <kid>.pem private keys for RS256/EdDSA
+	ActiveKeyID      string // key ID used for signing, defaults to the last key
+	JWKSURL          string // verify tokens with keys fetched from this URL
+	JWKSCacheTTL     int    // seconds
+}
+
+type AuthConfig struct {
+	PasswordResetExpiresIn     int  // minutes
+	PasswordResetInterval      int  // seconds between reset emails to one address
+	PasswordResetIPLimit       int  // reset requests per client IP within the window
+	PasswordResetIPWindow      int  // seconds
+	EmailVerificationExpiresIn int  // hours
+	VerificationResendInterval int  // seconds
+	RequireEmailVerification   bool // reject logins until the email is verified
+	MFAIssuer                  string
+	MFAChallengeExpiresIn      int // minutes
+	LockoutThreshold           int // failed logins before an account is locked
+	LockoutBaseDuration        int // seconds, doubled on every consecutive lockout
+	LockoutMaxDuration         int // seconds
+	IPFailureThreshold         int // failed logins per client IP within the window
+	IPFailureWindow            int // seconds
+	// APIKeyIntrospectionURL is the user service endpoint the other services
+	// validate API keys with; API keys are rejected there when it is empty
+	APIKeyIntrospectionURL    string
+	APIKeyIntrospectionSecret string // shared by the user service and its callers
+}
+
+// PasswordConfig selects how new passwords are hashed and which passwords
+// are accepted. Stored hashes made with another algorithm or other
+// parameters are upgraded on the next login.
+type PasswordConfig struct {
+	Algorithm         string // "bcrypt" or "argon2id"
+	BcryptCost        int
+	Argon2Memory      int // KiB
+	Argon2Iterations  int
+	Argon2Parallelism int
+	MinLength         int // characters
+	MaxBytes          int // at most 72 with bcrypt
+	RequireUpper      bool
+	RequireLower      bool
+	RequireDigit      bool
+	RequireSymbol     bool
+	CheckSimilarity   bool   // reject passwords resembling the username or email
+	BreachedFile      string // SHA-1 hashes of breached passwords, one per line
+}
+
+type MailConfig struct {
+	Driver    string // "log" or "file"
+	From      string
+	OutputDir string // used by the file driver
+	AppURL    string // base URL for links in emails
+}
+
+// AccountConfig controls the lifecycle of deleted accounts. Deleted accounts
+// can be restored during the grace period and are purged after retention.
+type AccountConfig struct {
+	RestoreGracePeriod int // hours
+	PurgeRetention     int // hours
+	PurgeInterval      int // minutes, 0 disables the purger
+}
+
+// StorageConfig selects where uploaded files are kept. The local driver
+// writes them below LocalDir, from where the service serves them at
+// PublicURL.
+type StorageConfig struct {
+	Driver    string // "local"
+	LocalDir  string
+	PublicURL string // base URL of stored files
+}
+
+// AvatarConfig limits the images accepted as profile pictures
+type AvatarConfig struct {
+	MaxSize      int // bytes
+	MaxDimension int // pixels, for both width and height
+}
+
+// VideoConfig limits video uploads. Uploads still running after
+// UploadTimeout fail; the sweeper fails uploads abandoned by a crashed
+// process once they are past that timeout.
+type VideoConfig struct {
+	MaxSize       int // bytes
+	UploadTimeout int // minutes
+	SweepInterval int // minutes, 0 disables the sweeper
+}
+
+type OIDCConfig struct {
+	Providers      []OIDCProviderConfig
+	StateExpiresIn int // minutes
+}
+
+// OIDCProviderConfig describes an OpenID Connect provider used for social
+// login. Providers are listed in OIDC_PROVIDERS and configured with
+// OIDC_<NAME>_ISSUER_URL, _CLIENT_ID, _CLIENT_SECRET and _SCOPES.
+type OIDCProviderConfig struct {
+	Name         string
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
 }
 
 func Load() *Config {
@@ -54,12 +164,95 @@ func Load() *Config {
 			DB:       getEnvAsInt("REDIS_DB", 0),
 		},
 		JWT: JWTConfig{
-			SecretKey: getEnv("JWT_SECRET", "your-secret-key"),
-			ExpiresIn: getEnvAsInt("JWT_EXPIRES_IN", 24),
+			SecretKey:        getEnv("JWT_SECRET", "your-secret-key"),
+			ExpiresIn:        getEnvAsInt("JWT_EXPIRES_IN", 1),
+			RefreshExpiresIn: getEnvAsInt("JWT_REFRESH_EXPIRES_IN", 720),
+			Algorithm:        getEnv("JWT_ALGORITHM", "HS256"),
+			KeysDir:          getEnv("JWT_KEYS_DIR", "keys"),
+			ActiveKeyID:      getEnv("JWT_ACTIVE_KEY_ID", ""),
+			JWKSURL:          getEnv("JWT_JWKS_URL", ""),
+			JWKSCacheTTL:     getEnvAsInt("JWT_JWKS_CACHE_TTL", 300),
+		},
+		Auth: AuthConfig{
+			PasswordResetExpiresIn:     getEnvAsInt("PASSWORD_RESET_EXPIRES_IN", 30),
+			PasswordResetInterval:      getEnvAsInt("PASSWORD_RESET_INTERVAL", 60),
+			PasswordResetIPLimit:       getEnvAsInt("PASSWORD_RESET_IP_LIMIT", 10),
+			PasswordResetIPWindow:      getEnvAsInt("PASSWORD_RESET_IP_WINDOW", 3600),
+			EmailVerificationExpiresIn: getEnvAsInt("EMAIL_VERIFICATION_EXPIRES_IN", 48),
+			VerificationResendInterval: getEnvAsInt("EMAIL_VERIFICATION_RESEND_INTERVAL", 60),
+			RequireEmailVerification:   getEnvAsBool("REQUIRE_EMAIL_VERIFICATION", false),
+			MFAIssuer:                  getEnv("MFA_ISSUER", "Kube"),
+			MFAChallengeExpiresIn:      getEnvAsInt("MFA_CHALLENGE_EXPIRES_IN", 5),
+			LockoutThreshold:           getEnvAsInt("LOCKOUT_THRESHOLD", 5),
+			LockoutBaseDuration:        getEnvAsInt("LOCKOUT_BASE_DURATION", 60),
+			LockoutMaxDuration:         getEnvAsInt("LOCKOUT_MAX_DURATION", 3600),
+			IPFailureThreshold:         getEnvAsInt("LOGIN_IP_FAILURE_THRESHOLD", 20),
+			IPFailureWindow:            getEnvAsInt("LOGIN_IP_FAILURE_WINDOW", 900),
+			APIKeyIntrospectionURL:     getEnv("API_KEY_INTROSPECTION_URL", ""),
+			APIKeyIntrospectionSecret:  getEnv("API_KEY_INTROSPECTION_SECRET", ""),
+		},
+		Password: PasswordConfig{
+			Algorithm:         getEnv("PASSWORD_HASH_ALGORITHM", "bcrypt"),
+			BcryptCost:        getEnvAsInt("PASSWORD_BCRYPT_COST", 10),
+			Argon2Memory:      getEnvAsInt("PASSWORD_ARGON2_MEMORY", 65536),
+			Argon2Iterations:  getEnvAsInt("PASSWORD_ARGON2_ITERATIONS", 3),
+			Argon2Parallelism: getEnvAsInt("PASSWORD_ARGON2_PARALLELISM", 2),
+			MinLength:         getEnvAsInt("PASSWORD_MIN_LENGTH", 8),
+			MaxBytes:          getEnvAsInt("PASSWORD_MAX_BYTES", 72),
+			RequireUpper:      getEnvAsBool("PASSWORD_REQUIRE_UPPER", false),
+			RequireLower:      getEnvAsBool("PASSWORD_REQUIRE_LOWER", false),
+			RequireDigit:      getEnvAsBool("PASSWORD_REQUIRE_DIGIT", false),
+			RequireSymbol:     getEnvAsBool("PASSWORD_REQUIRE_SYMBOL", false),
+			CheckSimilarity:   getEnvAsBool("PASSWORD_CHECK_SIMILARITY", true),
+			BreachedFile:      getEnv("PASSWORD_BREACHED_FILE", ""),
+		},
+		Mail: MailConfig{
+			Driver:    getEnv("MAIL_DRIVER", "log"),
+			From:      getEnv("MAIL_FROM", "no-reply@example.com"),
+			OutputDir: getEnv("MAIL_OUTPUT_DIR", "output/mail"),
+			AppURL:    getEnv("APP_URL", "http://localhost:8081"),
+		},
+		OIDC: OIDCConfig{
+			Providers:      loadOIDCProviders(),
+			StateExpiresIn: getEnvAsInt("OIDC_STATE_EXPIRES_IN", 10),
+		},
+		Account: AccountConfig{
+			RestoreGracePeriod: getEnvAsInt("ACCOUNT_RESTORE_GRACE_PERIOD", 720),
+			PurgeRetention:     getEnvAsInt("ACCOUNT_PURGE_RETENTION", 720),
+			PurgeInterval:      getEnvAsInt("ACCOUNT_PURGE_INTERVAL", 60),
+		},
+		Storage: StorageConfig{
+			Driver:    getEnv("STORAGE_DRIVER", "local"),
+			LocalDir:  getEnv("STORAGE_LOCAL_DIR", "output/storage"),
+			PublicURL: getEnv("STORAGE_PUBLIC_URL", "http://localhost:8081/media"),
+		},
+		Avatar: AvatarConfig{
+			MaxSize:      getEnvAsInt("AVATAR_MAX_SIZE", 2<<20),
+			MaxDimension: getEnvAsInt("AVATAR_MAX_DIMENSION", 4096),
+		},
+		Video: VideoConfig{
+			MaxSize:       getEnvAsInt("VIDEO_MAX_SIZE", 2<<30),
+			UploadTimeout: getEnvAsInt("VIDEO_UPLOAD_TIMEOUT", 360),
+			SweepInterval: getEnvAsInt("VIDEO_SWEEP_INTERVAL", 15),
 		},
 	}
 }
 
+func loadOIDCProviders() []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+	for _, name := range getEnvAsList("OIDC_PROVIDERS") {
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		providers = append(providers, OIDCProviderConfig{
+			Name:         strings.ToLower(name),
+			IssuerURL:    getEnv(prefix+"ISSUER_URL", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			Scopes:       getEnvAsList(prefix + "SCOPES"),
+		})
+	}
+	return providers
+}
+
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -75,3 +268,22 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+func getEnvAsList(key string) []string {
+	var values []string
+	for _, value := range strings.Split(os.Getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
@@ -0,0 +1,45 @@
+package database
+
+import (
+	"kube/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// migrationLockID identifies the advisory lock held while migrating, see
+// Migrate
+const migrationLockID = 4_242_001
+
+// Migrate creates or updates the tables of all services. The services share
+// one database and their tables reference each other, for example videos
+// reference channels and users, so every service migrates the whole schema
+// in dependency order instead of only its own tables. On Postgres the
+// migration runs in a transaction holding an advisory lock, so services
+// starting together on a fresh database do not race each other.
+func Migrate(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if tx.Dialector.Name() == "postgres" {
+			if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", migrationLockID).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.AutoMigrate(
+			&models.User{},
+			&models.Session{},
+			&models.RefreshToken{},
+			&models.Role{},
+			&models.Permission{},
+			&models.UserToken{},
+			&models.RecoveryCode{},
+			&models.Identity{},
+			&models.APIKey{},
+			&models.AuditEvent{},
+			&models.Channel{},
+			&models.Subscription{},
+			&models.ChannelMember{},
+			&models.ChannelInvitation{},
+			&models.Video{},
+		)
+	})
+}
@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"kube/internal/config"
+
+	"github.com/redis/go-redis/v9"
+)
+
+var Redis *redis.Client
+
+func InitRedis(cfg config.RedisConfig) *redis.Client {
+	client := redis.NewClient(&redis.Options{
+		Addr:     fmt.Sprintf("%s:%d", cfg.Host, cfg.Port),
+		Password: cfg.Password,
+		DB:       cfg.DB,
+	})
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		log.Fatal("Failed to connect to redis:", err)
+	}
+
+	Redis = client
+	log.Println("Redis connected successfully")
+	return client
+}
+
+func GetRedis() *redis.Client {
+	return Redis
+}
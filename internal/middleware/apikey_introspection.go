@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// APIKeyIntrospectionHeader carries the secret shared between the user
+// service and the services introspecting API keys through it
+const APIKeyIntrospectionHeader = "X-Introspection-Secret"
+
+// APIKeyIntrospectionRequest is the body of an API key introspection call
+type APIKeyIntrospectionRequest struct {
+	Key      string `json:"key"`
+	ClientIP string `json:"client_ip"`
+}
+
+// APIKeyIntrospectionResponse tells whether the key is usable and, if so,
+// with which claims
+type APIKeyIntrospectionResponse struct {
+	Active bool    `json:"active"`
+	Claims *Claims `json:"claims,omitempty"`
+}
+
+// APIKeyIntrospector validates API keys by asking the user service, which
+// owns the keys. Services other than the user service use it as their
+// APIKeyValidator. Results are not cached so that revoked keys stop working
+// immediately.
+type APIKeyIntrospector struct {
+	url        string
+	secret     string
+	httpClient *http.Client
+}
+
+// NewAPIKeyIntrospector creates a client for the introspection endpoint at url
+func NewAPIKeyIntrospector(url, secret string) *APIKeyIntrospector {
+	return &APIKeyIntrospector{
+		url:        url,
+		secret:     secret,
+		httpClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+func (i *APIKeyIntrospector) ValidateAPIKey(ctx context.Context, key, clientIP string) (*Claims, bool, error) {
+	body, err := json.Marshal(APIKeyIntrospectionRequest{Key: key, ClientIP: clientIP})
+	if err != nil {
+		return nil, false, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, i.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(APIKeyIntrospectionHeader, i.secret)
+
+	resp, err := i.httpClient.Do(req)
+	if err != nil {
+		return nil, false, fmt.Errorf("introspect API key: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, false, fmt.Errorf("introspect API key: unexpected status %d", resp.StatusCode)
+	}
+
+	var result APIKeyIntrospectionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, false, fmt.Errorf("decode API key introspection: %w", err)
+	}
+	if !result.Active || result.Claims == nil {
+		return nil, false, nil
+	}
+	return result.Claims, true, nil
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// introspectionServer answers like the user service, knowing only "valid-key"
+func introspectionServer(t *testing.T, secret string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(APIKeyIntrospectionHeader) != secret {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var req APIKeyIntrospectionRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		resp := APIKeyIntrospectionResponse{}
+		if req.Key == "valid-key" && req.ClientIP == "10.0.0.1" {
+			resp = APIKeyIntrospectionResponse{Active: true, Claims: &Claims{UserID: 7, Permissions: []string{"videos:upload"}}}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestAPIKeyIntrospector(t *testing.T) {
+	srv := introspectionServer(t, "shared-secret")
+	introspector := NewAPIKeyIntrospector(srv.URL, "shared-secret")
+	ctx := context.Background()
+
+	claims, ok, err := introspector.ValidateAPIKey(ctx, "valid-key", "10.0.0.1")
+	if err != nil || !ok {
+		t.Fatalf("ValidateAPIKey = (%v, %v), want a valid key", ok, err)
+	}
+	if claims.UserID != 7 || len(claims.Permissions) != 1 || claims.Permissions[0] != "videos:upload" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	if claims, ok, err := introspector.ValidateAPIKey(ctx, "revoked-key", "10.0.0.1"); err != nil || ok || claims != nil {
+		t.Errorf("ValidateAPIKey = (%+v, %v, %v), want an invalid key", claims, ok, err)
+	}
+}
+
+func TestAPIKeyIntrospectorReportsFailures(t *testing.T) {
+	srv := introspectionServer(t, "shared-secret")
+	ctx := context.Background()
+
+	// A misconfigured secret must not look like an invalid key
+	if _, ok, err := NewAPIKeyIntrospector(srv.URL, "wrong-secret").ValidateAPIKey(ctx, "valid-key", "10.0.0.1"); err == nil || ok {
+		t.Errorf("ValidateAPIKey with a wrong secret = (%v, %v), want an error", ok, err)
+	}
+
+	srv.Close()
+	if _, ok, err := NewAPIKeyIntrospector(srv.URL, "shared-secret").ValidateAPIKey(ctx, "valid-key", "10.0.0.1"); err == nil || ok {
+		t.Errorf("ValidateAPIKey with the user service down = (%v, %v), want an error", ok, err)
+	}
+}
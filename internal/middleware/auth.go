@@ -3,6 +3,9 @@ package middleware
 import (
 	"context"
 	"strings"
+	"time"
+
+	"kube/internal/auth"
 
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/common/utils"
@@ -10,12 +13,84 @@ import (
 )
 
 type Claims struct {
-	UserID uint   `json:"user_id"`
-	Email  string `json:"email"`
+	UserID        uint     `json:"user_id"`
+	Email         string   `json:"email"`
+	SessionID     string   `json:"sid,omitempty"`
+	IsAdmin       bool     `json:"is_admin,omitempty"`
+	EmailVerified bool     `json:"email_verified"`
+	Roles         []string `json:"roles,omitempty"`
+	Permissions   []string `json:"permissions,omitempty"`
+	// IssuedAtMilli is the issue time in Unix milliseconds. The standard
+	// iat claim has whole seconds, too coarse to tell a token issued right
+	// after a RevokeUser cut-off from one issued before it.
+	IssuedAtMilli int64 `json:"iat_ms,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func AuthMiddleware(secretKey string) app.HandlerFunc {
+// IssueTime returns the issue time compared with revocation cut-offs
+func (c *Claims) IssueTime() time.Time {
+	if c.IssuedAtMilli != 0 {
+		return time.UnixMilli(c.IssuedAtMilli)
+	}
+	if c.IssuedAt != nil {
+		return c.IssuedAt.Time
+	}
+	return time.Time{}
+}
+
+// Authentication methods recorded by AuthMiddleware
+const (
+	AuthMethodToken  = "token"
+	AuthMethodAPIKey = "api_key"
+)
+
+// APIKeyValidator resolves API keys presented as "Authorization: ApiKey <key>"
+// into the claims of their owner. ok is false for unknown, expired or
+// revoked keys; err is reserved for failures to check the key.
+type APIKeyValidator interface {
+	ValidateAPIKey(ctx context.Context, key, clientIP string) (claims *Claims, ok bool, err error)
+}
+
+// AuthOption configures optional AuthMiddleware behaviour
+type AuthOption func(*authOptions)
+
+type authOptions struct {
+	revocations auth.RevocationStore
+	verifier    auth.TokenVerifier
+	apiKeys     APIKeyValidator
+}
+
+// WithRevocationStore rejects tokens that have been revoked before expiry
+func WithRevocationStore(store auth.RevocationStore) AuthOption {
+	return func(o *authOptions) {
+		o.revocations = store
+	}
+}
+
+// WithTokenVerifier verifies tokens with the given verifier instead of the
+// shared HS256 secret, e.g. a key set or a JWKS client for asymmetric tokens
+func WithTokenVerifier(verifier auth.TokenVerifier) AuthOption {
+	return func(o *authOptions) {
+		o.verifier = verifier
+	}
+}
+
+// WithAPIKeyValidator additionally accepts "Authorization: ApiKey <key>"
+func WithAPIKeyValidator(validator APIKeyValidator) AuthOption {
+	return func(o *authOptions) {
+		o.apiKeys = validator
+	}
+}
+
+func AuthMiddleware(secretKey string, opts ...AuthOption) app.HandlerFunc {
+	options := &authOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.verifier == nil {
+		options.verifier = auth.NewHMACSigner(secretKey)
+	}
+
 	return func(ctx context.Context, c *app.RequestContext) {
 		authHeader := string(c.GetHeader("Authorization"))
 		if authHeader == "" {
@@ -24,6 +99,24 @@ func AuthMiddleware(secretKey string) app.HandlerFunc {
 			return
 		}
 
+		if options.apiKeys != nil && strings.HasPrefix(authHeader, "ApiKey ") {
+			claims, ok, err := options.apiKeys.ValidateAPIKey(ctx, strings.TrimPrefix(authHeader, "ApiKey "), c.ClientIP())
+			if err != nil {
+				c.JSON(503, utils.H{"error": "Unable to verify API key"})
+				c.Abort()
+				return
+			}
+			if !ok {
+				c.JSON(401, utils.H{"error": "Invalid API key"})
+				c.Abort()
+				return
+			}
+
+			setClaims(c, claims, AuthMethodAPIKey)
+			c.Next(ctx)
+			return
+		}
+
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		if tokenString == authHeader {
 			c.JSON(401, utils.H{"error": "Bearer token required"})
@@ -31,9 +124,8 @@ func AuthMiddleware(secretKey string) app.HandlerFunc {
 			return
 		}
 
-		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-			return []byte(secretKey), nil
-		})
+		token, err := jwt.ParseWithClaims(tokenString, &Claims{}, options.verifier.Keyfunc,
+			jwt.WithValidMethods(options.verifier.Algorithms()))
 
 		if err != nil || !token.Valid {
 			c.JSON(401, utils.H{"error": "Invalid token"})
@@ -41,15 +133,71 @@ func AuthMiddleware(secretKey string) app.HandlerFunc {
 			return
 		}
 
+		// Access tokens carry no audience; tokens issued by the same signer
+		// for other purposes, such as MFA challenges, always have one
 		claims, ok := token.Claims.(*Claims)
-		if !ok {
+		if !ok || len(claims.Audience) > 0 {
 			c.JSON(401, utils.H{"error": "Invalid token claims"})
 			c.Abort()
 			return
 		}
 
-		c.Set("user_id", claims.UserID)
-		c.Set("email", claims.Email)
+		if options.revocations != nil {
+			revoked, err := auth.IsClaimsRevoked(ctx, options.revocations, claims.ID, claims.SessionID, claims.UserID, claims.IssueTime())
+			if err != nil {
+				c.JSON(503, utils.H{"error": "Unable to verify token status"})
+				c.Abort()
+				return
+			}
+			if revoked {
+				c.JSON(401, utils.H{"error": "Token has been revoked"})
+				c.Abort()
+				return
+			}
+		}
+
+		setClaims(c, claims, AuthMethodToken)
 		c.Next(ctx)
 	}
 }
+
+func setClaims(c *app.RequestContext, claims *Claims, method string) {
+	c.Set("user_id", claims.UserID)
+	c.Set("email", claims.Email)
+	c.Set("is_admin", claims.IsAdmin)
+	c.Set("email_verified", claims.EmailVerified)
+	c.Set("roles", claims.Roles)
+	c.Set("permissions", claims.Permissions)
+	c.Set("auth_method", method)
+	c.Set("claims", claims)
+}
+
+// GetAuthMethod returns how the request was authenticated
+func GetAuthMethod(c *app.RequestContext) string {
+	return c.GetString("auth_method")
+}
+
+// GetClaims returns the token claims stored by AuthMiddleware
+func GetClaims(c *app.RequestContext) (*Claims, bool) {
+	value, exists := c.Get("claims")
+	if !exists {
+		return nil, false
+	}
+	claims, ok := value.(*Claims)
+	return claims, ok
+}
+
+// GetUserID returns the authenticated user ID stored by AuthMiddleware
+func GetUserID(c *app.RequestContext) (uint, bool) {
+	value, exists := c.Get("user_id")
+	if !exists {
+		return 0, false
+	}
+	userID, ok := value.(uint)
+	return userID, ok
+}
+
+// IsAdmin reports whether the authenticated user is an administrator
+func IsAdmin(c *app.RequestContext) bool {
+	return c.GetBool("is_admin")
+}
@@ -0,0 +1,124 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"kube/internal/auth"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/route"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// failingRevocationStore cannot be reached, like Redis being down
+type failingRevocationStore struct {
+	*auth.MemoryRevocationStore
+}
+
+func (failingRevocationStore) IsRevoked(ctx context.Context, tokenID string) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+// protectedEngine serves GET / behind AuthMiddleware, answering with the
+// authenticated user ID
+func protectedEngine(signer auth.TokenSigner, opts ...AuthOption) *route.Engine {
+	engine := route.NewEngine(config.NewOptions(nil))
+	opts = append([]AuthOption{WithTokenVerifier(signer)}, opts...)
+	engine.GET("/", AuthMiddleware("", opts...), func(ctx context.Context, c *app.RequestContext) {
+		userID, _ := GetUserID(c)
+		c.JSON(200, map[string]uint{"user_id": userID})
+	})
+	return engine
+}
+
+func signToken(t *testing.T, signer auth.TokenSigner, claims Claims) string {
+	t.Helper()
+	if claims.ExpiresAt == nil {
+		claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(time.Hour))
+	}
+	token, err := signer.Sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func performGet(engine *route.Engine, authorization string) int {
+	var headers []ut.Header
+	if authorization != "" {
+		headers = append(headers, ut.Header{Key: "Authorization", Value: authorization})
+	}
+	return ut.PerformRequest(engine, "GET", "/", nil, headers...).Result().StatusCode()
+}
+
+func TestAuthMiddlewareChecksRevocations(t *testing.T) {
+	signer := auth.NewHMACSigner("test-secret")
+	store := auth.NewMemoryRevocationStore()
+	engine := protectedEngine(signer, WithRevocationStore(store))
+	ctx := context.Background()
+	now := time.Now()
+
+	valid := signToken(t, signer, Claims{UserID: 1, SessionID: "session-1", RegisteredClaims: jwt.RegisteredClaims{ID: "token-1"}})
+	if got := performGet(engine, "Bearer "+valid); got != 200 {
+		t.Fatalf("valid token: status %d, want 200", got)
+	}
+
+	store.Revoke(ctx, "token-1", now.Add(time.Hour))
+	if got := performGet(engine, "Bearer "+valid); got != 401 {
+		t.Errorf("revoked token: status %d, want 401", got)
+	}
+
+	sessionToken := signToken(t, signer, Claims{UserID: 1, SessionID: "session-2", RegisteredClaims: jwt.RegisteredClaims{ID: "token-2"}})
+	store.Revoke(ctx, "session-2", now.Add(time.Hour))
+	if got := performGet(engine, "Bearer "+sessionToken); got != 401 {
+		t.Errorf("token of a revoked session: status %d, want 401", got)
+	}
+
+	// Logging out everywhere rejects what was issued before, to the millisecond
+	before := signToken(t, signer, Claims{UserID: 2, IssuedAtMilli: now.Add(-time.Millisecond).UnixMilli()})
+	store.RevokeUser(ctx, 2, now.Add(time.Hour))
+	after := signToken(t, signer, Claims{UserID: 2, IssuedAtMilli: time.Now().Add(time.Millisecond).UnixMilli()})
+	if got := performGet(engine, "Bearer "+before); got != 401 {
+		t.Errorf("token issued before the cut-off: status %d, want 401", got)
+	}
+	if got := performGet(engine, "Bearer "+after); got != 200 {
+		t.Errorf("token issued after the cut-off: status %d, want 200", got)
+	}
+}
+
+func TestAuthMiddlewareRejectsInvalidTokens(t *testing.T) {
+	signer := auth.NewHMACSigner("test-secret")
+	engine := protectedEngine(signer, WithRevocationStore(auth.NewMemoryRevocationStore()))
+
+	for _, tt := range []struct {
+		name          string
+		authorization string
+	}{
+		{"missing header", ""},
+		{"not a bearer token", "Basic dXNlcjpwYXNz"},
+		{"garbage", "Bearer not-a-jwt"},
+		{"other secret", "Bearer " + signToken(t, auth.NewHMACSigner("other-secret"), Claims{UserID: 1})},
+		{"expired", "Bearer " + signToken(t, signer, Claims{UserID: 1, RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(-time.Minute))}})},
+		{"MFA challenge", "Bearer " + signToken(t, signer, Claims{UserID: 1, RegisteredClaims: jwt.RegisteredClaims{Audience: jwt.ClaimStrings{"mfa"}}})},
+		{"API key without a validator", "ApiKey kube_0123456789abcdef_secret"},
+	} {
+		if got := performGet(engine, tt.authorization); got != 401 {
+			t.Errorf("%s: status %d, want 401", tt.name, got)
+		}
+	}
+}
+
+func TestAuthMiddlewareFailsClosedWithoutRevocationStore(t *testing.T) {
+	signer := auth.NewHMACSigner("test-secret")
+	engine := protectedEngine(signer, WithRevocationStore(failingRevocationStore{auth.NewMemoryRevocationStore()}))
+
+	token := signToken(t, signer, Claims{UserID: 1, RegisteredClaims: jwt.RegisteredClaims{ID: "token-1"}})
+	if got := performGet(engine, "Bearer "+token); got != 503 {
+		t.Errorf("status %d, want 503", got)
+	}
+}
@@ -40,6 +40,60 @@ func CustomDocsHandler() app.HandlerFunc {
 					"Description": "User login",
 					"Color":       "green",
 				},
+				{
+					"Method":      "POST",
+					"Path":        "/api/v1/users/login/mfa",
+					"Description": "Complete login with TOTP or recovery code",
+					"Color":       "green",
+				},
+				{
+					"Method":      "POST",
+					"Path":        "/api/v1/users/me/mfa/totp",
+					"Description": "Start TOTP enrollment",
+					"Color":       "blue",
+				},
+				{
+					"Method":      "POST",
+					"Path":        "/api/v1/users/token/refresh",
+					"Description": "Refresh access token",
+					"Color":       "green",
+				},
+				{
+					"Method":      "GET",
+					"Path":        "/api/v1/users/verify",
+					"Description": "Verify email address",
+					"Color":       "purple",
+				},
+				{
+					"Method":      "POST",
+					"Path":        "/api/v1/users/verify/resend",
+					"Description": "Resend verification email",
+					"Color":       "blue",
+				},
+				{
+					"Method":      "POST",
+					"Path":        "/api/v1/users/password/forgot",
+					"Description": "Request password reset email",
+					"Color":       "blue",
+				},
+				{
+					"Method":      "POST",
+					"Path":        "/api/v1/users/password/reset",
+					"Description": "Reset password with token",
+					"Color":       "blue",
+				},
+				{
+					"Method":      "POST",
+					"Path":        "/api/v1/users/logout",
+					"Description": "Logout current or all sessions",
+					"Color":       "green",
+				},
+				{
+					"Method":      "GET",
+					"Path":        "/api/v1/users",
+					"Description": "List users with filters and cursor pagination",
+					"Color":       "purple",
+				},
 				{
 					"Method":      "GET",
 					"Path":        "/api/v1/users/{id}",
@@ -52,12 +106,120 @@ func CustomDocsHandler() app.HandlerFunc {
 					"Description": "Update user information",
 					"Color":       "orange",
 				},
+				{
+					"Method":      "PATCH",
+					"Path":        "/api/v1/users/{id}",
+					"Description": "Partially update user information (JSON merge patch)",
+					"Color":       "orange",
+				},
 				{
 					"Method":      "DELETE",
 					"Path":        "/api/v1/users/{id}",
 					"Description": "Delete user",
 					"Color":       "red",
 				},
+				{
+					"Method":      "POST",
+					"Path":        "/api/v1/users/{id}/restore",
+					"Description": "Restore a deleted user within the grace period",
+					"Color":       "blue",
+				},
+				{
+					"Method":      "POST",
+					"Path":        "/api/v1/users/{id}/unlock",
+					"Description": "Unlock account after failed logins",
+					"Color":       "orange",
+				},
+				{
+					"Method":      "GET",
+					"Path":        "/api/v1/roles",
+					"Description": "List roles and permissions",
+					"Color":       "purple",
+				},
+				{
+					"Method":      "GET",
+					"Path":        "/api/v1/audit-events",
+					"Description": "Query the account audit log",
+					"Color":       "purple",
+				},
+				{
+					"Method":      "POST",
+					"Path":        "/api/v1/users/{id}/roles",
+					"Description": "Grant role to user",
+					"Color":       "blue",
+				},
+				{
+					"Method":      "DELETE",
+					"Path":        "/api/v1/users/{id}/roles/{role}",
+					"Description": "Revoke role from user",
+					"Color":       "red",
+				},
+				{
+					"Method":      "GET",
+					"Path":        "/api/v1/users/me/sessions",
+					"Description": "List active sessions",
+					"Color":       "purple",
+				},
+				{
+					"Method":      "DELETE",
+					"Path":        "/api/v1/users/me/sessions/{id}",
+					"Description": "Revoke a session",
+					"Color":       "red",
+				},
+				{
+					"Method":      "PUT",
+					"Path":        "/api/v1/users/me/avatar",
+					"Description": "Upload an avatar image",
+					"Color":       "orange",
+				},
+				{
+					"Method":      "GET",
+					"Path":        "/api/v1/users/me/export",
+					"Description": "Download a personal data export (JSON or ZIP)",
+					"Color":       "purple",
+				},
+				{
+					"Method":      "POST",
+					"Path":        "/api/v1/users/me/erasure",
+					"Description": "Erase personal data of the current user",
+					"Color":       "blue",
+				},
+				{
+					"Method":      "GET",
+					"Path":        "/api/v1/users/me/api-keys",
+					"Description": "List personal API keys",
+					"Color":       "purple",
+				},
+				{
+					"Method":      "POST",
+					"Path":        "/api/v1/users/me/api-keys",
+					"Description": "Create a personal API key",
+					"Color":       "blue",
+				},
+				{
+					"Method":      "DELETE",
+					"Path":        "/api/v1/users/me/api-keys/{keyId}",
+					"Description": "Revoke a personal API key",
+					"Color":       "red",
+				},
+				{
+					"Method":      "GET",
+					"Path":        "/api/v1/users/oauth/{provider}/authorize",
+					"Description": "Start social login with an OIDC provider",
+					"Color":       "purple",
+				},
+				{
+					"Method":      "GET",
+					"Path":        "/api/v1/users/oauth/{provider}/callback",
+					"Description": "Complete social login",
+					"Color":       "purple",
+				},
+				{
+					"Method":      "GET",
+					"Path":        "/.well-known/jwks.json",
+					"Description": "Public keys for verifying access tokens",
+					"Color":       "purple",
+				},
 			},
 		}
 
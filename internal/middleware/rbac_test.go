@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/common/config"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/route"
+)
+
+// authenticateAs stands in for AuthMiddleware
+func authenticateAs(claims *Claims, method string) app.HandlerFunc {
+	return func(ctx context.Context, c *app.RequestContext) {
+		setClaims(c, claims, method)
+		c.Next(ctx)
+	}
+}
+
+func TestRequireAPIKeyScope(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		claims *Claims
+		method string
+		want   int
+	}{
+		{"session without the permission", &Claims{UserID: 1}, AuthMethodToken, 200},
+		{"key with the scope", &Claims{UserID: 1, Permissions: []string{"videos:upload"}}, AuthMethodAPIKey, 200},
+		{"key without the scope", &Claims{UserID: 1, Permissions: []string{"users:read"}}, AuthMethodAPIKey, 403},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := route.NewEngine(config.NewOptions(nil))
+			engine.GET("/", authenticateAs(tt.claims, tt.method), RequireAPIKeyScope("videos:upload"), func(ctx context.Context, c *app.RequestContext) {
+				c.Status(200)
+			})
+
+			if got := ut.PerformRequest(engine, "GET", "/", nil).Result().StatusCode(); got != tt.want {
+				t.Errorf("status = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequireTokenAuth(t *testing.T) {
+	for _, tt := range []struct {
+		method string
+		want   int
+	}{
+		{AuthMethodToken, 200},
+		{AuthMethodAPIKey, 403},
+	} {
+		engine := route.NewEngine(config.NewOptions(nil))
+		engine.GET("/", authenticateAs(&Claims{UserID: 1, IsAdmin: true}, tt.method), RequireTokenAuth(), func(ctx context.Context, c *app.RequestContext) {
+			c.Status(200)
+		})
+
+		if got := ut.PerformRequest(engine, "GET", "/", nil).Result().StatusCode(); got != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.method, got, tt.want)
+		}
+	}
+}
+
+func TestRequirePermission(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		claims *Claims
+		want   int
+	}{
+		{"holder", &Claims{UserID: 1, Permissions: []string{"users:read"}}, 200},
+		{"other permission", &Claims{UserID: 1, Permissions: []string{"videos:upload"}}, 403},
+		{"admin", &Claims{UserID: 1, IsAdmin: true}, 200},
+	} {
+		engine := route.NewEngine(config.NewOptions(nil))
+		engine.GET("/", authenticateAs(tt.claims, AuthMethodToken), RequirePermission("users:read"), func(ctx context.Context, c *app.RequestContext) {
+			c.Status(200)
+		})
+
+		if got := ut.PerformRequest(engine, "GET", "/", nil).Result().StatusCode(); got != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRequireRole(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		claims *Claims
+		want   int
+	}{
+		{"holder", &Claims{UserID: 1, Roles: []string{"moderator", "user"}}, 200},
+		{"other role", &Claims{UserID: 1, Roles: []string{"user"}}, 403},
+	} {
+		engine := route.NewEngine(config.NewOptions(nil))
+		engine.GET("/", authenticateAs(tt.claims, AuthMethodToken), RequireRole("moderator"), func(ctx context.Context, c *app.RequestContext) {
+			c.Status(200)
+		})
+
+		if got := ut.PerformRequest(engine, "GET", "/", nil).Result().StatusCode(); got != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRequireVerifiedEmail(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		claims *Claims
+		want   int
+	}{
+		{"verified", &Claims{UserID: 1, EmailVerified: true}, 200},
+		{"unverified", &Claims{UserID: 1}, 403},
+	} {
+		engine := route.NewEngine(config.NewOptions(nil))
+		engine.GET("/", authenticateAs(tt.claims, AuthMethodToken), RequireVerifiedEmail(), func(ctx context.Context, c *app.RequestContext) {
+			c.Status(200)
+		})
+
+		if got := ut.PerformRequest(engine, "GET", "/", nil).Result().StatusCode(); got != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
@@ -1,30 +1,148 @@
 package storage
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
 	"kube/internal/config"
 )
 
+// DriverLocal keeps files on the local filesystem
+const DriverLocal = "local"
+
+var (
+	// ErrInvalidPath is returned for paths that are empty or point outside
+	// the storage root
+	ErrInvalidPath = errors.New("invalid storage path")
+	// ErrNotFound is returned when downloading a file that does not exist
+	ErrNotFound = errors.New("file not found")
+)
+
+// Client stores files by slash separated paths such as
+// "avatars/42/128.png". Only the local driver is implemented.
 type Client struct {
-	config *config.Config
+	config    *config.Config
+	root      string
+	publicURL string
 }
 
 func Init(cfg *config.Config) *Client {
 	return &Client{
-		config: cfg,
+		config:    cfg,
+		root:      cfg.Storage.LocalDir,
+		publicURL: strings.TrimRight(cfg.Storage.PublicURL, "/"),
 	}
 }
 
+// UploadFile stores data at filePath, replacing an existing file
 func (c *Client) UploadFile(filePath string, data []byte) error {
-	// TODO: Implement file upload logic
-	return nil
+	_, err := c.Upload(filePath, bytes.NewReader(data))
+	return err
+}
+
+// Upload streams r to filePath until EOF, replacing an existing file, and
+// returns the number of bytes written. The file is written to a temporary
+// name first, so readers never see a partial file and a failed upload
+// leaves nothing behind.
+func (c *Client) Upload(filePath string, r io.Reader) (int64, error) {
+	target, err := c.resolve(filePath)
+	if err != nil {
+		return 0, err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return 0, err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".upload-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return written, err
+	}
+	if err := tmp.Close(); err != nil {
+		return written, err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return written, err
+	}
+	return written, os.Rename(tmp.Name(), target)
 }
 
 func (c *Client) DownloadFile(filePath string) ([]byte, error) {
-	// TODO: Implement file download logic
-	return nil, nil
+	target, err := c.resolve(filePath)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(target)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
 }
 
+// DeleteFile removes the file at filePath. Deleting a missing file is not an
+// error. The enclosing directory is removed as well once it is empty, so
+// that files stored under unique directories leave nothing behind.
 func (c *Client) DeleteFile(filePath string) error {
-	// TODO: Implement file deletion logic
+	target, err := c.resolve(filePath)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if dir := filepath.Dir(target); dir != filepath.Clean(c.root) {
+		os.Remove(dir) // fails while the directory is not empty
+	}
 	return nil
 }
+
+// DeleteDir removes the directory at dirPath with everything below it.
+// Deleting a missing directory is not an error.
+func (c *Client) DeleteDir(dirPath string) error {
+	target, err := c.resolve(dirPath)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(target)
+}
+
+// URL returns the public URL of the file at filePath
+func (c *Client) URL(filePath string) string {
+	return c.publicURL + "/" + strings.TrimLeft(path.Clean("/"+filePath), "/")
+}
+
+// Path returns the storage path of a URL returned by URL, ignoring any query
+// string. ok is false for URLs that do not point into this storage.
+func (c *Client) Path(fileURL string) (filePath string, ok bool) {
+	fileURL, _, _ = strings.Cut(fileURL, "?")
+	filePath, ok = strings.CutPrefix(fileURL, c.publicURL+"/")
+	if !ok || filePath == "" {
+		return "", false
+	}
+	return filePath, true
+}
+
+// resolve maps a storage path to a location below the storage root
+func (c *Client) resolve(filePath string) (string, error) {
+	if c.config.Storage.Driver != DriverLocal {
+		return "", fmt.Errorf("unsupported storage driver %q", c.config.Storage.Driver)
+	}
+
+	cleaned := path.Clean("/" + filePath)
+	if cleaned == "/" || strings.Contains(filePath, "\\") {
+		return "", ErrInvalidPath
+	}
+	return filepath.Join(c.root, filepath.FromSlash(cleaned)), nil
+}
@@ -0,0 +1,67 @@
+// Package testutil provides the fixtures shared by the service tests: an
+// in-process SQLite database with the full schema and local storage in a
+// temporary directory, so the tests need no running services.
+package testutil
+
+import (
+	"path/filepath"
+	"testing"
+
+	"kube/internal/config"
+	"kube/internal/database"
+	"kube/internal/storage"
+	apperrors "kube/pkg/errors"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// NewDB returns a fresh SQLite database migrated like the services migrate
+// Postgres, with foreign keys enforced. It is closed with the test.
+func NewDB(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open(filepath.Join(t.TempDir(), "test.db")+"?_pragma=foreign_keys(1)"), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// SQLite allows a single writer, one connection avoids busy errors
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := database.Migrate(db); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// StorageConfig returns local storage in dir, served from http://media.test
+func StorageConfig(dir string) config.StorageConfig {
+	return config.StorageConfig{Driver: storage.DriverLocal, LocalDir: dir, PublicURL: "http://media.test"}
+}
+
+// AssertErrorCode fails the test unless err is an application error with
+// code
+func AssertErrorCode(t testing.TB, err error, code string) {
+	t.Helper()
+	if appErr := apperrors.GetAppError(err); appErr == nil || appErr.Code != code {
+		t.Fatalf("error = %v, want code %s", err, code)
+	}
+}
+
+// CountRows returns the number of rows of model matching query
+func CountRows(t testing.TB, db *gorm.DB, model interface{}, query string, args ...interface{}) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(model).Where(query, args...).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	return count
+}
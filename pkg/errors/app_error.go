@@ -2,6 +2,7 @@ package errors
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -42,6 +43,23 @@ func New(code, message, details string) *AppError {
 	}
 }
 
+// RuleViolation describes one failed rule of a validation policy
+type RuleViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// NewPolicyError returns an error listing every violated rule under the
+// "violations" metadata key, so clients can show all problems at once
+func NewPolicyError(code, message string, violations []RuleViolation) *AppError {
+	rules := make([]string, 0, len(violations))
+	for _, violation := range violations {
+		rules = append(rules, violation.Rule)
+	}
+	return New(code, message, "Failed rules: "+strings.Join(rules, ", ")).
+		AddMetadata("violations", violations)
+}
+
 func NewWithRequestID(code, message, details, requestID string) *AppError {
 	err := New(code, message, details)
 	err.RequestID = requestID
@@ -10,22 +10,31 @@ const (
 	ErrCodeTokenInvalid       = "TOKEN_INVALID"
 
 	// Validation
-	ErrCodeValidationFailed = "VALIDATION_FAILED"
-	ErrCodeInvalidInput     = "INVALID_INPUT"
-	ErrCodeMissingRequired  = "MISSING_REQUIRED"
-	ErrCodeInvalidFormat    = "INVALID_FORMAT"
+	ErrCodeValidationFailed     = "VALIDATION_FAILED"
+	ErrCodeInvalidInput         = "INVALID_INPUT"
+	ErrCodeMissingRequired      = "MISSING_REQUIRED"
+	ErrCodeInvalidFormat        = "INVALID_FORMAT"
+	ErrCodeWeakPassword         = "WEAK_PASSWORD"
+	ErrCodePayloadTooLarge      = "PAYLOAD_TOO_LARGE"
+	ErrCodeUnsupportedMediaType = "UNSUPPORTED_MEDIA_TYPE"
 
 	// Database
 	ErrCodeDatabaseError       = "DATABASE_ERROR"
 	ErrCodeRecordNotFound      = "RECORD_NOT_FOUND"
 	ErrCodeDuplicateRecord     = "DUPLICATE_RECORD"
 	ErrCodeConstraintViolation = "CONSTRAINT_VIOLATION"
+	ErrCodePreconditionFailed  = "PRECONDITION_FAILED"
 
 	// Business Logic
 	ErrCodeUserNotFound       = "USER_NOT_FOUND"
 	ErrCodeUserAlreadyExists  = "USER_ALREADY_EXISTS"
 	ErrCodeAccountDeactivated = "ACCOUNT_DEACTIVATED"
+	ErrCodeEmailNotVerified   = "EMAIL_NOT_VERIFIED"
+	ErrCodeAccountLocked      = "ACCOUNT_LOCKED"
 	ErrCodeInvalidOperation   = "INVALID_OPERATION"
+	ErrCodeChannelNotFound    = "CHANNEL_NOT_FOUND"
+	ErrCodeHandleTaken        = "HANDLE_TAKEN"
+	ErrCodeVideoNotFound      = "VIDEO_NOT_FOUND"
 
 	// External Services
 	ErrCodeExternalServiceError = "EXTERNAL_SERVICE_ERROR"
@@ -49,14 +58,23 @@ var ErrorCodeToHTTPStatus = map[string]int{
 	ErrCodeInvalidInput:         400,
 	ErrCodeMissingRequired:      400,
 	ErrCodeInvalidFormat:        400,
+	ErrCodeWeakPassword:         400,
+	ErrCodePayloadTooLarge:      413,
+	ErrCodeUnsupportedMediaType: 415,
 	ErrCodeDatabaseError:        500,
 	ErrCodeRecordNotFound:       404,
 	ErrCodeDuplicateRecord:      409,
 	ErrCodeConstraintViolation:  400,
+	ErrCodePreconditionFailed:   412,
 	ErrCodeUserNotFound:         404,
 	ErrCodeUserAlreadyExists:    409,
 	ErrCodeAccountDeactivated:   403,
+	ErrCodeEmailNotVerified:     403,
+	ErrCodeAccountLocked:        423,
 	ErrCodeInvalidOperation:     400,
+	ErrCodeChannelNotFound:      404,
+	ErrCodeHandleTaken:          409,
+	ErrCodeVideoNotFound:        404,
 	ErrCodeExternalServiceError: 502,
 	ErrCodeServiceUnavailable:   503,
 	ErrCodeTimeout:              408,
@@ -1,6 +1,7 @@
 package errors
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/cloudwego/hertz/pkg/app"
@@ -27,6 +28,10 @@ func SendError(c *app.RequestContext, err error) {
 		appErr.RequestID = requestID
 	}
 
+	if retryAfter, ok := appErr.Metadata["retry_after"]; ok {
+		c.Header("Retry-After", fmt.Sprint(retryAfter))
+	}
+
 	response := ErrorResponse{
 		Success:   false,
 		Error:     appErr,
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"kube/pkg/models"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// FilterType is the value type of a list filter query parameter
+type FilterType int
+
+const (
+	FilterString FilterType = iota
+	FilterBool
+	FilterTime // RFC 3339
+	FilterUint
+)
+
+// ListSpec whitelists the sort fields and filters a listing endpoint accepts
+type ListSpec struct {
+	SortFields  []string // the first field is the default
+	DefaultDesc bool
+	Filters     map[string]FilterType
+}
+
+// ParseListOptions parses the limit, cursor, sort and filter query parameters
+// of a listing request. Sort takes a field name, prefixed with "-" for
+// descending order. Query parameters that are not filters of the spec are
+// ignored; invalid values are reported as an error suitable for clients.
+func (h *BaseHandler) ParseListOptions(c *app.RequestContext, spec ListSpec) (*models.ListOptions, error) {
+	opts := &models.ListOptions{
+		Limit:   models.DefaultPageLimit,
+		Cursor:  c.Query("cursor"),
+		Desc:    spec.DefaultDesc,
+		Filters: make(map[string]interface{}),
+	}
+
+	if value := c.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > models.MaxPageLimit {
+			return nil, fmt.Errorf("limit must be between 1 and %d", models.MaxPageLimit)
+		}
+		opts.Limit = limit
+	}
+
+	if len(spec.SortFields) > 0 {
+		opts.Sort = spec.SortFields[0]
+	}
+	if value := c.Query("sort"); value != "" {
+		field := strings.TrimPrefix(value, "-")
+		if !containsField(spec.SortFields, field) {
+			return nil, fmt.Errorf("sort must be one of %s", strings.Join(spec.SortFields, ", "))
+		}
+		opts.Sort = field
+		opts.Desc = strings.HasPrefix(value, "-")
+	}
+
+	for name, filterType := range spec.Filters {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+
+		switch filterType {
+		case FilterBool:
+			parsed, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be true or false", name)
+			}
+			opts.Filters[name] = parsed
+		case FilterTime:
+			parsed, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be an RFC 3339 timestamp", name)
+			}
+			opts.Filters[name] = parsed
+		case FilterUint:
+			parsed, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s must be a positive integer", name)
+			}
+			opts.Filters[name] = uint(parsed)
+		default:
+			opts.Filters[name] = value
+		}
+	}
+
+	return opts, nil
+}
+
+func containsField(fields []string, field string) bool {
+	for _, f := range fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// Audit event actions, in "<resource>.<event>" form
+const (
+	AuditActionRegister       = "user.register"
+	AuditActionLoginSuccess   = "user.login.success"
+	AuditActionLoginFailure   = "user.login.failure"
+	AuditActionUpdate         = "user.update"
+	AuditActionDelete         = "user.delete"
+	AuditActionRestore        = "user.restore"
+	AuditActionErase          = "user.erase"
+	AuditActionPasswordChange = "user.password.change"
+)
+
+// AuditEvent is an append-only record of a security relevant account event.
+// ActorID is the authenticated user who caused the event and TargetID the
+// account it concerns; either is nil when unknown, such as a failed login
+// for an unregistered email. There is no foreign key to users so that
+// events outlive the accounts they describe.
+type AuditEvent struct {
+	ID        uint                   `json:"id" gorm:"primaryKey"`
+	Action    string                 `json:"action" gorm:"not null;index"`
+	ActorID   *uint                  `json:"actor_id" gorm:"index"`
+	TargetID  *uint                  `json:"target_id" gorm:"index"`
+	IP        string                 `json:"ip"`
+	RequestID string                 `json:"request_id" gorm:"index"`
+	Changes   map[string]FieldChange `json:"changes,omitempty" gorm:"serializer:json"`
+	CreatedAt time.Time              `json:"created_at" gorm:"index"`
+}
+
+// FieldChange is the old and new value of a field changed by an event
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
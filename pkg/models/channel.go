@@ -6,30 +6,35 @@ import (
 
 // Channel represents a user's channel
 type Channel struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	UserID      uint      `json:"user_id" gorm:"not null"`
-	User        User      `json:"user" gorm:"foreignKey:UserID"`
-	Name        string    `json:"name" gorm:"not null"`
-	Description string    `json:"description"`
-	BannerImage string    `json:"banner_image"`
-	Avatar      string    `json:"avatar"`
-	IsActive    bool      `json:"is_active" gorm:"default:true"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	UserID          uint      `json:"user_id" gorm:"not null;index"`
+	User            User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Handle          string    `json:"handle" gorm:"uniqueIndex;not null"` // normalized, see channel.normalizeHandle
+	Name            string    `json:"name" gorm:"not null"`
+	Description     string    `json:"description"`
+	BannerImage     string    `json:"banner_image"`
+	Avatar          string    `json:"avatar"`
+	IsActive        bool      `json:"is_active" gorm:"default:true"`
+	SubscriberCount int64     `json:"subscriber_count" gorm:"not null;default:0"` // maintained with the subscriptions
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
 }
 
-// ChannelCreateRequest represents the request to create a channel
+// ChannelCreateRequest represents the request to create a channel. The
+// owner is the authenticated user.
 type ChannelCreateRequest struct {
-	UserID      uint   `json:"user_id" binding:"required"`
+	Handle      string `json:"handle" binding:"required"`
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description"`
 	BannerImage string `json:"banner_image"`
 	Avatar      string `json:"avatar"`
 }
 
-// ChannelUpdateRequest represents the request to update a channel
+// ChannelUpdateRequest represents the request to update a channel. An empty
+// handle keeps the current one.
 type ChannelUpdateRequest struct {
-	Name        string `json:"name"`
+	Handle      string `json:"handle"`
+	Name        string `json:"name" binding:"required"`
 	Description string `json:"description"`
 	BannerImage string `json:"banner_image"`
 	Avatar      string `json:"avatar"`
@@ -37,13 +42,15 @@ type ChannelUpdateRequest struct {
 
 // ChannelResponse represents the response for channel data
 type ChannelResponse struct {
-	ID          uint      `json:"id"`
-	UserID      uint      `json:"user_id"`
-	Name        string    `json:"name"`
-	Description string    `json:"description"`
-	BannerImage string    `json:"banner_image"`
-	Avatar      string    `json:"avatar"`
-	IsActive    bool      `json:"is_active"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
-} 
\ No newline at end of file
+	ID              uint      `json:"id"`
+	UserID          uint      `json:"user_id"`
+	Handle          string    `json:"handle"`
+	Name            string    `json:"name"`
+	Description     string    `json:"description"`
+	BannerImage     string    `json:"banner_image"`
+	Avatar          string    `json:"avatar"`
+	IsActive        bool      `json:"is_active"`
+	SubscriberCount int64     `json:"subscriber_count"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
@@ -0,0 +1,156 @@
+package models
+
+import (
+	"time"
+)
+
+// Channel role names. The owner is the channel's UserID and has no member
+// row; the other roles are granted through invitations.
+const (
+	ChannelRoleOwner           = "owner"
+	ChannelRoleManager         = "manager"
+	ChannelRoleEditor          = "editor"
+	ChannelRoleViewerAnalytics = "viewer-analytics"
+)
+
+// Channel permission names, checked with services.AuthorizeChannel. They are
+// scoped to one channel and unrelated to the account-wide permissions.
+const (
+	ChannelPermissionUpdate        = "channel:update"
+	ChannelPermissionDelete        = "channel:delete"
+	ChannelPermissionTransfer      = "channel:transfer"
+	ChannelPermissionMembersRead   = "members:read"
+	ChannelPermissionMembersManage = "members:manage"
+	ChannelPermissionVideosManage  = "videos:manage"
+	ChannelPermissionAnalyticsRead = "analytics:read"
+)
+
+// ChannelRolePermissions lists the permissions of each channel role
+var ChannelRolePermissions = map[string][]string{
+	ChannelRoleOwner: {
+		ChannelPermissionUpdate,
+		ChannelPermissionDelete,
+		ChannelPermissionTransfer,
+		ChannelPermissionMembersRead,
+		ChannelPermissionMembersManage,
+		ChannelPermissionVideosManage,
+		ChannelPermissionAnalyticsRead,
+	},
+	ChannelRoleManager: {
+		ChannelPermissionUpdate,
+		ChannelPermissionMembersRead,
+		ChannelPermissionMembersManage,
+		ChannelPermissionVideosManage,
+		ChannelPermissionAnalyticsRead,
+	},
+	ChannelRoleEditor: {
+		ChannelPermissionMembersRead,
+		ChannelPermissionVideosManage,
+	},
+	ChannelRoleViewerAnalytics: {
+		ChannelPermissionMembersRead,
+		ChannelPermissionAnalyticsRead,
+	},
+}
+
+// ChannelRoleRanks orders the channel roles. Members may only grant, change
+// and revoke roles ranked below their own.
+var ChannelRoleRanks = map[string]int{
+	ChannelRoleOwner:           4,
+	ChannelRoleManager:         3,
+	ChannelRoleEditor:          2,
+	ChannelRoleViewerAnalytics: 1,
+}
+
+// ChannelRoleHasPermission reports whether the channel role grants the
+// permission
+func ChannelRoleHasPermission(role, permission string) bool {
+	for _, granted := range ChannelRolePermissions[role] {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Channel invitation states
+const (
+	InvitationStatusPending  = "pending"
+	InvitationStatusAccepted = "accepted"
+	InvitationStatusDeclined = "declined"
+	InvitationStatusRevoked  = "revoked"
+)
+
+// ChannelMember grants a user a role on a channel they do not own
+type ChannelMember struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	ChannelID   uint      `json:"channel_id" gorm:"not null;uniqueIndex:idx_channel_members_channel_user"`
+	Channel     Channel   `json:"-" gorm:"foreignKey:ChannelID;constraint:OnDelete:CASCADE"`
+	UserID      uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_channel_members_channel_user;index"`
+	User        User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Role        string    `json:"role" gorm:"not null"`
+	InvitedByID *uint     `json:"invited_by_id"` // nil for former owners after a transfer
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// ChannelInvitation offers a user a role on a channel until it is accepted,
+// declined, revoked or expires
+type ChannelInvitation struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	ChannelID   uint       `json:"channel_id" gorm:"not null;index"`
+	Channel     Channel    `json:"-" gorm:"foreignKey:ChannelID;constraint:OnDelete:CASCADE"`
+	InviteeID   uint       `json:"invitee_id" gorm:"not null;index"`
+	Invitee     User       `json:"-" gorm:"foreignKey:InviteeID;constraint:OnDelete:CASCADE"`
+	InvitedByID uint       `json:"invited_by_id" gorm:"not null"`
+	InvitedBy   User       `json:"-" gorm:"foreignKey:InvitedByID;constraint:OnDelete:CASCADE"`
+	Role        string     `json:"role" gorm:"not null"`
+	Status      string     `json:"status" gorm:"not null;index"`
+	ExpiresAt   time.Time  `json:"expires_at"`
+	RespondedAt *time.Time `json:"responded_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// ChannelInvitationRequest represents the request to invite a user to a
+// channel. Identifier is a username or email address.
+type ChannelInvitationRequest struct {
+	Identifier string `json:"identifier" binding:"required"`
+	Role       string `json:"role" binding:"required"`
+}
+
+// ChannelMemberUpdateRequest represents the request to change the role of a
+// member
+type ChannelMemberUpdateRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// ChannelTransferRequest represents the request to hand a channel over to
+// one of its members
+type ChannelTransferRequest struct {
+	UserID uint `json:"user_id" binding:"required"`
+}
+
+// ChannelMemberResponse represents a member of a channel, including its
+// owner
+type ChannelMemberResponse struct {
+	UserID   uint      `json:"user_id"`
+	Username string    `json:"username"`
+	Avatar   string    `json:"avatar"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// ChannelInvitationResponse represents an invitation to a channel
+type ChannelInvitationResponse struct {
+	ID          uint             `json:"id"`
+	ChannelID   uint             `json:"channel_id"`
+	Channel     *ChannelResponse `json:"channel,omitempty"`
+	InviteeID   uint             `json:"invitee_id"`
+	InvitedByID uint             `json:"invited_by_id"`
+	Role        string           `json:"role"`
+	Status      string           `json:"status"`
+	ExpiresAt   time.Time        `json:"expires_at"`
+	RespondedAt *time.Time       `json:"responded_at,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// Formats of a personal data export
+const (
+	ExportFormatJSON = "json"
+	ExportFormatZIP  = "zip"
+)
+
+// UserExport holds everything stored about a user, for data subject access
+// requests. Secrets such as password and token hashes are never included.
+type UserExport struct {
+	ExportedAt    time.Time              `json:"exported_at"`
+	Profile       UserResponse           `json:"profile"`
+	Sessions      []Session              `json:"sessions"`
+	Identities    []Identity             `json:"identities"`
+	APIKeys       []APIKey               `json:"api_keys"`
+	Channels      []ChannelResponse      `json:"channels"`
+	Subscriptions []SubscriptionResponse `json:"subscriptions"`
+	AuditEvents   []AuditEvent           `json:"audit_events"`
+}
+
+// ErasureRequest represents the request to erase the personal data of the
+// authenticated user. Confirm must repeat the username.
+type ErasureRequest struct {
+	Confirm string `json:"confirm" binding:"required"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// Identity links an account at an external OpenID Connect provider to a
+// user. The provider's subject identifier is stable, unlike the email.
+type Identity struct {
+	ID          uint       `json:"id" gorm:"primaryKey"`
+	UserID      uint       `json:"user_id" gorm:"not null;index"`
+	User        User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Provider    string     `json:"provider" gorm:"not null;uniqueIndex:idx_identities_provider_subject"`
+	Subject     string     `json:"-" gorm:"not null;uniqueIndex:idx_identities_provider_subject"`
+	Email       string     `json:"email"`
+	LastLoginAt *time.Time `json:"last_login_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
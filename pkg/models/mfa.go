@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+)
+
+// RecoveryCode represents a hashed one-time code that can replace a TOTP
+// code when the user has lost their authenticator
+type RecoveryCode struct {
+	ID        uint       `json:"id" gorm:"primaryKey"`
+	UserID    uint       `json:"user_id" gorm:"not null;index"`
+	User      User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	CodeHash  string     `json:"-" gorm:"not null;index"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// MFAEnrollmentResponse represents a pending TOTP enrollment
+type MFAEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+	QRPayload  string `json:"qr_payload"` // encode as-is into a QR code
+}
+
+// MFACodeRequest represents a request carrying a TOTP or recovery code
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFALoginRequest represents the second step of a login with MFA enabled.
+// Code accepts either a TOTP code or an unused recovery code.
+type MFALoginRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// RecoveryCodesResponse represents newly generated recovery codes. They are
+// only shown once.
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
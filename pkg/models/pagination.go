@@ -0,0 +1,27 @@
+package models
+
+// Default and maximum page sizes of cursor-paginated listings
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// ListOptions describes one page of a cursor-paginated listing. Sort and the
+// filter names have already been checked against the endpoint's whitelist.
+type ListOptions struct {
+	Limit   int
+	Cursor  string
+	Sort    string
+	Desc    bool
+	Filters map[string]interface{} // string, bool or time.Time values
+}
+
+// Page is the response envelope of every paginated listing. NextCursor is
+// passed back as the cursor query parameter to fetch the following page.
+type Page struct {
+	Items      interface{} `json:"items"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	HasMore    bool        `json:"has_more"`
+	Limit      int         `json:"limit"`
+	Sort       string      `json:"sort"`
+}
@@ -0,0 +1,86 @@
+package models
+
+import (
+	"time"
+)
+
+// Built-in role names
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+	RoleUser      = "user"
+)
+
+// Permission names shared by every service. Permissions follow the
+// "<resource>:<action>" convention.
+const (
+	PermissionUsersRead      = "users:read"
+	PermissionUsersUpdate    = "users:update"
+	PermissionUsersDelete    = "users:delete"
+	PermissionUsersUnlock    = "users:unlock"
+	PermissionUsersRestore   = "users:restore"
+	PermissionRolesRead      = "roles:read"
+	PermissionRolesManage    = "roles:manage"
+	PermissionAuditRead      = "audit:read"
+	PermissionVideosUpload   = "videos:upload"
+	PermissionVideosDelete   = "videos:delete"
+	PermissionVideosModerate = "videos:moderate"
+	PermissionMetadataWrite  = "metadata:write"
+)
+
+// DefaultRolePermissions lists the permissions seeded for each built-in role
+var DefaultRolePermissions = map[string][]string{
+	RoleAdmin: {
+		PermissionUsersRead,
+		PermissionUsersUpdate,
+		PermissionUsersDelete,
+		PermissionUsersUnlock,
+		PermissionUsersRestore,
+		PermissionRolesRead,
+		PermissionRolesManage,
+		PermissionAuditRead,
+		PermissionVideosUpload,
+		PermissionVideosDelete,
+		PermissionVideosModerate,
+		PermissionMetadataWrite,
+	},
+	RoleModerator: {
+		PermissionUsersRead,
+		PermissionVideosDelete,
+		PermissionVideosModerate,
+	},
+	RoleUser: {
+		PermissionVideosUpload,
+	},
+}
+
+// Role groups permissions that can be granted to users
+type Role struct {
+	ID          uint         `json:"id" gorm:"primaryKey"`
+	Name        string       `json:"name" gorm:"uniqueIndex;not null"`
+	Description string       `json:"description"`
+	Permissions []Permission `json:"permissions" gorm:"many2many:role_permissions"`
+	CreatedAt   time.Time    `json:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at"`
+}
+
+// Permission represents a single action that can be authorized
+type Permission struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Name        string    `json:"name" gorm:"uniqueIndex;not null"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// RoleAssignRequest represents the request to grant a role to a user
+type RoleAssignRequest struct {
+	Role string `json:"role" binding:"required"`
+}
+
+// RoleResponse represents the response for role data
+type RoleResponse struct {
+	ID          uint     `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
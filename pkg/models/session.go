@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// Session represents a login on one device. Its ID is the refresh token
+// family and the "sid" claim of every access token issued for the login.
+type Session struct {
+	ID         string     `json:"id" gorm:"primaryKey;size:36"`
+	UserID     uint       `json:"user_id" gorm:"not null;index"`
+	User       User       `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	UserAgent  string     `json:"user_agent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `json:"expires_at" gorm:"not null"` // expiry of the latest refresh token
+	RevokedAt  *time.Time `json:"revoked_at"`
+}
+
+// SessionResponse represents an active session. Current marks the session
+// the request was made from.
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
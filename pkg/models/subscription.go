@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+)
+
+// Subscription records that a user follows a channel. The channel keeps a
+// denormalized SubscriberCount that is updated in the same transaction.
+type Subscription struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	UserID        uint      `json:"user_id" gorm:"not null;uniqueIndex:idx_subscriptions_user_channel"`
+	User          User      `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	ChannelID     uint      `json:"channel_id" gorm:"not null;uniqueIndex:idx_subscriptions_user_channel;index"`
+	Channel       Channel   `json:"-" gorm:"foreignKey:ChannelID;constraint:OnDelete:CASCADE"`
+	NotifyUploads bool      `json:"notify_uploads" gorm:"not null"` // notify about new videos
+	NotifyLive    bool      `json:"notify_live" gorm:"not null"`    // notify when the channel goes live
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// SubscriptionRequest represents the notification preferences of a
+// subscription. Omitted flags keep their current value, or default to true
+// for a new subscription.
+type SubscriptionRequest struct {
+	NotifyUploads *bool `json:"notify_uploads"`
+	NotifyLive    *bool `json:"notify_live"`
+}
+
+// SubscriptionResponse represents a subscription of the authenticated user
+type SubscriptionResponse struct {
+	ChannelID     uint             `json:"channel_id"`
+	Channel       *ChannelResponse `json:"channel,omitempty"`
+	NotifyUploads bool             `json:"notify_uploads"`
+	NotifyLive    bool             `json:"notify_live"`
+	CreatedAt     time.Time        `json:"created_at"`
+}
+
+// SubscriberResponse represents a subscriber of a channel
+type SubscriberResponse struct {
+	UserID       uint      `json:"user_id"`
+	Username     string    `json:"username"`
+	Avatar       string    `json:"avatar"`
+	SubscribedAt time.Time `json:"subscribed_at"`
+}
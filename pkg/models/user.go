@@ -7,23 +7,41 @@ import (
 )
 
 type User struct {
-	ID        uint           `json:"id" gorm:"primaryKey"`
-	Username  string         `json:"username" gorm:"uniqueIndex;not null"`
-	Email     string         `json:"email" gorm:"uniqueIndex;not null"`
-	Password  string         `json:"-" gorm:"not null"` // "-" means don't include in JSON
-	FirstName string         `json:"first_name"`
-	LastName  string         `json:"last_name"`
-	Avatar    string         `json:"avatar"`
-	IsActive  bool           `json:"is_active" gorm:"default:true"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
-	DeletedAt gorm.DeletedAt `json:"-" gorm:"index"`
+	ID                 uint           `json:"id" gorm:"primaryKey"`
+	Username           string         `json:"username" gorm:"uniqueIndex;uniqueIndex:idx_users_username_lower,expression:lower(username);not null"` // normalized, see user.normalizeIdentifier
+	Email              string         `json:"email" gorm:"uniqueIndex;uniqueIndex:idx_users_email_lower,expression:lower(email);not null"`
+	Password           string         `json:"-" gorm:"not null"` // "-" means don't include in JSON
+	FirstName          string         `json:"first_name"`
+	LastName           string         `json:"last_name"`
+	Avatar             string         `json:"avatar"`
+	AvatarURLs         AvatarURLs     `json:"avatar_urls,omitempty" gorm:"serializer:json"` // thumbnails of an uploaded avatar
+	IsActive           bool           `json:"is_active" gorm:"default:true"`
+	IsAdmin            bool           `json:"is_admin" gorm:"default:false"`
+	Roles              []Role         `json:"roles,omitempty" gorm:"many2many:user_roles"`
+	EmailVerifiedAt    *time.Time     `json:"email_verified_at"`
+	VerificationSentAt *time.Time     `json:"-"` // last verification email, throttles resends
+	ResetSentAt        *time.Time     `json:"-"` // last password reset email, throttles resets
+	MFAEnabled         bool           `json:"mfa_enabled" gorm:"default:false"`
+	MFASecret          string         `json:"-"`
+	MFALastStep        int64          `json:"-"` // last accepted TOTP step, prevents replays
+	FailedLogins       int            `json:"-" gorm:"default:0"`
+	LockoutCount       int            `json:"-" gorm:"default:0"` // consecutive lockouts, drives the backoff
+	LockedUntil        *time.Time     `json:"-"`
+	Version            int            `json:"version" gorm:"not null;default:1"` // bumped on profile changes, exposed as ETag
+	ErasedAt           *time.Time     `json:"-"`                                 // personal data was anonymized on request
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `json:"-" gorm:"index"`
 }
 
+// AvatarURLs maps a thumbnail edge length in pixels, such as "128", to the
+// URL of the square thumbnail
+type AvatarURLs map[string]string
+
 type UserCreateRequest struct {
 	Username  string `json:"username" binding:"required"`
 	Email     string `json:"email" binding:"required,email"`
-	Password  string `json:"password" binding:"required,min=6"`
+	Password  string `json:"password" binding:"required"` // checked against the password policy
 	FirstName string `json:"first_name"`
 	LastName  string `json:"last_name"`
 }
@@ -34,19 +52,50 @@ type UserUpdateRequest struct {
 	Avatar    string `json:"avatar"`
 }
 
+// UserPatchRequest documents the fields accepted in a JSON merge patch
+// (RFC 7396). Omitted fields are left unchanged; null clears a field.
+type UserPatchRequest struct {
+	FirstName *string `json:"first_name,omitempty"`
+	LastName  *string `json:"last_name,omitempty"`
+	Avatar    *string `json:"avatar,omitempty"`
+}
+
+// UserLoginRequest represents login credentials. Identifier is a username
+// or email address; Email is still accepted for older clients.
 type UserLoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Identifier string `json:"identifier"`
+	Email      string `json:"email"`
+	Password   string `json:"password" binding:"required"`
 }
 
 type UserResponse struct {
-	ID        uint      `json:"id"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	FirstName string    `json:"first_name"`
-	LastName  string    `json:"last_name"`
-	Avatar    string    `json:"avatar"`
-	IsActive  bool      `json:"is_active"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID              uint       `json:"id"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	FirstName       string     `json:"first_name"`
+	LastName        string     `json:"last_name"`
+	Avatar          string     `json:"avatar"`
+	AvatarURLs      AvatarURLs `json:"avatar_urls,omitempty"`
+	IsActive        bool       `json:"is_active"`
+	IsAdmin         bool       `json:"is_admin"`
+	Roles           []string   `json:"roles,omitempty"`
+	EmailVerified   bool       `json:"email_verified"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+	MFAEnabled      bool       `json:"mfa_enabled"`
+	Version         int        `json:"version"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+type PasswordForgotRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type PasswordResetRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"` // checked against the password policy
+}
+
+type EmailVerificationResendRequest struct {
+	Email string `json:"email" binding:"required,email"`
 }
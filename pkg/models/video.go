@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+)
+
+// Video upload states. Uploads start as uploading and end as uploaded once
+// the whole file is stored, or failed otherwise.
+const (
+	VideoStatusUploading = "uploading"
+	VideoStatusUploaded  = "uploaded"
+	VideoStatusFailed    = "failed"
+)
+
+// Video is a video uploaded to a channel. The source file is kept in storage
+// under StorageKey.
+type Video struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	UserID        *uint     `json:"user_id" gorm:"index"` // uploader, nil once their account is purged
+	User          *User     `json:"-" gorm:"foreignKey:UserID;constraint:OnDelete:SET NULL"`
+	ChannelID     uint      `json:"channel_id" gorm:"not null;index"`
+	Channel       Channel   `json:"-" gorm:"foreignKey:ChannelID;constraint:OnDelete:CASCADE"`
+	Title         string    `json:"title" gorm:"not null"`
+	Description   string    `json:"description"`
+	Status        string    `json:"status" gorm:"not null;index"`
+	FailureReason string    `json:"failure_reason"`
+	Filename      string    `json:"filename"` // as sent by the client
+	ContentType   string    `json:"content_type"`
+	Size          int64     `json:"size" gorm:"not null;default:0"` // bytes
+	Checksum      string    `json:"checksum"`                       // hex encoded SHA-256 of the file
+	StorageKey    string    `json:"-" gorm:"uniqueIndex;not null"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// VideoUploadRequest represents the form fields sent with a video file
+type VideoUploadRequest struct {
+	ChannelID   uint
+	Title       string
+	Description string
+	Filename    string
+}
+
+// VideoResponse represents the response for video data
+type VideoResponse struct {
+	ID            uint      `json:"id"`
+	UserID        *uint     `json:"user_id"`
+	ChannelID     uint      `json:"channel_id"`
+	Title         string    `json:"title"`
+	Description   string    `json:"description"`
+	Status        string    `json:"status"`
+	FailureReason string    `json:"failure_reason,omitempty"`
+	Filename      string    `json:"filename"`
+	ContentType   string    `json:"content_type"`
+	Size          int64     `json:"size"`
+	Checksum      string    `json:"checksum"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/app/server"
+	hertzconfig "github.com/cloudwego/hertz/pkg/common/config"
 	"github.com/hertz-contrib/swagger"
 	swaggerFiles "github.com/swaggo/files"
 )
@@ -18,6 +19,14 @@ type ServerConfig struct {
 	SwaggerURL   string
 	RateLimit    int
 	RateDuration time.Duration
+
+	// MaxRequestBodySize limits buffered request bodies in bytes; 0 keeps
+	// the Hertz default of 4 MB
+	MaxRequestBodySize int
+	// StreamRequestBody hands request bodies larger than
+	// MaxRequestBodySize to handlers as a stream instead of reading them
+	// into memory first, for large uploads
+	StreamRequestBody bool
 }
 
 // CommonServer provides common server setup and utilities
@@ -28,7 +37,16 @@ type CommonServer struct {
 
 // NewServer creates a new server with common middleware and setup
 func NewServer(config ServerConfig) *CommonServer {
-	h := server.Default(server.WithHostPorts(":" + config.Port))
+	opts := []hertzconfig.Option{server.WithHostPorts(":" + config.Port)}
+	if config.MaxRequestBodySize > 0 {
+		opts = append(opts, server.WithMaxRequestBodySize(config.MaxRequestBodySize))
+	}
+	if config.StreamRequestBody {
+		// Hertz would otherwise spool multipart forms to temporary files
+		// before the handler runs
+		opts = append(opts, server.WithStreamBody(true), server.WithDisablePreParseMultipartForm(true))
+	}
+	h := server.Default(opts...)
 
 	// Add common middleware
 	h.Use(LoggingMiddleware())
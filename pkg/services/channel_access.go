@@ -0,0 +1,68 @@
+package services
+
+import (
+	"errors"
+	"strconv"
+
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// ChannelRole returns the role of the user on the channel: owner for its
+// UserID, the member role otherwise, or "" when the user has no role
+func ChannelRole(db *gorm.DB, channel *models.Channel, userID uint) (string, error) {
+	if channel.UserID == userID {
+		return models.ChannelRoleOwner, nil
+	}
+
+	var member models.ChannelMember
+	err := db.Where("channel_id = ? AND user_id = ?", channel.ID, userID).First(&member).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", nil
+	}
+	if err != nil {
+		return "", apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to check channel role", err.Error())
+	}
+	return member.Role, nil
+}
+
+// CheckChannelPermission fails with ErrCodeForbidden unless the user's role
+// on the channel grants the permission. It returns the role, which callers
+// use to compare role ranks.
+func CheckChannelPermission(db *gorm.DB, channel *models.Channel, userID uint, permission string) (string, error) {
+	role, err := ChannelRole(db, channel, userID)
+	if err != nil {
+		return "", err
+	}
+	if !models.ChannelRoleHasPermission(role, permission) {
+		return "", apperrors.New(apperrors.ErrCodeForbidden, "Forbidden", "Your role on this channel does not allow this action").
+			AddMetadata("permission", permission)
+	}
+	return role, nil
+}
+
+// AuthorizeChannel loads the channel and checks that the user may perform
+// the action described by permission on it. Services that manage resources
+// belonging to a channel, such as videos, call it before every change.
+func AuthorizeChannel(db *gorm.DB, channelID, userID uint, permission string) (*models.Channel, error) {
+	var channel models.Channel
+	if err := db.First(&channel, channelID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.New(apperrors.ErrCodeChannelNotFound, "Channel not found", "Channel "+strconv.FormatUint(uint64(channelID), 10)+" does not exist")
+		}
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to load channel", err.Error())
+	}
+
+	if _, err := CheckChannelPermission(db, &channel, userID, permission); err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// AuthorizeChannel checks a channel permission with the service database,
+// see AuthorizeChannel
+func (s *BaseService) AuthorizeChannel(channelID, userID uint, permission string) (*models.Channel, error) {
+	return AuthorizeChannel(s.db, channelID, userID, permission)
+}
@@ -0,0 +1,119 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"kube/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidCursor is returned for cursors that are malformed or were issued
+// for a different sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// cursor is the position after the last row of a page: the value of the sort
+// column and the row ID as tie-breaker
+type cursor struct {
+	Sort  string          `json:"s"`
+	Desc  bool            `json:"d,omitempty"`
+	Value json.RawMessage `json:"v"`
+	Time  bool            `json:"t,omitempty"`
+	ID    uint            `json:"id"`
+}
+
+// Paginate runs a keyset-paginated query ordered by column and then by ID.
+// key returns the sort column value and ID of a row and is used to build the
+// next cursor. Keyset pagination keeps pages stable while rows are inserted
+// and does not slow down on deep pages like OFFSET does.
+func Paginate[T any](query *gorm.DB, opts *models.ListOptions, column string, key func(*T) (interface{}, uint)) (*models.Page, []T, error) {
+	direction, comparison := "ASC", ">"
+	if opts.Desc {
+		direction, comparison = "DESC", "<"
+	}
+
+	if opts.Cursor != "" {
+		value, id, err := decodeCursor(opts)
+		if err != nil {
+			return nil, nil, err
+		}
+		query = query.Where("("+column+" "+comparison+" ?) OR ("+column+" = ? AND id "+comparison+" ?)", value, value, id)
+	}
+
+	var rows []T
+	if err := query.Order(column + " " + direction).Order("id " + direction).Limit(opts.Limit + 1).Find(&rows).Error; err != nil {
+		return nil, nil, err
+	}
+
+	page := &models.Page{Limit: opts.Limit, Sort: opts.Sort}
+	if opts.Desc {
+		page.Sort = "-" + opts.Sort
+	}
+
+	if len(rows) > opts.Limit {
+		rows = rows[:opts.Limit]
+		value, id := key(&rows[len(rows)-1])
+
+		next, err := encodeCursor(opts, value, id)
+		if err != nil {
+			return nil, nil, err
+		}
+		page.HasMore = true
+		page.NextCursor = next
+	}
+
+	return page, rows, nil
+}
+
+func encodeCursor(opts *models.ListOptions, value interface{}, id uint) (string, error) {
+	c := cursor{Sort: opts.Sort, Desc: opts.Desc, ID: id}
+	if t, ok := value.(time.Time); ok {
+		value = t.UTC().Format(time.RFC3339Nano)
+		c.Time = true
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	c.Value = raw
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeCursor(opts *models.ListOptions) (interface{}, uint, error) {
+	data, err := base64.RawURLEncoding.DecodeString(opts.Cursor)
+	if err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil || c.Sort != opts.Sort || c.Desc != opts.Desc {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(c.Value, &value); err != nil {
+		return nil, 0, ErrInvalidCursor
+	}
+
+	if c.Time {
+		s, ok := value.(string)
+		if !ok {
+			return nil, 0, ErrInvalidCursor
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return nil, 0, ErrInvalidCursor
+		}
+		value = t
+	}
+	return value, c.ID, nil
+}
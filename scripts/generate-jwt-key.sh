@@ -0,0 +1,39 @@
+#!/bin/bash
+
+# Generate a private key for asymmetric JWT signing
+# Usage: ./scripts/generate-jwt-key.sh <key-id> [ed25519|rsa]
+
+set -e
+
+KEY_ID=$1
+KEY_TYPE=${2:-ed25519}
+KEYS_DIR=${JWT_KEYS_DIR:-keys}
+
+if [ -z "$KEY_ID" ]; then
+    echo "Usage: $0 <key-id> [ed25519|rsa]"
+    exit 1
+fi
+
+mkdir -p "$KEYS_DIR"
+KEY_FILE="$KEYS_DIR/$KEY_ID.pem"
+
+if [ -f "$KEY_FILE" ]; then
+    echo "Key $KEY_FILE already exists"
+    exit 1
+fi
+
+case $KEY_TYPE in
+    ed25519)
+        openssl genpkey -algorithm ed25519 -out "$KEY_FILE"
+        ;;
+    rsa)
+        openssl genpkey -algorithm RSA -pkeyopt rsa_keygen_bits:2048 -out "$KEY_FILE"
+        ;;
+    *)
+        echo "Unknown key type: $KEY_TYPE (use ed25519 or rsa)"
+        exit 1
+        ;;
+esac
+
+chmod 600 "$KEY_FILE"
+echo "Generated $KEY_TYPE signing key $KEY_FILE"
@@ -395,7 +395,8 @@ export DB_SSLMODE=disable
 export REDIS_HOST=localhost
 export REDIS_PORT=6379
 export JWT_SECRET=your-secret-key
-export JWT_EXPIRES_IN=24
+export JWT_EXPIRES_IN=1
+export JWT_REFRESH_EXPIRES_IN=720
 
 # Run the service
 go run cmd/$SERVICE_NAME/main.go
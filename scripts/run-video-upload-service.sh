@@ -0,0 +1,19 @@
+#!/bin/bash
+
+echo "Starting Video Upload Service..."
+
+# Set environment variables
+export DB_HOST=localhost
+export DB_PORT=5432
+export DB_USER=postgres
+export DB_PASSWORD=password
+export DB_NAME=video_streaming
+export DB_SSLMODE=disable
+export REDIS_HOST=localhost
+export REDIS_PORT=6379
+export JWT_SECRET=your-secret-key
+export JWT_EXPIRES_IN=1
+export JWT_REFRESH_EXPIRES_IN=720
+
+# Run the service
+go run cmd/video-upload-service/main.go 
\ No newline at end of file
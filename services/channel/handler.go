@@ -0,0 +1,189 @@
+package channel
+
+import (
+	"kube/internal/middleware"
+	"kube/pkg/errors"
+	"kube/pkg/handlers"
+	"kube/pkg/models"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+type Handler struct {
+	*handlers.BaseHandler
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		BaseHandler: handlers.NewBaseHandler(),
+		service:     service,
+	}
+}
+
+// channelListSpec whitelists the sort fields and filters of channel listings
+var channelListSpec = handlers.ListSpec{
+	SortFields:  []string{"created_at", "handle", "name", "subscriber_count"},
+	DefaultDesc: true,
+	Filters: map[string]handlers.FilterType{
+		"user_id":   handlers.FilterUint,
+		"is_active": handlers.FilterBool,
+	},
+}
+
+// CreateChannel godoc
+// @Summary Create a channel
+// @Description Create a channel owned by the authenticated user. Handles are trimmed, lowercased and unique; they are 3-30 characters of a-z, 0-9, "_" and "-" and start with a letter.
+// @Tags channels
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param channel body models.ChannelCreateRequest true "Channel data"
+// @Success 201 {object} map[string]interface{} "Channel created successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request data, see error.metadata.fields"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 409 {object} map[string]interface{} "Handle already taken"
+// @Router /api/v1/channels [post]
+func (h *Handler) CreateChannel(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	var req models.ChannelCreateRequest
+	if err := c.BindJSON(&req); err != nil {
+		h.SendValidationError(c, "Invalid request data format")
+		return
+	}
+
+	channel, err := h.service.CreateChannel(userID, &req)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 201, channel, "Channel created successfully")
+}
+
+// GetChannel godoc
+// @Summary Get channel by ID or handle
+// @Description Retrieve a channel by its numeric ID or its handle, with or without a leading "@"
+// @Tags channels
+// @Produce json
+// @Param channel path string true "Channel ID or handle"
+// @Success 200 {object} map[string]interface{} "Channel information"
+// @Failure 404 {object} map[string]interface{} "Channel not found"
+// @Router /api/v1/channels/{channel} [get]
+func (h *Handler) GetChannel(c *app.RequestContext) {
+	channel, err := h.service.GetChannel(c.Param("channel"))
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, channel, "Channel retrieved successfully")
+}
+
+// ListChannels godoc
+// @Summary List channels
+// @Description List channels with cursor pagination. Filter by user_id to list the channels of one user.
+// @Tags channels
+// @Produce json
+// @Param limit query int false "Page size, 1-100" default(20)
+// @Param cursor query string false "Cursor from the previous page"
+// @Param sort query string false "created_at, handle, name or subscriber_count; prefix with - for descending" default(-created_at)
+// @Param user_id query int false "Owner of the channels"
+// @Param is_active query bool false "Only active or inactive channels"
+// @Success 200 {object} map[string]interface{} "Channels retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid query parameters or cursor"
+// @Router /api/v1/channels [get]
+func (h *Handler) ListChannels(c *app.RequestContext) {
+	opts, err := h.ParseListOptions(c, channelListSpec)
+	if err != nil {
+		h.SendValidationError(c, err.Error())
+		return
+	}
+
+	page, err := h.service.ListChannels(opts)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, page, "Channels retrieved successfully")
+}
+
+// UpdateChannel godoc
+// @Summary Update a channel
+// @Description Replace the channel details. An empty handle keeps the current one. The owner and managers can update a channel.
+// @Tags channels
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param channel path string true "Channel ID or handle"
+// @Param update body models.ChannelUpdateRequest true "Channel data"
+// @Success 200 {object} map[string]interface{} "Channel updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request data, see error.metadata.fields"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Channel role does not allow this action"
+// @Failure 404 {object} map[string]interface{} "Channel not found"
+// @Failure 409 {object} map[string]interface{} "Handle already taken"
+// @Router /api/v1/channels/{channel} [put]
+func (h *Handler) UpdateChannel(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	var req models.ChannelUpdateRequest
+	if err := c.BindJSON(&req); err != nil {
+		h.SendValidationError(c, "Invalid request data format")
+		return
+	}
+
+	channel, err := h.service.UpdateChannel(c.Param("channel"), userID, &req)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, channel, "Channel updated successfully")
+}
+
+// DeleteChannel godoc
+// @Summary Delete a channel
+// @Description Delete a channel with all its videos, including the stored video files. Only the owner can delete a channel.
+// @Tags channels
+// @Produce json
+// @Security BearerAuth
+// @Param channel path string true "Channel ID or handle"
+// @Success 200 {object} map[string]interface{} "Channel deleted successfully"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Channel role does not allow this action"
+// @Failure 404 {object} map[string]interface{} "Channel not found"
+// @Router /api/v1/channels/{channel} [delete]
+func (h *Handler) DeleteChannel(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	if err := h.service.DeleteChannel(c.Param("channel"), userID); err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, nil, "Channel deleted successfully")
+}
+
+// currentUserID returns the authenticated user ID
+func currentUserID(c *app.RequestContext) (uint, error) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return 0, errors.New(errors.ErrCodeUnauthorized, "Unauthorized", "Authentication required")
+	}
+	return userID, nil
+}
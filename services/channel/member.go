@@ -0,0 +1,503 @@
+package channel
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+	"kube/pkg/services"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// invitationTTL is how long an invitation can be accepted
+const invitationTTL = 7 * 24 * time.Hour
+
+// ListMembers returns the owner of the channel followed by its members in
+// the order they joined
+func (s *Service) ListMembers(ref string, actorID uint) ([]*models.ChannelMemberResponse, error) {
+	db := s.GetDB()
+
+	channel, err := findChannel(db.Preload("User"), ref)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := services.CheckChannelPermission(db, channel, actorID, models.ChannelPermissionMembersRead); err != nil {
+		return nil, err
+	}
+
+	var members []models.ChannelMember
+	if err := db.Preload("User").Where("channel_id = ?", channel.ID).Order("created_at, id").Find(&members).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to list members", err.Error())
+	}
+
+	items := make([]*models.ChannelMemberResponse, 0, len(members)+1)
+	items = append(items, &models.ChannelMemberResponse{
+		UserID:   channel.UserID,
+		Username: channel.User.Username,
+		Avatar:   channel.User.Avatar,
+		Role:     models.ChannelRoleOwner,
+		JoinedAt: channel.CreatedAt,
+	})
+	for _, member := range members {
+		items = append(items, &models.ChannelMemberResponse{
+			UserID:   member.UserID,
+			Username: member.User.Username,
+			Avatar:   member.User.Avatar,
+			Role:     member.Role,
+			JoinedAt: member.CreatedAt,
+		})
+	}
+	return items, nil
+}
+
+// UpdateMemberRole changes the role of a member. Members may only change
+// roles ranked below their own, to another role ranked below their own.
+func (s *Service) UpdateMemberRole(ref string, actorID, userID uint, req *models.ChannelMemberUpdateRequest) (*models.ChannelMemberResponse, error) {
+	if err := validateMemberRole(req.Role); err != nil {
+		return nil, err
+	}
+
+	var member *models.ChannelMember
+
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		channel, err := findChannel(tx, ref)
+		if err != nil {
+			return err
+		}
+		actorRole, err := services.CheckChannelPermission(tx, channel, actorID, models.ChannelPermissionMembersManage)
+		if err != nil {
+			return err
+		}
+		if userID == channel.UserID {
+			return apperrors.New(apperrors.ErrCodeInvalidOperation, "Cannot change role", "The owner keeps their role until ownership is transferred")
+		}
+
+		member, err = findMember(tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("User"), channel.ID, userID)
+		if err != nil {
+			return err
+		}
+		if err := authorizeRank(actorRole, member.Role); err != nil {
+			return err
+		}
+		if err := authorizeRank(actorRole, req.Role); err != nil {
+			return err
+		}
+
+		member.Role = req.Role
+		member.UpdatedAt = time.Now()
+		if err := tx.Model(member).Select("role", "updated_at").Updates(member).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to update member", err.Error())
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.ChannelMemberResponse{
+		UserID:   member.UserID,
+		Username: member.User.Username,
+		Avatar:   member.User.Avatar,
+		Role:     member.Role,
+		JoinedAt: member.CreatedAt,
+	}, nil
+}
+
+// RemoveMember removes a member from the channel. Members may always leave;
+// removing someone else needs a role ranked above theirs. Pending invitations
+// sent by the removed member are revoked with them.
+func (s *Service) RemoveMember(ref string, actorID, userID uint) error {
+	return s.WithTransaction(func(tx *gorm.DB) error {
+		channel, err := findChannel(tx, ref)
+		if err != nil {
+			return err
+		}
+		if userID == channel.UserID {
+			return apperrors.New(apperrors.ErrCodeInvalidOperation, "Cannot remove owner", "Transfer ownership before leaving the channel")
+		}
+
+		member, err := findMember(tx.Clauses(clause.Locking{Strength: "UPDATE"}), channel.ID, userID)
+		if err != nil {
+			return err
+		}
+		if actorID != userID {
+			actorRole, err := services.CheckChannelPermission(tx, channel, actorID, models.ChannelPermissionMembersManage)
+			if err != nil {
+				return err
+			}
+			if err := authorizeRank(actorRole, member.Role); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Delete(member).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to remove member", err.Error())
+		}
+		return revokeInvitationsBy(tx, channel.ID, userID)
+	})
+}
+
+// InviteMember invites the user identified by a username or email address to
+// the channel with the given role. Inviting a user with a pending invitation
+// replaces its role and restarts its expiry.
+func (s *Service) InviteMember(ref string, actorID uint, req *models.ChannelInvitationRequest) (*models.ChannelInvitationResponse, error) {
+	if err := validateMemberRole(req.Role); err != nil {
+		return nil, err
+	}
+
+	var invitation models.ChannelInvitation
+
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		channel, err := findChannel(tx, ref)
+		if err != nil {
+			return err
+		}
+		actorRole, err := services.CheckChannelPermission(tx, channel, actorID, models.ChannelPermissionMembersManage)
+		if err != nil {
+			return err
+		}
+		if err := authorizeRank(actorRole, req.Role); err != nil {
+			return err
+		}
+
+		invitee, err := findInvitee(tx, req.Identifier)
+		if err != nil {
+			return err
+		}
+		role, err := services.ChannelRole(tx, channel, invitee.ID)
+		if err != nil {
+			return err
+		}
+		if role != "" {
+			return apperrors.New(apperrors.ErrCodeDuplicateRecord, "Already a member", invitee.Username+" is already a member of this channel")
+		}
+
+		now := time.Now()
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("channel_id = ? AND invitee_id = ? AND status = ?", channel.ID, invitee.ID, models.InvitationStatusPending).
+			First(&invitation).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			invitation = models.ChannelInvitation{
+				ChannelID:   channel.ID,
+				InviteeID:   invitee.ID,
+				InvitedByID: actorID,
+				Role:        req.Role,
+				Status:      models.InvitationStatusPending,
+				ExpiresAt:   now.Add(invitationTTL),
+			}
+			if err := tx.Create(&invitation).Error; err != nil {
+				return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to create invitation", err.Error())
+			}
+			return nil
+		}
+		if err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to load invitation", err.Error())
+		}
+
+		invitation.InvitedByID = actorID
+		invitation.Role = req.Role
+		invitation.ExpiresAt = now.Add(invitationTTL)
+		invitation.UpdatedAt = now
+		if err := tx.Model(&invitation).Select("invited_by_id", "role", "expires_at", "updated_at").Updates(&invitation).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to update invitation", err.Error())
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toInvitationResponse(&invitation), nil
+}
+
+// ListInvitations returns the pending invitations of the channel, most
+// recent first
+func (s *Service) ListInvitations(ref string, actorID uint) ([]*models.ChannelInvitationResponse, error) {
+	db := s.GetDB()
+
+	channel, err := findChannel(db, ref)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := services.CheckChannelPermission(db, channel, actorID, models.ChannelPermissionMembersManage); err != nil {
+		return nil, err
+	}
+
+	var invitations []models.ChannelInvitation
+	if err := db.Where("channel_id = ? AND status = ? AND expires_at > ?", channel.ID, models.InvitationStatusPending, time.Now()).
+		Order("created_at DESC, id DESC").Find(&invitations).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to list invitations", err.Error())
+	}
+
+	items := make([]*models.ChannelInvitationResponse, 0, len(invitations))
+	for i := range invitations {
+		items = append(items, toInvitationResponse(&invitations[i]))
+	}
+	return items, nil
+}
+
+// RevokeInvitation withdraws a pending invitation. The same rank rules as
+// for inviting apply.
+func (s *Service) RevokeInvitation(ref string, actorID, invitationID uint) error {
+	return s.WithTransaction(func(tx *gorm.DB) error {
+		channel, err := findChannel(tx, ref)
+		if err != nil {
+			return err
+		}
+		actorRole, err := services.CheckChannelPermission(tx, channel, actorID, models.ChannelPermissionMembersManage)
+		if err != nil {
+			return err
+		}
+
+		invitation, err := findPendingInvitation(tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("channel_id = ?", channel.ID), invitationID)
+		if err != nil {
+			return err
+		}
+		if err := authorizeRank(actorRole, invitation.Role); err != nil {
+			return err
+		}
+
+		return respondToInvitation(tx, invitation, models.InvitationStatusRevoked)
+	})
+}
+
+// ListMyInvitations returns the pending invitations of the user with their
+// channels, most recent first
+func (s *Service) ListMyInvitations(userID uint) ([]*models.ChannelInvitationResponse, error) {
+	var invitations []models.ChannelInvitation
+	if err := s.GetDB().Preload("Channel").
+		Where("invitee_id = ? AND status = ? AND expires_at > ?", userID, models.InvitationStatusPending, time.Now()).
+		Order("created_at DESC, id DESC").Find(&invitations).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to list invitations", err.Error())
+	}
+
+	items := make([]*models.ChannelInvitationResponse, 0, len(invitations))
+	for i := range invitations {
+		item := toInvitationResponse(&invitations[i])
+		item.Channel = toChannelResponse(&invitations[i].Channel)
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// AcceptInvitation makes the user a member of the channel with the role of
+// the invitation
+func (s *Service) AcceptInvitation(userID, invitationID uint) (*models.ChannelInvitationResponse, error) {
+	var invitation *models.ChannelInvitation
+
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		var err error
+		invitation, err = findPendingInvitation(tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("invitee_id = ?", userID), invitationID)
+		if err != nil {
+			return err
+		}
+
+		var channel models.Channel
+		if err := tx.First(&channel, invitation.ChannelID).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to load channel", err.Error())
+		}
+		role, err := services.ChannelRole(tx, &channel, userID)
+		if err != nil {
+			return err
+		}
+		if role != "" {
+			return apperrors.New(apperrors.ErrCodeDuplicateRecord, "Already a member", "You are already a member of this channel")
+		}
+
+		inviterID := invitation.InvitedByID
+		member := models.ChannelMember{
+			ChannelID:   channel.ID,
+			UserID:      userID,
+			Role:        invitation.Role,
+			InvitedByID: &inviterID,
+		}
+		if err := tx.Create(&member).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to add member", err.Error())
+		}
+
+		return respondToInvitation(tx, invitation, models.InvitationStatusAccepted)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toInvitationResponse(invitation), nil
+}
+
+// DeclineInvitation declines a pending invitation of the user
+func (s *Service) DeclineInvitation(userID, invitationID uint) error {
+	return s.WithTransaction(func(tx *gorm.DB) error {
+		invitation, err := findPendingInvitation(tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("invitee_id = ?", userID), invitationID)
+		if err != nil {
+			return err
+		}
+		return respondToInvitation(tx, invitation, models.InvitationStatusDeclined)
+	})
+}
+
+// TransferOwnership hands the channel over to one of its members. The
+// previous owner stays on as a manager. Only the owner may transfer it.
+func (s *Service) TransferOwnership(ref string, actorID uint, req *models.ChannelTransferRequest) (*models.ChannelResponse, error) {
+	var channel *models.Channel
+
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		var err error
+		channel, err = findChannel(tx.Clauses(clause.Locking{Strength: "UPDATE"}), ref)
+		if err != nil {
+			return err
+		}
+		if _, err := services.CheckChannelPermission(tx, channel, actorID, models.ChannelPermissionTransfer); err != nil {
+			return err
+		}
+		if req.UserID == channel.UserID {
+			return apperrors.New(apperrors.ErrCodeInvalidOperation, "Cannot transfer", "You already own this channel")
+		}
+
+		member, err := findMember(tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("User"), channel.ID, req.UserID)
+		if err != nil {
+			return err
+		}
+		if !member.User.IsActive {
+			return apperrors.New(apperrors.ErrCodeInvalidOperation, "Cannot transfer", "The new owner's account is not active")
+		}
+
+		if err := tx.Delete(member).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to transfer channel", err.Error())
+		}
+		if err := tx.Create(&models.ChannelMember{
+			ChannelID: channel.ID,
+			UserID:    channel.UserID,
+			Role:      models.ChannelRoleManager,
+		}).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to transfer channel", err.Error())
+		}
+
+		// Owners cannot subscribe to or be invited to their own channel
+		result := tx.Where("user_id = ? AND channel_id = ?", req.UserID, channel.ID).Delete(&models.Subscription{})
+		if result.Error != nil {
+			return apperrors.Wrap(result.Error, apperrors.ErrCodeDatabaseError, "Failed to transfer channel", result.Error.Error())
+		}
+		if result.RowsAffected > 0 {
+			if err := adjustSubscriberCount(tx, channel.ID, -1); err != nil {
+				return err
+			}
+			channel.SubscriberCount = max(channel.SubscriberCount-1, 0)
+		}
+		if err := tx.Model(&models.ChannelInvitation{}).
+			Where("channel_id = ? AND invitee_id = ? AND status = ?", channel.ID, req.UserID, models.InvitationStatusPending).
+			Updates(map[string]interface{}{"status": models.InvitationStatusRevoked, "responded_at": time.Now()}).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to transfer channel", err.Error())
+		}
+
+		channel.UserID = req.UserID
+		channel.UpdatedAt = time.Now()
+		if err := tx.Model(channel).Select("user_id", "updated_at").Updates(channel).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to transfer channel", err.Error())
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toChannelResponse(channel), nil
+}
+
+func findMember(db *gorm.DB, channelID, userID uint) (*models.ChannelMember, error) {
+	var member models.ChannelMember
+	if err := db.Where("channel_id = ? AND user_id = ?", channelID, userID).First(&member).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.New(apperrors.ErrCodeRecordNotFound, "Member not found", "The user is not a member of this channel")
+		}
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to load member", err.Error())
+	}
+	return &member, nil
+}
+
+// findInvitee looks up an active user by username or, when the identifier
+// contains "@", by email address
+func findInvitee(db *gorm.DB, identifier string) (*models.User, error) {
+	identifier = normalizeIdentifier(identifier)
+
+	column := "username"
+	if strings.Contains(identifier, "@") {
+		column = "email"
+	}
+
+	var user models.User
+	if err := db.Where("LOWER("+column+") = ?", identifier).First(&user).Error; err != nil || !user.IsActive {
+		if err == nil || errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.New(apperrors.ErrCodeUserNotFound, "User not found", "No active user matches "+identifier)
+		}
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to load user", err.Error())
+	}
+	return &user, nil
+}
+
+// findPendingInvitation loads an invitation that can still be answered.
+// Expired invitations are reported as not found.
+func findPendingInvitation(db *gorm.DB, invitationID uint) (*models.ChannelInvitation, error) {
+	var invitation models.ChannelInvitation
+	err := db.Where("id = ? AND status = ? AND expires_at > ?", invitationID, models.InvitationStatusPending, time.Now()).First(&invitation).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.New(apperrors.ErrCodeRecordNotFound, "Invitation not found", "The invitation does not exist, has expired or was already answered")
+		}
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to load invitation", err.Error())
+	}
+	return &invitation, nil
+}
+
+func respondToInvitation(tx *gorm.DB, invitation *models.ChannelInvitation, status string) error {
+	now := time.Now()
+	invitation.Status = status
+	invitation.RespondedAt = &now
+	invitation.UpdatedAt = now
+	if err := tx.Model(invitation).Select("status", "responded_at", "updated_at").Updates(invitation).Error; err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to update invitation", err.Error())
+	}
+	return nil
+}
+
+// revokeInvitationsBy revokes the pending invitations a user sent for the
+// channel, so they cannot be accepted after the user lost access
+func revokeInvitationsBy(tx *gorm.DB, channelID, userID uint) error {
+	if err := tx.Model(&models.ChannelInvitation{}).
+		Where("channel_id = ? AND invited_by_id = ? AND status = ?", channelID, userID, models.InvitationStatusPending).
+		Updates(map[string]interface{}{"status": models.InvitationStatusRevoked, "responded_at": time.Now()}).Error; err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to revoke invitations", err.Error())
+	}
+	return nil
+}
+
+// authorizeRank allows an actor to grant or manage only roles ranked below
+// their own
+func authorizeRank(actorRole, role string) error {
+	if models.ChannelRoleRanks[actorRole] <= models.ChannelRoleRanks[role] {
+		return apperrors.New(apperrors.ErrCodeForbidden, "Forbidden", "You can only manage roles ranked below your own").
+			AddMetadata("role", role)
+	}
+	return nil
+}
+
+func toInvitationResponse(invitation *models.ChannelInvitation) *models.ChannelInvitationResponse {
+	return &models.ChannelInvitationResponse{
+		ID:          invitation.ID,
+		ChannelID:   invitation.ChannelID,
+		InviteeID:   invitation.InviteeID,
+		InvitedByID: invitation.InvitedByID,
+		Role:        invitation.Role,
+		Status:      invitation.Status,
+		ExpiresAt:   invitation.ExpiresAt,
+		RespondedAt: invitation.RespondedAt,
+		CreatedAt:   invitation.CreatedAt,
+	}
+}
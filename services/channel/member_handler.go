@@ -0,0 +1,346 @@
+package channel
+
+import (
+	"kube/pkg/errors"
+	"kube/pkg/models"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// ListMembers godoc
+// @Summary List channel members
+// @Description List the owner of a channel followed by its members in the order they joined. Requires the members:read channel permission, which every role has.
+// @Tags channel-members
+// @Produce json
+// @Security BearerAuth
+// @Param channel path string true "Channel ID or handle"
+// @Success 200 {object} map[string]interface{} "Members retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Not a member of the channel"
+// @Failure 404 {object} map[string]interface{} "Channel not found"
+// @Router /api/v1/channels/{channel}/members [get]
+func (h *Handler) ListMembers(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	members, err := h.service.ListMembers(c.Param("channel"), userID)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, members, "Members retrieved successfully")
+}
+
+// UpdateMember godoc
+// @Summary Change a member's role
+// @Description Change the role of a channel member. Requires the members:manage channel permission; both the current and the new role must rank below the caller's role (owner > manager > editor > viewer-analytics).
+// @Tags channel-members
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param channel path string true "Channel ID or handle"
+// @Param userId path int true "Member user ID"
+// @Param request body models.ChannelMemberUpdateRequest true "New role: manager, editor or viewer-analytics"
+// @Success 200 {object} map[string]interface{} "Member updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid role or user ID, or the user is the owner"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Channel role does not allow this action"
+// @Failure 404 {object} map[string]interface{} "Channel or member not found"
+// @Router /api/v1/channels/{channel}/members/{userId} [put]
+func (h *Handler) UpdateMember(c *app.RequestContext) {
+	actorID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	userID, err := h.GetParamUint(c, "userId")
+	if err != nil {
+		h.SendValidationError(c, "Invalid user ID format")
+		return
+	}
+
+	var req models.ChannelMemberUpdateRequest
+	if err := c.BindJSON(&req); err != nil {
+		h.SendValidationError(c, "Invalid request data format")
+		return
+	}
+
+	member, err := h.service.UpdateMemberRole(c.Param("channel"), actorID, userID, &req)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, member, "Member updated successfully")
+}
+
+// RemoveMember godoc
+// @Summary Remove a member
+// @Description Remove a member from a channel. Members can always remove themselves to leave; removing someone else requires the members:manage channel permission and a role ranked above theirs. The owner cannot be removed.
+// @Tags channel-members
+// @Produce json
+// @Security BearerAuth
+// @Param channel path string true "Channel ID or handle"
+// @Param userId path int true "Member user ID"
+// @Success 200 {object} map[string]interface{} "Member removed successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID, or the user is the owner"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Channel role does not allow this action"
+// @Failure 404 {object} map[string]interface{} "Channel or member not found"
+// @Router /api/v1/channels/{channel}/members/{userId} [delete]
+func (h *Handler) RemoveMember(c *app.RequestContext) {
+	actorID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	userID, err := h.GetParamUint(c, "userId")
+	if err != nil {
+		h.SendValidationError(c, "Invalid user ID format")
+		return
+	}
+
+	if err := h.service.RemoveMember(c.Param("channel"), actorID, userID); err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, nil, "Member removed successfully")
+}
+
+// InviteMember godoc
+// @Summary Invite a member
+// @Description Invite a user, identified by username or email address, to a channel. Requires the members:manage channel permission and the role must rank below the caller's role. Inviting a user with a pending invitation replaces its role and restarts its 7 day expiry.
+// @Tags channel-members
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param channel path string true "Channel ID or handle"
+// @Param request body models.ChannelInvitationRequest true "Username or email, and role: manager, editor or viewer-analytics"
+// @Success 201 {object} map[string]interface{} "Invitation sent successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request data or role"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Channel role does not allow this action"
+// @Failure 404 {object} map[string]interface{} "Channel or user not found"
+// @Failure 409 {object} map[string]interface{} "User is already a member"
+// @Router /api/v1/channels/{channel}/invitations [post]
+func (h *Handler) InviteMember(c *app.RequestContext) {
+	actorID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	var req models.ChannelInvitationRequest
+	if err := c.BindJSON(&req); err != nil {
+		h.SendValidationError(c, "Invalid request data format")
+		return
+	}
+
+	invitation, err := h.service.InviteMember(c.Param("channel"), actorID, &req)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 201, invitation, "Invitation sent successfully")
+}
+
+// ListInvitations godoc
+// @Summary List channel invitations
+// @Description List the pending invitations of a channel, most recent first. Requires the members:manage channel permission.
+// @Tags channel-members
+// @Produce json
+// @Security BearerAuth
+// @Param channel path string true "Channel ID or handle"
+// @Success 200 {object} map[string]interface{} "Invitations retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Channel role does not allow this action"
+// @Failure 404 {object} map[string]interface{} "Channel not found"
+// @Router /api/v1/channels/{channel}/invitations [get]
+func (h *Handler) ListInvitations(c *app.RequestContext) {
+	actorID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	invitations, err := h.service.ListInvitations(c.Param("channel"), actorID)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, invitations, "Invitations retrieved successfully")
+}
+
+// RevokeInvitation godoc
+// @Summary Revoke an invitation
+// @Description Withdraw a pending invitation. Requires the members:manage channel permission and the invited role must rank below the caller's role.
+// @Tags channel-members
+// @Produce json
+// @Security BearerAuth
+// @Param channel path string true "Channel ID or handle"
+// @Param invitationId path int true "Invitation ID"
+// @Success 200 {object} map[string]interface{} "Invitation revoked successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid invitation ID"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Channel role does not allow this action"
+// @Failure 404 {object} map[string]interface{} "Channel or pending invitation not found"
+// @Router /api/v1/channels/{channel}/invitations/{invitationId} [delete]
+func (h *Handler) RevokeInvitation(c *app.RequestContext) {
+	actorID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	invitationID, err := h.GetParamUint(c, "invitationId")
+	if err != nil {
+		h.SendValidationError(c, "Invalid invitation ID format")
+		return
+	}
+
+	if err := h.service.RevokeInvitation(c.Param("channel"), actorID, invitationID); err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, nil, "Invitation revoked successfully")
+}
+
+// TransferOwnership godoc
+// @Summary Transfer channel ownership
+// @Description Hand a channel over to one of its members. Only the owner can transfer a channel; the previous owner stays on as a manager.
+// @Tags channel-members
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param channel path string true "Channel ID or handle"
+// @Param request body models.ChannelTransferRequest true "User ID of the new owner"
+// @Success 200 {object} map[string]interface{} "Ownership transferred successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request data or inactive new owner"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Not the channel owner"
+// @Failure 404 {object} map[string]interface{} "Channel not found or the user is not a member"
+// @Router /api/v1/channels/{channel}/transfer [post]
+func (h *Handler) TransferOwnership(c *app.RequestContext) {
+	actorID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	var req models.ChannelTransferRequest
+	if err := c.BindJSON(&req); err != nil {
+		h.SendValidationError(c, "Invalid request data format")
+		return
+	}
+
+	channel, err := h.service.TransferOwnership(c.Param("channel"), actorID, &req)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, channel, "Ownership transferred successfully")
+}
+
+// ListMyInvitations godoc
+// @Summary List my channel invitations
+// @Description List the pending invitations of the authenticated user with their channels, most recent first
+// @Tags channel-members
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Invitations retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Router /api/v1/channel-invitations [get]
+func (h *Handler) ListMyInvitations(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	invitations, err := h.service.ListMyInvitations(userID)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, invitations, "Invitations retrieved successfully")
+}
+
+// AcceptInvitation godoc
+// @Summary Accept a channel invitation
+// @Description Accept a pending invitation of the authenticated user and join the channel with the invited role
+// @Tags channel-members
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Invitation ID"
+// @Success 200 {object} map[string]interface{} "Invitation accepted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid invitation ID"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 404 {object} map[string]interface{} "Invitation not found, expired or already answered"
+// @Failure 409 {object} map[string]interface{} "Already a member"
+// @Router /api/v1/channel-invitations/{id}/accept [post]
+func (h *Handler) AcceptInvitation(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	invitationID, err := h.GetParamUint(c, "id")
+	if err != nil {
+		h.SendValidationError(c, "Invalid invitation ID format")
+		return
+	}
+
+	invitation, err := h.service.AcceptInvitation(userID, invitationID)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, invitation, "Invitation accepted successfully")
+}
+
+// DeclineInvitation godoc
+// @Summary Decline a channel invitation
+// @Description Decline a pending invitation of the authenticated user
+// @Tags channel-members
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Invitation ID"
+// @Success 200 {object} map[string]interface{} "Invitation declined successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid invitation ID"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 404 {object} map[string]interface{} "Invitation not found, expired or already answered"
+// @Router /api/v1/channel-invitations/{id}/decline [post]
+func (h *Handler) DeclineInvitation(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	invitationID, err := h.GetParamUint(c, "id")
+	if err != nil {
+		h.SendValidationError(c, "Invalid invitation ID format")
+		return
+	}
+
+	if err := h.service.DeclineInvitation(userID, invitationID); err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, nil, "Invitation declined successfully")
+}
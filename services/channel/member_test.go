@@ -0,0 +1,222 @@
+package channel
+
+import (
+	"fmt"
+	"testing"
+
+	"kube/internal/testutil"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+	"kube/pkg/services"
+)
+
+// memberFixture is a channel with one member of every role
+type memberFixture struct {
+	s                            *Service
+	ownerID, managerID, editorID uint
+	analystID, outsiderID        uint
+}
+
+func newMemberFixture(t *testing.T) *memberFixture {
+	t.Helper()
+	s := newTestService(t)
+	f := &memberFixture{
+		s:          s,
+		ownerID:    createTestUser(t, s, "owner"),
+		managerID:  createTestUser(t, s, "manager"),
+		editorID:   createTestUser(t, s, "editor"),
+		analystID:  createTestUser(t, s, "analyst"),
+		outsiderID: createTestUser(t, s, "outsider"),
+	}
+	channel := createTestChannel(t, s, f.ownerID, "news")
+	addTestMember(t, s, channel.ID, f.managerID, models.ChannelRoleManager)
+	addTestMember(t, s, channel.ID, f.editorID, models.ChannelRoleEditor)
+	addTestMember(t, s, channel.ID, f.analystID, models.ChannelRoleViewerAnalytics)
+	return f
+}
+
+func TestInviteMemberRoleRanks(t *testing.T) {
+	f := newMemberFixture(t)
+
+	for _, tt := range []struct {
+		actor uint
+		role  string
+		want  string
+	}{
+		{f.ownerID, models.ChannelRoleManager, ""},
+		{f.managerID, models.ChannelRoleEditor, ""},
+		{f.managerID, models.ChannelRoleViewerAnalytics, ""},
+		{f.managerID, models.ChannelRoleManager, apperrors.ErrCodeForbidden},
+		{f.editorID, models.ChannelRoleViewerAnalytics, apperrors.ErrCodeForbidden},
+		{f.outsiderID, models.ChannelRoleViewerAnalytics, apperrors.ErrCodeForbidden},
+		{f.ownerID, models.ChannelRoleOwner, apperrors.ErrCodeValidationFailed},
+		{f.ownerID, "superuser", apperrors.ErrCodeValidationFailed},
+	} {
+		_, err := f.s.InviteMember("news", tt.actor, &models.ChannelInvitationRequest{Identifier: "outsider", Role: tt.role})
+		if tt.want == "" {
+			if err != nil {
+				t.Errorf("user %d inviting a %s: %v", tt.actor, tt.role, err)
+			}
+			continue
+		}
+		testutil.AssertErrorCode(t, err, tt.want)
+	}
+
+	// Members and the owner cannot be invited
+	_, err := f.s.InviteMember("news", f.ownerID, &models.ChannelInvitationRequest{Identifier: "EDITOR@example.com", Role: models.ChannelRoleEditor})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeDuplicateRecord)
+	_, err = f.s.InviteMember("news", f.managerID, &models.ChannelInvitationRequest{Identifier: "owner", Role: models.ChannelRoleEditor})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeDuplicateRecord)
+}
+
+func TestUpdateMemberRoleRanks(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		actor  func(*memberFixture) uint
+		target func(*memberFixture) uint
+		role   string
+		want   string
+	}{
+		{"owner promotes an editor", func(f *memberFixture) uint { return f.ownerID }, func(f *memberFixture) uint { return f.editorID }, models.ChannelRoleManager, ""},
+		{"manager demotes an editor", func(f *memberFixture) uint { return f.managerID }, func(f *memberFixture) uint { return f.editorID }, models.ChannelRoleViewerAnalytics, ""},
+		{"manager promotes to manager", func(f *memberFixture) uint { return f.managerID }, func(f *memberFixture) uint { return f.analystID }, models.ChannelRoleManager, apperrors.ErrCodeForbidden},
+		{"manager demotes itself", func(f *memberFixture) uint { return f.managerID }, func(f *memberFixture) uint { return f.managerID }, models.ChannelRoleEditor, apperrors.ErrCodeForbidden},
+		{"editor changes an analyst", func(f *memberFixture) uint { return f.editorID }, func(f *memberFixture) uint { return f.analystID }, models.ChannelRoleViewerAnalytics, apperrors.ErrCodeForbidden},
+		{"owner's role", func(f *memberFixture) uint { return f.ownerID }, func(f *memberFixture) uint { return f.ownerID }, models.ChannelRoleManager, apperrors.ErrCodeInvalidOperation},
+		{"not a member", func(f *memberFixture) uint { return f.ownerID }, func(f *memberFixture) uint { return f.outsiderID }, models.ChannelRoleEditor, apperrors.ErrCodeRecordNotFound},
+	} {
+		f := newMemberFixture(t)
+		member, err := f.s.UpdateMemberRole("news", tt.actor(f), tt.target(f), &models.ChannelMemberUpdateRequest{Role: tt.role})
+		if tt.want == "" {
+			if err != nil || member.Role != tt.role {
+				t.Errorf("%s: (%+v, %v)", tt.name, member, err)
+			}
+			continue
+		}
+		if !apperrors.IsAppError(err) || apperrors.GetAppError(err).Code != tt.want {
+			t.Errorf("%s: error %v, want %s", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestRemoveMemberRoleRanks(t *testing.T) {
+	f := newMemberFixture(t)
+
+	err := f.s.RemoveMember("news", f.editorID, f.analystID)
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeForbidden)
+	err = f.s.RemoveMember("news", f.managerID, f.ownerID)
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeInvalidOperation)
+
+	// A removed manager's pending invitations go with them
+	invitation, err := f.s.InviteMember("news", f.managerID, &models.ChannelInvitationRequest{Identifier: "outsider", Role: models.ChannelRoleEditor})
+	if err != nil {
+		t.Fatalf("InviteMember: %v", err)
+	}
+	if err := f.s.RemoveMember("news", f.ownerID, f.managerID); err != nil {
+		t.Fatalf("RemoveMember: %v", err)
+	}
+	_, err = f.s.AcceptInvitation(f.outsiderID, invitation.ID)
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeRecordNotFound)
+
+	// Anyone may leave
+	if err := f.s.RemoveMember("news", f.analystID, f.analystID); err != nil {
+		t.Errorf("leaving the channel: %v", err)
+	}
+
+	members, err := f.s.ListMembers("news", f.editorID)
+	if err != nil {
+		t.Fatalf("ListMembers: %v", err)
+	}
+	var roles []string
+	for _, member := range members {
+		roles = append(roles, member.Username+":"+member.Role)
+	}
+	if got := fmt.Sprint(roles); got != "[owner:owner editor:editor]" {
+		t.Errorf("members %s", got)
+	}
+}
+
+func TestInvitationLifecycle(t *testing.T) {
+	f := newMemberFixture(t)
+
+	invitation, err := f.s.InviteMember("news", f.managerID, &models.ChannelInvitationRequest{Identifier: "Outsider@Example.com", Role: models.ChannelRoleViewerAnalytics})
+	if err != nil {
+		t.Fatalf("InviteMember: %v", err)
+	}
+	// Inviting again replaces the role of the pending invitation
+	again, err := f.s.InviteMember("news", f.managerID, &models.ChannelInvitationRequest{Identifier: "outsider", Role: models.ChannelRoleEditor})
+	if err != nil {
+		t.Fatalf("InviteMember again: %v", err)
+	}
+	if again.ID != invitation.ID || again.Role != models.ChannelRoleEditor {
+		t.Errorf("second invitation %+v, want the first one with the new role", again)
+	}
+
+	mine, err := f.s.ListMyInvitations(f.outsiderID)
+	if err != nil || len(mine) != 1 || mine[0].Channel == nil || mine[0].Channel.Handle != "news" {
+		t.Fatalf("ListMyInvitations = (%+v, %v)", mine, err)
+	}
+
+	// Only the invitee can answer
+	_, err = f.s.AcceptInvitation(f.editorID, invitation.ID)
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeRecordNotFound)
+
+	if _, err := f.s.AcceptInvitation(f.outsiderID, invitation.ID); err != nil {
+		t.Fatalf("AcceptInvitation: %v", err)
+	}
+	channel, err := findChannel(f.s.GetDB(), "news")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role, _ := services.ChannelRole(f.s.GetDB(), channel, f.outsiderID); role != models.ChannelRoleEditor {
+		t.Errorf("role after accepting %q, want editor", role)
+	}
+	err = f.s.DeclineInvitation(f.outsiderID, invitation.ID)
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeRecordNotFound)
+
+	// Editors cannot revoke invitations, managers only those below them
+	bobID := createTestUser(t, f.s, "bob")
+	pending, err := f.s.InviteMember("news", f.ownerID, &models.ChannelInvitationRequest{Identifier: "bob", Role: models.ChannelRoleManager})
+	if err != nil {
+		t.Fatalf("InviteMember: %v", err)
+	}
+	testutil.AssertErrorCode(t, f.s.RevokeInvitation("news", f.editorID, pending.ID), apperrors.ErrCodeForbidden)
+	testutil.AssertErrorCode(t, f.s.RevokeInvitation("news", f.managerID, pending.ID), apperrors.ErrCodeForbidden)
+	if err := f.s.RevokeInvitation("news", f.ownerID, pending.ID); err != nil {
+		t.Fatalf("RevokeInvitation: %v", err)
+	}
+	_, err = f.s.AcceptInvitation(bobID, pending.ID)
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeRecordNotFound)
+}
+
+func TestTransferOwnership(t *testing.T) {
+	f := newMemberFixture(t)
+	if _, err := f.s.Subscribe("news", f.editorID, nil); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+
+	_, err := f.s.TransferOwnership("news", f.managerID, &models.ChannelTransferRequest{UserID: f.editorID})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeForbidden)
+	_, err = f.s.TransferOwnership("news", f.ownerID, &models.ChannelTransferRequest{UserID: f.outsiderID})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeRecordNotFound)
+
+	channel, err := f.s.TransferOwnership("news", f.ownerID, &models.ChannelTransferRequest{UserID: f.editorID})
+	if err != nil {
+		t.Fatalf("TransferOwnership: %v", err)
+	}
+	if channel.UserID != f.editorID || channel.SubscriberCount != 0 {
+		t.Errorf("unexpected channel after the transfer %+v", channel)
+	}
+
+	// The previous owner stays on as a manager and cannot take it back
+	_, err = f.s.TransferOwnership("news", f.ownerID, &models.ChannelTransferRequest{UserID: f.ownerID})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeForbidden)
+	if _, err := services.AuthorizeChannel(f.s.GetDB(), channel.ID, f.ownerID, models.ChannelPermissionVideosManage); err != nil {
+		t.Errorf("previous owner cannot manage videos: %v", err)
+	}
+	_, err = services.AuthorizeChannel(f.s.GetDB(), channel.ID, f.ownerID, models.ChannelPermissionDelete)
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeForbidden)
+	if n := subscriberCount(t, f.s, "news"); n != 0 {
+		t.Errorf("subscriber count %d, want 0 once the subscriber owns the channel", n)
+	}
+}
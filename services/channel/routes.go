@@ -0,0 +1,56 @@
+package channel
+
+import (
+	"context"
+
+	"kube/internal/middleware"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+)
+
+func RegisterRoutes(h *server.Hertz, service *Service, authMiddleware app.HandlerFunc) {
+	handler := NewHandler(service)
+
+	// Channel routes; :channel is a numeric ID or a handle
+	api := h.Group("/api/v1/channels")
+	{
+		// API keys carry no channel scopes, so channels are only managed
+		// from a login session
+		sessionOnly := middleware.RequireTokenAuth()
+
+		api.GET("", func(ctx context.Context, c *app.RequestContext) { handler.ListChannels(c) })
+		api.GET("/:channel", func(ctx context.Context, c *app.RequestContext) { handler.GetChannel(c) })
+		api.POST("", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.CreateChannel(c) })
+		api.PUT("/:channel", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.UpdateChannel(c) })
+		api.DELETE("/:channel", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.DeleteChannel(c) })
+
+		api.GET("/:channel/subscription", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.GetSubscription(c) })
+		api.PUT("/:channel/subscription", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.Subscribe(c) })
+		api.PATCH("/:channel/subscription", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.UpdateSubscription(c) })
+		api.DELETE("/:channel/subscription", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.Unsubscribe(c) })
+		api.GET("/:channel/subscribers", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.ListSubscribers(c) })
+
+		api.GET("/:channel/members", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.ListMembers(c) })
+		api.PUT("/:channel/members/:userId", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.UpdateMember(c) })
+		api.DELETE("/:channel/members/:userId", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.RemoveMember(c) })
+		api.GET("/:channel/invitations", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.ListInvitations(c) })
+		api.POST("/:channel/invitations", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.InviteMember(c) })
+		api.DELETE("/:channel/invitations/:invitationId", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.RevokeInvitation(c) })
+		api.POST("/:channel/transfer", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.TransferOwnership(c) })
+	}
+
+	// Subscriptions of the authenticated user
+	subscriptions := h.Group("/api/v1/subscriptions", authMiddleware, middleware.RequireTokenAuth())
+	{
+		subscriptions.GET("", func(ctx context.Context, c *app.RequestContext) { handler.ListSubscriptions(c) })
+	}
+
+	// Channel invitations addressed to the authenticated user
+	invitations := h.Group("/api/v1/channel-invitations", authMiddleware, middleware.RequireTokenAuth())
+	{
+		invitations.GET("", func(ctx context.Context, c *app.RequestContext) { handler.ListMyInvitations(c) })
+		invitations.POST("/:id/accept", func(ctx context.Context, c *app.RequestContext) { handler.AcceptInvitation(c) })
+		invitations.POST("/:id/decline", func(ctx context.Context, c *app.RequestContext) { handler.DeclineInvitation(c) })
+	}
+}
@@ -0,0 +1,258 @@
+package channel
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"kube/internal/config"
+	"kube/internal/storage"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+	"kube/pkg/services"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type Service struct {
+	*services.BaseService
+	storage *storage.Client
+}
+
+func NewService(db *gorm.DB, cfg *config.Config) *Service {
+	return &Service{
+		BaseService: services.NewBaseService(db),
+		storage:     storage.Init(cfg),
+	}
+}
+
+// CreateChannel creates a channel owned by ownerID. Handles are unique
+// across all channels, ignoring case.
+func (s *Service) CreateChannel(ownerID uint, req *models.ChannelCreateRequest) (*models.ChannelResponse, error) {
+	handle := normalizeHandle(req.Handle)
+	if err := validateHandle(handle); err != nil {
+		return nil, err
+	}
+	if err := validateChannel(req.Name, req.Description, req.BannerImage, req.Avatar); err != nil {
+		return nil, err
+	}
+
+	var channel *models.Channel
+
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		if err := ensureHandleAvailable(tx, handle, 0); err != nil {
+			return err
+		}
+
+		channel = &models.Channel{
+			UserID:      ownerID,
+			Handle:      handle,
+			Name:        req.Name,
+			Description: req.Description,
+			BannerImage: req.BannerImage,
+			Avatar:      req.Avatar,
+			IsActive:    true,
+			CreatedAt:   time.Now(),
+			UpdatedAt:   time.Now(),
+		}
+
+		if err := tx.Create(channel).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to create channel", err.Error())
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toChannelResponse(channel), nil
+}
+
+// GetChannel returns the channel identified by ref, a numeric ID or a handle
+func (s *Service) GetChannel(ref string) (*models.ChannelResponse, error) {
+	channel, err := findChannel(s.GetDB(), ref)
+	if err != nil {
+		return nil, err
+	}
+	return toChannelResponse(channel), nil
+}
+
+// ListChannels returns one page of channels, optionally only those of one
+// owner
+func (s *Service) ListChannels(opts *models.ListOptions) (*models.Page, error) {
+	query := s.GetDB().Model(&models.Channel{})
+
+	if ownerID, ok := opts.Filters["user_id"].(uint); ok {
+		query = query.Where("user_id = ?", ownerID)
+	}
+	if active, ok := opts.Filters["is_active"].(bool); ok {
+		query = query.Where("is_active = ?", active)
+	}
+
+	page, channels, err := services.Paginate(query, opts, opts.Sort, func(channel *models.Channel) (interface{}, uint) {
+		switch opts.Sort {
+		case "handle":
+			return channel.Handle, channel.ID
+		case "name":
+			return channel.Name, channel.ID
+		case "subscriber_count":
+			return channel.SubscriberCount, channel.ID
+		default:
+			return channel.CreatedAt, channel.ID
+		}
+	})
+	if errors.Is(err, services.ErrInvalidCursor) {
+		return nil, apperrors.New(apperrors.ErrCodeInvalidInput, "Invalid cursor", "The cursor is malformed or does not match the sort order")
+	}
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to list channels", err.Error())
+	}
+
+	items := make([]*models.ChannelResponse, 0, len(channels))
+	for i := range channels {
+		items = append(items, toChannelResponse(&channels[i]))
+	}
+	page.Items = items
+	return page, nil
+}
+
+// UpdateChannel replaces the editable fields of the channel. The owner and
+// managers may update it.
+func (s *Service) UpdateChannel(ref string, actorID uint, req *models.ChannelUpdateRequest) (*models.ChannelResponse, error) {
+	if err := validateChannel(req.Name, req.Description, req.BannerImage, req.Avatar); err != nil {
+		return nil, err
+	}
+
+	var channel *models.Channel
+
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		var err error
+		channel, err = findChannel(tx.Clauses(clause.Locking{Strength: "UPDATE"}), ref)
+		if err != nil {
+			return err
+		}
+		if _, err := services.CheckChannelPermission(tx, channel, actorID, models.ChannelPermissionUpdate); err != nil {
+			return err
+		}
+
+		if req.Handle != "" {
+			handle := normalizeHandle(req.Handle)
+			if handle != channel.Handle {
+				if err := validateHandle(handle); err != nil {
+					return err
+				}
+				if err := ensureHandleAvailable(tx, handle, channel.ID); err != nil {
+					return err
+				}
+				channel.Handle = handle
+			}
+		}
+
+		channel.Name = req.Name
+		channel.Description = req.Description
+		channel.BannerImage = req.BannerImage
+		channel.Avatar = req.Avatar
+		channel.UpdatedAt = time.Now()
+
+		if err := tx.Model(channel).Select("handle", "name", "description", "banner_image", "avatar", "updated_at").Updates(channel).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to update channel", err.Error())
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toChannelResponse(channel), nil
+}
+
+// DeleteChannel deletes the channel with its videos. Only the owner may
+// delete it. The video rows go with the channel through their foreign key;
+// the stored video files are deleted once the deletion is committed.
+func (s *Service) DeleteChannel(ref string, actorID uint) error {
+	var storageKeys []string
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		channel, err := findChannel(tx.Clauses(clause.Locking{Strength: "UPDATE"}), ref)
+		if err != nil {
+			return err
+		}
+		if _, err := services.CheckChannelPermission(tx, channel, actorID, models.ChannelPermissionDelete); err != nil {
+			return err
+		}
+
+		// The channel row lock keeps new videos out until the deletion commits
+		if err := tx.Model(&models.Video{}).Where("channel_id = ?", channel.ID).Pluck("storage_key", &storageKeys).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to load channel videos", err.Error())
+		}
+
+		if err := tx.Delete(channel).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to delete channel", err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, key := range storageKeys {
+		if err := s.storage.DeleteFile(key); err != nil {
+			hlog.Errorf("Failed to delete video file %s of a deleted channel: %v", key, err)
+		}
+	}
+	return nil
+}
+
+// findChannel looks a channel up by numeric ID or by handle. Handles start
+// with a letter, so the two never overlap.
+func findChannel(db *gorm.DB, ref string) (*models.Channel, error) {
+	var channel models.Channel
+
+	query := db
+	if id, err := strconv.ParseUint(ref, 10, 64); err == nil {
+		query = query.Where("id = ?", id)
+	} else {
+		query = query.Where("handle = ?", normalizeHandle(ref))
+	}
+
+	if err := query.First(&channel).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.New(apperrors.ErrCodeChannelNotFound, "Channel not found", "Channel "+ref+" does not exist")
+		}
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to load channel", err.Error())
+	}
+	return &channel, nil
+}
+
+// ensureHandleAvailable fails when another channel than exceptID already
+// uses the handle
+func ensureHandleAvailable(tx *gorm.DB, handle string, exceptID uint) error {
+	var count int64
+	if err := tx.Model(&models.Channel{}).Where("handle = ? AND id <> ?", handle, exceptID).Count(&count).Error; err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to check handle", err.Error())
+	}
+	if count > 0 {
+		return apperrors.New(apperrors.ErrCodeHandleTaken, "Handle already taken", "Another channel already uses @"+handle).
+			AddMetadata("fields", map[string]string{"handle": "is already taken"})
+	}
+	return nil
+}
+
+func toChannelResponse(channel *models.Channel) *models.ChannelResponse {
+	return &models.ChannelResponse{
+		ID:              channel.ID,
+		UserID:          channel.UserID,
+		Handle:          channel.Handle,
+		Name:            channel.Name,
+		Description:     channel.Description,
+		BannerImage:     channel.BannerImage,
+		Avatar:          channel.Avatar,
+		IsActive:        channel.IsActive,
+		SubscriberCount: channel.SubscriberCount,
+		CreatedAt:       channel.CreatedAt,
+		UpdatedAt:       channel.UpdatedAt,
+	}
+}
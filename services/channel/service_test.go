@@ -0,0 +1,95 @@
+package channel
+
+import (
+	"errors"
+	"testing"
+
+	"kube/internal/config"
+	"kube/internal/storage"
+	"kube/internal/testutil"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+)
+
+// newTestService returns a service backed by a fresh SQLite database and
+// local storage in a temporary directory
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+	return NewService(testutil.NewDB(t), &config.Config{Storage: testutil.StorageConfig(t.TempDir())})
+}
+
+func createTestUser(t *testing.T, s *Service, username string) uint {
+	t.Helper()
+	user := models.User{Username: username, Email: username + "@example.com", Password: "x", IsActive: true}
+	if err := s.GetDB().Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	return user.ID
+}
+
+func TestDeleteChannelRemovesVideoFiles(t *testing.T) {
+	s := newTestService(t)
+	ownerID := createTestUser(t, s, "owner")
+
+	channel, err := s.CreateChannel(ownerID, &models.ChannelCreateRequest{Handle: "deleted", Name: "Deleted"})
+	if err != nil {
+		t.Fatalf("CreateChannel: %v", err)
+	}
+	other, err := s.CreateChannel(ownerID, &models.ChannelCreateRequest{Handle: "kept", Name: "Kept"})
+	if err != nil {
+		t.Fatalf("CreateChannel: %v", err)
+	}
+
+	videos := map[string]uint{
+		"videos/a/source.mp4": channel.ID,
+		"videos/b/source.mp4": channel.ID,
+		"videos/c/source.mp4": other.ID,
+	}
+	for key, channelID := range videos {
+		if err := s.storage.UploadFile(key, []byte("video")); err != nil {
+			t.Fatal(err)
+		}
+		video := models.Video{UserID: &ownerID, ChannelID: channelID, Title: key, Status: models.VideoStatusUploaded, StorageKey: key}
+		if err := s.GetDB().Create(&video).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := s.DeleteChannel("deleted", ownerID); err != nil {
+		t.Fatalf("DeleteChannel: %v", err)
+	}
+
+	for key, channelID := range videos {
+		_, err := s.storage.DownloadFile(key)
+		if deleted := errors.Is(err, storage.ErrNotFound); deleted != (channelID == channel.ID) {
+			t.Errorf("file %s deleted = %v", key, deleted)
+		}
+	}
+
+	var count int64
+	if err := s.GetDB().Model(&models.Video{}).Where("channel_id = ?", channel.ID).Count(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("%d videos of the deleted channel left", count)
+	}
+}
+
+func TestDeleteChannelRequiresOwner(t *testing.T) {
+	s := newTestService(t)
+	ownerID := createTestUser(t, s, "owner")
+	otherID := createTestUser(t, s, "other")
+
+	if _, err := s.CreateChannel(ownerID, &models.ChannelCreateRequest{Handle: "mine", Name: "Mine"}); err != nil {
+		t.Fatalf("CreateChannel: %v", err)
+	}
+	if err := s.storage.UploadFile("videos/a/source.mp4", []byte("video")); err != nil {
+		t.Fatal(err)
+	}
+
+	err := s.DeleteChannel("mine", otherID)
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeForbidden)
+	if _, err := s.storage.DownloadFile("videos/a/source.mp4"); err != nil {
+		t.Errorf("file deleted after a rejected deletion: %v", err)
+	}
+}
@@ -0,0 +1,244 @@
+package channel
+
+import (
+	"errors"
+
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+	"kube/pkg/services"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Subscribe subscribes the user to the channel. Subscribing again is not an
+// error; it only applies the given notification preferences. The subscriber
+// count of the channel changes in the same transaction as the subscription.
+func (s *Service) Subscribe(ref string, userID uint, req *models.SubscriptionRequest) (*models.SubscriptionResponse, error) {
+	var subscription models.Subscription
+
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		channel, err := findChannel(tx, ref)
+		if err != nil {
+			return err
+		}
+		if channel.UserID == userID {
+			return apperrors.New(apperrors.ErrCodeInvalidOperation, "Cannot subscribe", "You cannot subscribe to your own channel")
+		}
+		if !channel.IsActive {
+			return apperrors.New(apperrors.ErrCodeInvalidOperation, "Cannot subscribe", "The channel is not active")
+		}
+
+		subscription = models.Subscription{
+			UserID:        userID,
+			ChannelID:     channel.ID,
+			NotifyUploads: true,
+			NotifyLive:    true,
+		}
+		applySubscriptionRequest(&subscription, req)
+
+		// The unique index makes concurrent subscribes insert only once
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&subscription)
+		if result.Error != nil {
+			return apperrors.Wrap(result.Error, apperrors.ErrCodeDatabaseError, "Failed to subscribe", result.Error.Error())
+		}
+		if result.RowsAffected == 1 {
+			return adjustSubscriberCount(tx, channel.ID, 1)
+		}
+
+		// Already subscribed
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND channel_id = ?", userID, channel.ID).
+			First(&subscription).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to subscribe", err.Error())
+		}
+		return updatePreferences(tx, &subscription, req)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toSubscriptionResponse(&subscription), nil
+}
+
+// Unsubscribe removes the subscription of the user to the channel.
+// Unsubscribing without a subscription is not an error.
+func (s *Service) Unsubscribe(ref string, userID uint) error {
+	return s.WithTransaction(func(tx *gorm.DB) error {
+		channel, err := findChannel(tx, ref)
+		if err != nil {
+			return err
+		}
+
+		result := tx.Where("user_id = ? AND channel_id = ?", userID, channel.ID).Delete(&models.Subscription{})
+		if result.Error != nil {
+			return apperrors.Wrap(result.Error, apperrors.ErrCodeDatabaseError, "Failed to unsubscribe", result.Error.Error())
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		return adjustSubscriberCount(tx, channel.ID, -1)
+	})
+}
+
+// GetSubscription returns the subscription of the user to the channel
+func (s *Service) GetSubscription(ref string, userID uint) (*models.SubscriptionResponse, error) {
+	db := s.GetDB()
+
+	channel, err := findChannel(db, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription, err := findSubscription(db, userID, channel.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toSubscriptionResponse(subscription), nil
+}
+
+// UpdateSubscription changes the notification preferences of an existing
+// subscription
+func (s *Service) UpdateSubscription(ref string, userID uint, req *models.SubscriptionRequest) (*models.SubscriptionResponse, error) {
+	var subscription *models.Subscription
+
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		channel, err := findChannel(tx, ref)
+		if err != nil {
+			return err
+		}
+
+		subscription, err = findSubscription(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID, channel.ID)
+		if err != nil {
+			return err
+		}
+		return updatePreferences(tx, subscription, req)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return toSubscriptionResponse(subscription), nil
+}
+
+// ListSubscriptions returns one page of the channels the user is subscribed
+// to, most recent first
+func (s *Service) ListSubscriptions(userID uint, opts *models.ListOptions) (*models.Page, error) {
+	query := s.GetDB().Model(&models.Subscription{}).Preload("Channel").Where("user_id = ?", userID)
+
+	page, subscriptions, err := services.Paginate(query, opts, opts.Sort, func(subscription *models.Subscription) (interface{}, uint) {
+		return subscription.CreatedAt, subscription.ID
+	})
+	if errors.Is(err, services.ErrInvalidCursor) {
+		return nil, apperrors.New(apperrors.ErrCodeInvalidInput, "Invalid cursor", "The cursor is malformed or does not match the sort order")
+	}
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to list subscriptions", err.Error())
+	}
+
+	items := make([]*models.SubscriptionResponse, 0, len(subscriptions))
+	for i := range subscriptions {
+		item := toSubscriptionResponse(&subscriptions[i])
+		item.Channel = toChannelResponse(&subscriptions[i].Channel)
+		items = append(items, item)
+	}
+	page.Items = items
+	return page, nil
+}
+
+// ListSubscribers returns one page of the subscribers of the channel, most
+// recent first. Members with analytics access may list them.
+func (s *Service) ListSubscribers(ref string, actorID uint, opts *models.ListOptions) (*models.Page, error) {
+	db := s.GetDB()
+
+	channel, err := findChannel(db, ref)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := services.CheckChannelPermission(db, channel, actorID, models.ChannelPermissionAnalyticsRead); err != nil {
+		return nil, err
+	}
+
+	query := db.Model(&models.Subscription{}).Preload("User").Where("channel_id = ?", channel.ID)
+
+	page, subscriptions, err := services.Paginate(query, opts, opts.Sort, func(subscription *models.Subscription) (interface{}, uint) {
+		return subscription.CreatedAt, subscription.ID
+	})
+	if errors.Is(err, services.ErrInvalidCursor) {
+		return nil, apperrors.New(apperrors.ErrCodeInvalidInput, "Invalid cursor", "The cursor is malformed or does not match the sort order")
+	}
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to list subscribers", err.Error())
+	}
+
+	items := make([]*models.SubscriberResponse, 0, len(subscriptions))
+	for _, subscription := range subscriptions {
+		items = append(items, &models.SubscriberResponse{
+			UserID:       subscription.UserID,
+			Username:     subscription.User.Username,
+			Avatar:       subscription.User.Avatar,
+			SubscribedAt: subscription.CreatedAt,
+		})
+	}
+	page.Items = items
+	return page, nil
+}
+
+func findSubscription(db *gorm.DB, userID, channelID uint) (*models.Subscription, error) {
+	var subscription models.Subscription
+	if err := db.Where("user_id = ? AND channel_id = ?", userID, channelID).First(&subscription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.New(apperrors.ErrCodeRecordNotFound, "Subscription not found", "You are not subscribed to this channel")
+		}
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to load subscription", err.Error())
+	}
+	return &subscription, nil
+}
+
+// adjustSubscriberCount adds delta to the subscriber count of the channel,
+// never going below zero. The update is relative, so concurrent changes to
+// other subscriptions of the channel are not lost. CASE is used instead of
+// GREATEST, which SQLite does not have.
+func adjustSubscriberCount(tx *gorm.DB, channelID uint, delta int) error {
+	if err := tx.Model(&models.Channel{}).Where("id = ?", channelID).
+		UpdateColumn("subscriber_count", gorm.Expr("CASE WHEN subscriber_count + ? > 0 THEN subscriber_count + ? ELSE 0 END", delta, delta)).Error; err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to update subscriber count", err.Error())
+	}
+	return nil
+}
+
+// updatePreferences saves the notification flags given in req
+func updatePreferences(tx *gorm.DB, subscription *models.Subscription, req *models.SubscriptionRequest) error {
+	if req == nil || (req.NotifyUploads == nil && req.NotifyLive == nil) {
+		return nil
+	}
+
+	applySubscriptionRequest(subscription, req)
+	if err := tx.Model(subscription).Select("notify_uploads", "notify_live", "updated_at").Updates(subscription).Error; err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to update subscription", err.Error())
+	}
+	return nil
+}
+
+func applySubscriptionRequest(subscription *models.Subscription, req *models.SubscriptionRequest) {
+	if req == nil {
+		return
+	}
+	if req.NotifyUploads != nil {
+		subscription.NotifyUploads = *req.NotifyUploads
+	}
+	if req.NotifyLive != nil {
+		subscription.NotifyLive = *req.NotifyLive
+	}
+}
+
+func toSubscriptionResponse(subscription *models.Subscription) *models.SubscriptionResponse {
+	return &models.SubscriptionResponse{
+		ChannelID:     subscription.ChannelID,
+		NotifyUploads: subscription.NotifyUploads,
+		NotifyLive:    subscription.NotifyLive,
+		CreatedAt:     subscription.CreatedAt,
+	}
+}
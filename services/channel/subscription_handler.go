@@ -0,0 +1,216 @@
+package channel
+
+import (
+	"kube/pkg/errors"
+	"kube/pkg/handlers"
+	"kube/pkg/models"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// subscriptionListSpec is used for both subscription and subscriber
+// listings, which are ordered by subscription time
+var subscriptionListSpec = handlers.ListSpec{
+	SortFields:  []string{"created_at"},
+	DefaultDesc: true,
+}
+
+// Subscribe godoc
+// @Summary Subscribe to a channel
+// @Description Subscribe the authenticated user to a channel. Subscribing again is not an error and only applies the given notification preferences. The body is optional; new subscriptions notify about uploads and live streams by default.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param channel path string true "Channel ID or handle"
+// @Param preferences body models.SubscriptionRequest false "Notification preferences"
+// @Success 200 {object} map[string]interface{} "Subscribed successfully"
+// @Failure 400 {object} map[string]interface{} "Own or inactive channel"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 404 {object} map[string]interface{} "Channel not found"
+// @Router /api/v1/channels/{channel}/subscription [put]
+func (h *Handler) Subscribe(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	var req models.SubscriptionRequest
+	if len(c.Request.Body()) > 0 {
+		if err := c.BindJSON(&req); err != nil {
+			h.SendValidationError(c, "Invalid request data format")
+			return
+		}
+	}
+
+	subscription, err := h.service.Subscribe(c.Param("channel"), userID, &req)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, subscription, "Subscribed successfully")
+}
+
+// Unsubscribe godoc
+// @Summary Unsubscribe from a channel
+// @Description Remove the subscription of the authenticated user. Unsubscribing without a subscription is not an error.
+// @Tags subscriptions
+// @Produce json
+// @Security BearerAuth
+// @Param channel path string true "Channel ID or handle"
+// @Success 200 {object} map[string]interface{} "Unsubscribed successfully"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 404 {object} map[string]interface{} "Channel not found"
+// @Router /api/v1/channels/{channel}/subscription [delete]
+func (h *Handler) Unsubscribe(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	if err := h.service.Unsubscribe(c.Param("channel"), userID); err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, nil, "Unsubscribed successfully")
+}
+
+// GetSubscription godoc
+// @Summary Get subscription
+// @Description Return the subscription of the authenticated user to a channel, or 404 when not subscribed
+// @Tags subscriptions
+// @Produce json
+// @Security BearerAuth
+// @Param channel path string true "Channel ID or handle"
+// @Success 200 {object} map[string]interface{} "Subscription retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 404 {object} map[string]interface{} "Channel not found or not subscribed"
+// @Router /api/v1/channels/{channel}/subscription [get]
+func (h *Handler) GetSubscription(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	subscription, err := h.service.GetSubscription(c.Param("channel"), userID)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, subscription, "Subscription retrieved successfully")
+}
+
+// UpdateSubscription godoc
+// @Summary Update notification preferences
+// @Description Change the notification preferences of a subscription. Omitted flags are left unchanged.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param channel path string true "Channel ID or handle"
+// @Param preferences body models.SubscriptionRequest true "Notification preferences"
+// @Success 200 {object} map[string]interface{} "Subscription updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request data"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 404 {object} map[string]interface{} "Channel not found or not subscribed"
+// @Router /api/v1/channels/{channel}/subscription [patch]
+func (h *Handler) UpdateSubscription(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	var req models.SubscriptionRequest
+	if err := c.BindJSON(&req); err != nil {
+		h.SendValidationError(c, "Invalid request data format")
+		return
+	}
+
+	subscription, err := h.service.UpdateSubscription(c.Param("channel"), userID, &req)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, subscription, "Subscription updated successfully")
+}
+
+// ListSubscriptions godoc
+// @Summary List my subscriptions
+// @Description List the channels the authenticated user is subscribed to, most recent first, with cursor pagination
+// @Tags subscriptions
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Page size, 1-100" default(20)
+// @Param cursor query string false "Cursor from the previous page"
+// @Param sort query string false "created_at; prefix with - for descending" default(-created_at)
+// @Success 200 {object} map[string]interface{} "Subscriptions retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid query parameters or cursor"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Router /api/v1/subscriptions [get]
+func (h *Handler) ListSubscriptions(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	opts, err := h.ParseListOptions(c, subscriptionListSpec)
+	if err != nil {
+		h.SendValidationError(c, err.Error())
+		return
+	}
+
+	page, err := h.service.ListSubscriptions(userID, opts)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, page, "Subscriptions retrieved successfully")
+}
+
+// ListSubscribers godoc
+// @Summary List channel subscribers
+// @Description List the subscribers of a channel, most recent first, with cursor pagination. Requires the analytics:read channel permission (owner, manager or viewer-analytics).
+// @Tags subscriptions
+// @Produce json
+// @Security BearerAuth
+// @Param channel path string true "Channel ID or handle"
+// @Param limit query int false "Page size, 1-100" default(20)
+// @Param cursor query string false "Cursor from the previous page"
+// @Param sort query string false "created_at; prefix with - for descending" default(-created_at)
+// @Success 200 {object} map[string]interface{} "Subscribers retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid query parameters or cursor"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Channel role does not allow listing subscribers"
+// @Failure 404 {object} map[string]interface{} "Channel not found"
+// @Router /api/v1/channels/{channel}/subscribers [get]
+func (h *Handler) ListSubscribers(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	opts, err := h.ParseListOptions(c, subscriptionListSpec)
+	if err != nil {
+		h.SendValidationError(c, err.Error())
+		return
+	}
+
+	page, err := h.service.ListSubscribers(c.Param("channel"), userID, opts)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, page, "Subscribers retrieved successfully")
+}
@@ -0,0 +1,185 @@
+package channel
+
+import (
+	"sync"
+	"testing"
+
+	"kube/internal/testutil"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+)
+
+func createTestChannel(t *testing.T, s *Service, ownerID uint, handle string) *models.ChannelResponse {
+	t.Helper()
+	channel, err := s.CreateChannel(ownerID, &models.ChannelCreateRequest{Handle: handle, Name: handle})
+	if err != nil {
+		t.Fatalf("CreateChannel: %v", err)
+	}
+	return channel
+}
+
+func addTestMember(t *testing.T, s *Service, channelID, userID uint, role string) {
+	t.Helper()
+	if err := s.GetDB().Create(&models.ChannelMember{ChannelID: channelID, UserID: userID, Role: role}).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func subscriberCount(t *testing.T, s *Service, handle string) int64 {
+	t.Helper()
+	channel, err := s.GetChannel(handle)
+	if err != nil {
+		t.Fatalf("GetChannel: %v", err)
+	}
+	return channel.SubscriberCount
+}
+
+func TestSubscribe(t *testing.T) {
+	s := newTestService(t)
+	ownerID := createTestUser(t, s, "owner")
+	janeID := createTestUser(t, s, "jane")
+	createTestChannel(t, s, ownerID, "news")
+
+	subscription, err := s.Subscribe("news", janeID, nil)
+	if err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	if !subscription.NotifyUploads || !subscription.NotifyLive {
+		t.Errorf("new subscription does not notify by default: %+v", subscription)
+	}
+
+	// Subscribing again only applies the preferences
+	off := false
+	subscription, err = s.Subscribe("news", janeID, &models.SubscriptionRequest{NotifyLive: &off})
+	if err != nil {
+		t.Fatalf("Subscribe again: %v", err)
+	}
+	if !subscription.NotifyUploads || subscription.NotifyLive {
+		t.Errorf("preferences not applied: %+v", subscription)
+	}
+	if n := subscriberCount(t, s, "news"); n != 1 {
+		t.Errorf("subscriber count %d after subscribing twice, want 1", n)
+	}
+
+	subscription, err = s.UpdateSubscription("news", janeID, &models.SubscriptionRequest{NotifyUploads: &off})
+	if err != nil {
+		t.Fatalf("UpdateSubscription: %v", err)
+	}
+	if subscription.NotifyUploads || subscription.NotifyLive {
+		t.Errorf("preferences not updated: %+v", subscription)
+	}
+
+	_, err = s.Subscribe("news", ownerID, nil)
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeInvalidOperation)
+	_, err = s.Subscribe("missing", janeID, nil)
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeChannelNotFound)
+
+	if err := s.GetDB().Model(&models.Channel{}).Where("handle = ?", "news").Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.Subscribe("news", createTestUser(t, s, "bob"), nil)
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeInvalidOperation)
+}
+
+func TestUnsubscribe(t *testing.T) {
+	s := newTestService(t)
+	ownerID := createTestUser(t, s, "owner")
+	janeID := createTestUser(t, s, "jane")
+	createTestChannel(t, s, ownerID, "news")
+
+	if _, err := s.Subscribe("news", janeID, nil); err != nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := s.Unsubscribe("news", janeID); err != nil {
+			t.Fatalf("Unsubscribe: %v", err)
+		}
+	}
+	if n := subscriberCount(t, s, "news"); n != 0 {
+		t.Errorf("subscriber count %d after unsubscribing twice, want 0", n)
+	}
+
+	_, err := s.GetSubscription("news", janeID)
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeRecordNotFound)
+	_, err = s.UpdateSubscription("news", janeID, &models.SubscriptionRequest{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeRecordNotFound)
+}
+
+func TestSubscriberCountNeverGoesNegative(t *testing.T) {
+	s := newTestService(t)
+	ownerID := createTestUser(t, s, "owner")
+	channel := createTestChannel(t, s, ownerID, "news")
+
+	if err := adjustSubscriberCount(s.GetDB(), channel.ID, -1); err != nil {
+		t.Fatalf("adjustSubscriberCount: %v", err)
+	}
+	if n := subscriberCount(t, s, "news"); n != 0 {
+		t.Errorf("subscriber count %d, want 0", n)
+	}
+}
+
+func TestConcurrentSubscribesCountOnce(t *testing.T) {
+	s := newTestService(t)
+	ownerID := createTestUser(t, s, "owner")
+	janeID := createTestUser(t, s, "jane")
+	createTestChannel(t, s, ownerID, "news")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := s.Subscribe("news", janeID, nil); err != nil {
+				t.Errorf("Subscribe: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := subscriberCount(t, s, "news"); n != 1 {
+		t.Errorf("subscriber count %d, want 1", n)
+	}
+}
+
+func TestListSubscriptionsAndSubscribers(t *testing.T) {
+	s := newTestService(t)
+	ownerID := createTestUser(t, s, "owner")
+	janeID := createTestUser(t, s, "jane")
+	editorID := createTestUser(t, s, "editor")
+	analystID := createTestUser(t, s, "analyst")
+	news := createTestChannel(t, s, ownerID, "news")
+	createTestChannel(t, s, ownerID, "sports")
+	addTestMember(t, s, news.ID, editorID, models.ChannelRoleEditor)
+	addTestMember(t, s, news.ID, analystID, models.ChannelRoleViewerAnalytics)
+
+	for _, handle := range []string{"news", "sports"} {
+		if _, err := s.Subscribe(handle, janeID, nil); err != nil {
+			t.Fatalf("Subscribe: %v", err)
+		}
+	}
+
+	page, err := s.ListSubscriptions(janeID, &models.ListOptions{Limit: 1, Sort: "created_at", Desc: true})
+	if err != nil {
+		t.Fatalf("ListSubscriptions: %v", err)
+	}
+	items := page.Items.([]*models.SubscriptionResponse)
+	if len(items) != 1 || !page.HasMore || items[0].Channel == nil || items[0].Channel.Handle != "sports" {
+		t.Errorf("unexpected first page %+v", page)
+	}
+
+	for _, actorID := range []uint{ownerID, analystID} {
+		page, err := s.ListSubscribers("news", actorID, &models.ListOptions{Limit: 10, Sort: "created_at", Desc: true})
+		if err != nil {
+			t.Fatalf("ListSubscribers as %d: %v", actorID, err)
+		}
+		if subscribers := page.Items.([]*models.SubscriberResponse); len(subscribers) != 1 || subscribers[0].Username != "jane" {
+			t.Errorf("unexpected subscribers %+v", subscribers)
+		}
+	}
+
+	// Subscribers are private to members with analytics access
+	for _, actorID := range []uint{editorID, janeID} {
+		_, err := s.ListSubscribers("news", actorID, &models.ListOptions{Limit: 10, Sort: "created_at", Desc: true})
+		testutil.AssertErrorCode(t, err, apperrors.ErrCodeForbidden)
+	}
+}
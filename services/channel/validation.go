@@ -0,0 +1,127 @@
+package channel
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"unicode"
+
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	minHandleLength      = 3
+	maxHandleLength      = 30
+	maxNameLength        = 100
+	maxDescriptionLength = 5000
+	maxImageURLLength    = 2048
+)
+
+// reservedHandles cannot be claimed because they collide with routes or
+// could be mistaken for official channels
+var reservedHandles = map[string]bool{
+	"new":       true,
+	"me":        true,
+	"admin":     true,
+	"api":       true,
+	"official":  true,
+	"support":   true,
+	"help":      true,
+	"settings":  true,
+	"channels":  true,
+	"videos":    true,
+	"null":      true,
+	"undefined": true,
+}
+
+// normalizeHandle returns the canonical form of a handle: trimmed, without a
+// leading "@", Unicode NFKC normalized and lowercased
+func normalizeHandle(handle string) string {
+	handle = strings.TrimPrefix(strings.TrimSpace(handle), "@")
+	return strings.ToLower(norm.NFKC.String(handle))
+}
+
+// validateHandle checks a normalized handle: 3-30 characters of a-z, 0-9,
+// "_" and "-", starting with a letter so that handles never look like IDs,
+// and not reserved. Handles are used in URLs as they are.
+func validateHandle(handle string) error {
+	if reservedHandles[handle] {
+		return apperrors.New(apperrors.ErrCodeValidationFailed, "Handle not available", "This handle is reserved").
+			AddMetadata("fields", map[string]string{"handle": "is reserved"})
+	}
+
+	if len(handle) < minHandleLength || len(handle) > maxHandleLength {
+		return fieldValidationError(map[string]string{"handle": "must be between 3 and 30 characters long"})
+	}
+
+	for i, r := range handle {
+		if i == 0 && (r < 'a' || r > 'z') {
+			return fieldValidationError(map[string]string{"handle": "must start with a letter"})
+		}
+		if !(r >= 'a' && r <= 'z') && !(r >= '0' && r <= '9') && r != '_' && r != '-' {
+			return fieldValidationError(map[string]string{"handle": "may only contain letters, digits, '_' and '-'"})
+		}
+	}
+	return nil
+}
+
+// validateChannel checks the free-form channel fields
+func validateChannel(name, description, bannerImage, avatar string) error {
+	fieldErrors := make(map[string]string)
+
+	if strings.TrimSpace(name) == "" {
+		fieldErrors["name"] = "is required"
+	} else if len([]rune(name)) > maxNameLength {
+		fieldErrors["name"] = "must be at most 100 characters"
+	} else if strings.IndexFunc(name, unicode.IsControl) >= 0 {
+		fieldErrors["name"] = "must not contain control characters"
+	}
+
+	if len([]rune(description)) > maxDescriptionLength {
+		fieldErrors["description"] = "must be at most 5000 characters"
+	}
+
+	for field, value := range map[string]string{"banner_image": bannerImage, "avatar": avatar} {
+		if value == "" {
+			continue
+		}
+		parsed, err := url.Parse(value)
+		if err != nil || len(value) > maxImageURLLength || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			fieldErrors[field] = "must be an http or https URL of at most 2048 characters"
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return fieldValidationError(fieldErrors)
+	}
+	return nil
+}
+
+// validateMemberRole checks a role granted to a member. Ownership is only
+// handed over by a transfer, never granted.
+func validateMemberRole(role string) error {
+	if _, ok := models.ChannelRoleRanks[role]; !ok || role == models.ChannelRoleOwner {
+		return fieldValidationError(map[string]string{"role": "must be one of manager, editor, viewer-analytics"})
+	}
+	return nil
+}
+
+// normalizeIdentifier returns the canonical form of an email or username, as
+// stored by the user service
+func normalizeIdentifier(value string) string {
+	return strings.ToLower(norm.NFKC.String(strings.TrimSpace(value)))
+}
+
+func fieldValidationError(fieldErrors map[string]string) error {
+	names := make([]string, 0, len(fieldErrors))
+	for name := range fieldErrors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return apperrors.New(apperrors.ErrCodeValidationFailed, "Validation failed", "Invalid fields: "+strings.Join(names, ", ")).
+		AddMetadata("fields", fieldErrors)
+}
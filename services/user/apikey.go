@@ -0,0 +1,362 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"kube/internal/middleware"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// API keys look like "kube_<16 hex chars>_<secret>". The part before the
+// second underscore is the stored prefix used to look the key up; it has 64
+// random bits so that prefixes do not collide under their unique index.
+const (
+	apiKeyPrefix          = "kube_"
+	apiKeyIDBytes         = 8
+	apiKeySecretBytes     = 32
+	maxAPIKeysPerUser     = 25
+	maxAPIKeyNameLength   = 100
+	maxAPIKeyLifetimeDays = 365
+	apiKeyUsageInterval   = time.Minute // granularity of last-used tracking
+)
+
+// CreateAPIKey creates an API key for the user. The returned key is not
+// stored and cannot be retrieved again.
+func (s *Service) CreateAPIKey(userID uint, req *models.APIKeyCreateRequest) (*models.APIKeyCreatedResponse, error) {
+	name := strings.TrimSpace(req.Name)
+	if err := validateAPIKeyName(name); err != nil {
+		return nil, err
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > maxAPIKeyLifetimeDays {
+		return nil, apperrors.New(apperrors.ErrCodeValidationFailed, "Invalid expiry", "expires_in_days must be between 0 and 365")
+	}
+
+	var created *models.APIKeyCreatedResponse
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, userID).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeUserNotFound, "User not found", "User does not exist")
+		}
+
+		var count int64
+		if err := tx.Model(&models.APIKey{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to count API keys", err.Error())
+		}
+		if count >= maxAPIKeysPerUser {
+			return apperrors.New(apperrors.ErrCodeInvalidOperation, "Too many API keys", "Delete an unused API key before creating a new one")
+		}
+
+		scopes, err := validateAPIKeyScopes(tx, &user, req.Scopes)
+		if err != nil {
+			return err
+		}
+
+		key, prefix, err := generateAPIKey()
+		if err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeInternalError, "Failed to generate API key", err.Error())
+		}
+
+		record := &models.APIKey{
+			UserID:  userID,
+			Name:    name,
+			Prefix:  prefix,
+			KeyHash: hashToken(key),
+			Scopes:  scopes,
+		}
+		if req.ExpiresInDays > 0 {
+			expiresAt := time.Now().AddDate(0, 0, req.ExpiresInDays)
+			record.ExpiresAt = &expiresAt
+		}
+
+		if err := tx.Create(record).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to create API key", err.Error())
+		}
+
+		created = &models.APIKeyCreatedResponse{APIKeyResponse: toAPIKeyResponse(record), Key: key}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+func (s *Service) ListAPIKeys(userID uint) ([]models.APIKeyResponse, error) {
+	var keys []models.APIKey
+	if err := s.GetDB().Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to list API keys", err.Error())
+	}
+
+	responses := make([]models.APIKeyResponse, 0, len(keys))
+	for i := range keys {
+		responses = append(responses, toAPIKeyResponse(&keys[i]))
+	}
+	return responses, nil
+}
+
+func (s *Service) GetAPIKey(userID, keyID uint) (*models.APIKeyResponse, error) {
+	key, err := findAPIKey(s.GetDB(), userID, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := toAPIKeyResponse(key)
+	return &response, nil
+}
+
+// UpdateAPIKey renames an API key or replaces its scopes
+func (s *Service) UpdateAPIKey(userID, keyID uint, req *models.APIKeyUpdateRequest) (*models.APIKeyResponse, error) {
+	var key *models.APIKey
+
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		var err error
+		key, err = findAPIKey(tx, userID, keyID)
+		if err != nil {
+			return err
+		}
+
+		if req.Name != nil {
+			name := strings.TrimSpace(*req.Name)
+			if err := validateAPIKeyName(name); err != nil {
+				return err
+			}
+			key.Name = name
+		}
+
+		if req.Scopes != nil {
+			var user models.User
+			if err := tx.First(&user, userID).Error; err != nil {
+				return apperrors.Wrap(err, apperrors.ErrCodeUserNotFound, "User not found", "User does not exist")
+			}
+
+			key.Scopes, err = validateAPIKeyScopes(tx, &user, req.Scopes)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Save(key).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to update API key", err.Error())
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	response := toAPIKeyResponse(key)
+	return &response, nil
+}
+
+// DeleteAPIKey revokes an API key immediately
+func (s *Service) DeleteAPIKey(userID, keyID uint) error {
+	result := s.GetDB().Where("id = ? AND user_id = ?", keyID, userID).Delete(&models.APIKey{})
+	if result.Error != nil {
+		return apperrors.Wrap(result.Error, apperrors.ErrCodeDatabaseError, "Failed to delete API key", result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.New(apperrors.ErrCodeRecordNotFound, "API key not found", "API key does not exist")
+	}
+	return nil
+}
+
+// ValidateAPIKey implements middleware.APIKeyValidator. The key acts with the
+// intersection of its scopes and the permissions its owner currently holds,
+// so revoking a role from the owner also narrows their keys. Roles and the
+// admin flag are never delegated to keys.
+func (s *Service) ValidateAPIKey(ctx context.Context, key, clientIP string) (*middleware.Claims, bool, error) {
+	prefix, ok := parseAPIKeyPrefix(key)
+	if !ok {
+		return nil, false, nil
+	}
+
+	db := s.GetDB().WithContext(ctx)
+
+	var record models.APIKey
+	if err := db.Where("prefix = ?", prefix).First(&record).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(record.KeyHash), []byte(hashToken(key))) != 1 {
+		return nil, false, nil
+	}
+	if record.ExpiresAt != nil && time.Now().After(*record.ExpiresAt) {
+		return nil, false, nil
+	}
+
+	var user models.User
+	if err := db.First(&user, record.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	if !user.IsActive {
+		return nil, false, nil
+	}
+
+	permissions := record.Scopes
+	if !user.IsAdmin {
+		_, held, err := loadAuthorization(db, user.ID)
+		if err != nil {
+			return nil, false, err
+		}
+		permissions = intersectStrings(record.Scopes, held)
+	}
+
+	if record.LastUsedAt == nil || time.Since(*record.LastUsedAt) > apiKeyUsageInterval {
+		if err := db.Model(&record).UpdateColumns(map[string]interface{}{
+			"last_used_at": time.Now(),
+			"last_used_ip": clientIP,
+		}).Error; err != nil {
+			return nil, false, err
+		}
+	}
+
+	return &middleware.Claims{
+		UserID:        user.ID,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		Permissions:   permissions,
+	}, true, nil
+}
+
+// validateAPIKeyScopes returns the sorted, de-duplicated scopes if the user
+// holds every one of them. Admins may grant any existing permission.
+func validateAPIKeyScopes(tx *gorm.DB, user *models.User, scopes []string) ([]string, error) {
+	if len(scopes) == 0 {
+		return nil, apperrors.New(apperrors.ErrCodeValidationFailed, "Invalid scopes", "At least one scope is required")
+	}
+
+	var allowed []string
+	if user.IsAdmin {
+		if err := tx.Model(&models.Permission{}).Pluck("name", &allowed).Error; err != nil {
+			return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to load permissions", err.Error())
+		}
+	} else {
+		var err error
+		_, allowed, err = loadAuthorization(tx, user.ID)
+		if err != nil {
+			return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to load user roles", err.Error())
+		}
+	}
+
+	unique := intersectStrings(scopes, scopes)
+	for _, scope := range unique {
+		if !containsString(allowed, scope) {
+			return nil, apperrors.New(apperrors.ErrCodeValidationFailed, "Invalid scope", "Scope "+scope+" is not a permission you hold")
+		}
+	}
+	return unique, nil
+}
+
+func validateAPIKeyName(name string) error {
+	if name == "" || len(name) > maxAPIKeyNameLength {
+		return apperrors.New(apperrors.ErrCodeValidationFailed, "Invalid name", "Name must be between 1 and 100 characters")
+	}
+	return nil
+}
+
+func findAPIKey(tx *gorm.DB, userID, keyID uint) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := tx.Where("id = ? AND user_id = ?", keyID, userID).First(&key).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeRecordNotFound, "API key not found", "API key does not exist")
+	}
+	return &key, nil
+}
+
+// generateAPIKey returns a new key and its lookup prefix
+func generateAPIKey() (string, string, error) {
+	id := make([]byte, apiKeyIDBytes)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", err
+	}
+
+	secret, err := generateOpaqueToken(apiKeySecretBytes)
+	if err != nil {
+		return "", "", err
+	}
+
+	prefix := apiKeyPrefix + hex.EncodeToString(id)
+	return prefix + "_" + secret, prefix, nil
+}
+
+func parseAPIKeyPrefix(key string) (string, bool) {
+	prefixLength := len(apiKeyPrefix) + 2*apiKeyIDBytes
+	if !strings.HasPrefix(key, apiKeyPrefix) || len(key) <= prefixLength+1 || key[prefixLength] != '_' {
+		return "", false
+	}
+	return key[:prefixLength], true
+}
+
+func toAPIKeyResponse(key *models.APIKey) models.APIKeyResponse {
+	return models.APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+		LastUsedIP: key.LastUsedIP,
+		CreatedAt:  key.CreatedAt,
+	}
+}
+
+// intersectStrings returns the sorted, de-duplicated values of a that are
+// also in b
+func intersectStrings(a, b []string) []string {
+	result := make([]string, 0, len(a))
+	for _, value := range a {
+		if containsString(b, value) && !containsString(result, value) {
+			result = append(result, value)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// IntrospectAPIKey validates a key on behalf of another service. The caller
+// authenticates with the shared introspection secret; without a configured
+// secret the endpoint does not exist.
+func (s *Service) IntrospectAPIKey(ctx context.Context, secret string, req *middleware.APIKeyIntrospectionRequest) (*middleware.APIKeyIntrospectionResponse, error) {
+	expected := s.authCfg.APIKeyIntrospectionSecret
+	if expected == "" {
+		return nil, apperrors.New(apperrors.ErrCodeRecordNotFound, "Not found", "API key introspection is disabled")
+	}
+	if subtle.ConstantTimeCompare([]byte(secret), []byte(expected)) != 1 {
+		return nil, apperrors.New(apperrors.ErrCodeUnauthorized, "Unauthorized", "Invalid introspection secret")
+	}
+
+	claims, ok, err := s.ValidateAPIKey(ctx, req.Key, req.ClientIP)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to validate API key", err.Error())
+	}
+	if !ok {
+		return &middleware.APIKeyIntrospectionResponse{Active: false}, nil
+	}
+	return &middleware.APIKeyIntrospectionResponse{Active: true, Claims: claims}, nil
+}
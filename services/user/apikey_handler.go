@@ -0,0 +1,210 @@
+package user
+
+import (
+	"context"
+
+	"kube/internal/middleware"
+	"kube/pkg/errors"
+	"kube/pkg/models"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// ListAPIKeys godoc
+// @Summary List API keys
+// @Description List the API keys of the authenticated user. Secrets are never returned.
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "API keys retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Called with an API key"
+// @Router /api/v1/users/me/api-keys [get]
+func (h *Handler) ListAPIKeys(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	keys, err := h.service.ListAPIKeys(userID)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, keys, "API keys retrieved successfully")
+}
+
+// CreateAPIKey godoc
+// @Summary Create API key
+// @Description Create an API key for machine-to-machine access. Send it as "Authorization: ApiKey <key>". The key is only shown in this response.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.APIKeyCreateRequest true "Key name, scopes and expiry"
+// @Success 201 {object} map[string]interface{} "API key created successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request data, scope or too many keys"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Called with an API key"
+// @Router /api/v1/users/me/api-keys [post]
+func (h *Handler) CreateAPIKey(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	var req models.APIKeyCreateRequest
+	if err := c.BindJSON(&req); err != nil {
+		h.SendValidationError(c, "Invalid request data format")
+		return
+	}
+
+	key, err := h.service.CreateAPIKey(userID, &req)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 201, key, "API key created successfully")
+}
+
+// GetAPIKey godoc
+// @Summary Get API key
+// @Description Get an API key of the authenticated user, including when it was last used
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param keyId path int true "API key ID"
+// @Success 200 {object} map[string]interface{} "API key retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid API key ID"
+// @Failure 403 {object} map[string]interface{} "Called with an API key"
+// @Failure 404 {object} map[string]interface{} "API key not found"
+// @Router /api/v1/users/me/api-keys/{keyId} [get]
+func (h *Handler) GetAPIKey(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	keyID, err := h.GetParamUint(c, "keyId")
+	if err != nil {
+		h.SendValidationError(c, "Invalid API key ID format")
+		return
+	}
+
+	key, err := h.service.GetAPIKey(userID, keyID)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, key, "API key retrieved successfully")
+}
+
+// UpdateAPIKey godoc
+// @Summary Update API key
+// @Description Rename an API key or replace its scopes
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param keyId path int true "API key ID"
+// @Param request body models.APIKeyUpdateRequest true "New name and/or scopes"
+// @Success 200 {object} map[string]interface{} "API key updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request data or scope"
+// @Failure 403 {object} map[string]interface{} "Called with an API key"
+// @Failure 404 {object} map[string]interface{} "API key not found"
+// @Router /api/v1/users/me/api-keys/{keyId} [put]
+func (h *Handler) UpdateAPIKey(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	keyID, err := h.GetParamUint(c, "keyId")
+	if err != nil {
+		h.SendValidationError(c, "Invalid API key ID format")
+		return
+	}
+
+	var req models.APIKeyUpdateRequest
+	if err := c.BindJSON(&req); err != nil {
+		h.SendValidationError(c, "Invalid request data format")
+		return
+	}
+
+	key, err := h.service.UpdateAPIKey(userID, keyID, &req)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, key, "API key updated successfully")
+}
+
+// DeleteAPIKey godoc
+// @Summary Delete API key
+// @Description Revoke an API key. Requests using it are rejected immediately.
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param keyId path int true "API key ID"
+// @Success 200 {object} map[string]interface{} "API key deleted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid API key ID"
+// @Failure 403 {object} map[string]interface{} "Called with an API key"
+// @Failure 404 {object} map[string]interface{} "API key not found"
+// @Router /api/v1/users/me/api-keys/{keyId} [delete]
+func (h *Handler) DeleteAPIKey(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	keyID, err := h.GetParamUint(c, "keyId")
+	if err != nil {
+		h.SendValidationError(c, "Invalid API key ID format")
+		return
+	}
+
+	if err := h.service.DeleteAPIKey(userID, keyID); err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, nil, "API key deleted successfully")
+}
+
+// IntrospectAPIKey godoc
+// @Summary Introspect API key
+// @Description Internal endpoint used by the other services to validate API keys. Authenticated with the shared API_KEY_INTROSPECTION_SECRET in the X-Introspection-Secret header, and disabled when no secret is configured. Returns the claims the key acts with, or active false for unknown, expired and revoked keys.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Param X-Introspection-Secret header string true "Shared introspection secret"
+// @Param request body middleware.APIKeyIntrospectionRequest true "API key and the client IP it was presented from"
+// @Success 200 {object} map[string]interface{} "Introspection result with active and claims"
+// @Failure 400 {object} map[string]interface{} "Invalid request data"
+// @Failure 401 {object} map[string]interface{} "Invalid introspection secret"
+// @Failure 404 {object} map[string]interface{} "Introspection disabled"
+// @Router /api/v1/internal/api-keys/introspect [post]
+func (h *Handler) IntrospectAPIKey(c *app.RequestContext) {
+	var req middleware.APIKeyIntrospectionRequest
+	if err := c.BindJSON(&req); err != nil {
+		h.SendValidationError(c, "Invalid request data format")
+		return
+	}
+
+	result, err := h.service.IntrospectAPIKey(context.Background(), string(c.GetHeader(middleware.APIKeyIntrospectionHeader)), &req)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	c.JSON(200, result)
+}
@@ -0,0 +1,80 @@
+package user
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"kube/internal/config"
+	"kube/internal/middleware"
+	"kube/internal/testutil"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, err := generateAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(key, prefix+"_") {
+		t.Fatalf("key %q does not start with its prefix %q", key, prefix)
+	}
+	id, err := hex.DecodeString(strings.TrimPrefix(prefix, apiKeyPrefix))
+	if err != nil || len(id) < 8 {
+		t.Errorf("prefix %q should carry at least 8 random bytes", prefix)
+	}
+
+	parsed, ok := parseAPIKeyPrefix(key)
+	if !ok || parsed != prefix {
+		t.Errorf("parseAPIKeyPrefix = (%q, %v), want (%q, true)", parsed, ok, prefix)
+	}
+}
+
+func TestParseAPIKeyPrefixRejectsMalformedKeys(t *testing.T) {
+	for _, key := range []string{
+		"",
+		"kube_",
+		"kube_0123456789abcdef",
+		"kube_0123456789abcdef_",
+		"kube_01234567_secret",
+		"other_0123456789abcdef_secret",
+	} {
+		if prefix, ok := parseAPIKeyPrefix(key); ok {
+			t.Errorf("parseAPIKeyPrefix(%q) = %q, want rejection", key, prefix)
+		}
+	}
+}
+
+func TestIntrospectAPIKey(t *testing.T) {
+	s := newTestService(t, func(cfg *config.Config) { cfg.Auth.APIKeyIntrospectionSecret = "shared-secret" })
+	user := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	key := createAPIKey(t, s, user.ID)
+	ctx := context.Background()
+
+	_, err := s.IntrospectAPIKey(ctx, "wrong-secret", &middleware.APIKeyIntrospectionRequest{Key: key})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeUnauthorized)
+
+	result, err := s.IntrospectAPIKey(ctx, "shared-secret", &middleware.APIKeyIntrospectionRequest{Key: key, ClientIP: "10.0.0.1"})
+	if err != nil {
+		t.Fatalf("IntrospectAPIKey: %v", err)
+	}
+	if !result.Active || result.Claims.UserID != user.ID || len(result.Claims.Permissions) != 1 || result.Claims.Permissions[0] != models.PermissionVideosUpload {
+		t.Errorf("unexpected introspection result %+v", result)
+	}
+
+	result, err = s.IntrospectAPIKey(ctx, "shared-secret", &middleware.APIKeyIntrospectionRequest{Key: key + "x"})
+	if err != nil || result.Active || result.Claims != nil {
+		t.Errorf("unknown key introspected as (%+v, %v), want inactive", result, err)
+	}
+}
+
+func TestIntrospectAPIKeyDisabledWithoutSecret(t *testing.T) {
+	s := newTestService(t)
+	user := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+
+	_, err := s.IntrospectAPIKey(context.Background(), "", &middleware.APIKeyIntrospectionRequest{Key: createAPIKey(t, s, user.ID)})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeRecordNotFound)
+}
@@ -0,0 +1,132 @@
+package user
+
+import (
+	"errors"
+	"time"
+
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+	"kube/pkg/services"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+)
+
+// newAuditEvent describes an event caused by the client behind meta. A zero
+// targetID means the event does not concern a known account.
+func newAuditEvent(action string, meta RequestMeta, targetID uint, changes map[string]models.FieldChange) *models.AuditEvent {
+	return &models.AuditEvent{
+		Action:    action,
+		ActorID:   optionalID(meta.ActorID),
+		TargetID:  optionalID(targetID),
+		IP:        meta.IP,
+		RequestID: meta.RequestID,
+		Changes:   changes,
+		CreatedAt: time.Now(),
+	}
+}
+
+// appendAudit stores the event as part of tx, so that it is only recorded
+// if the change it describes is committed
+func appendAudit(tx *gorm.DB, event *models.AuditEvent) error {
+	if err := tx.Create(event).Error; err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to record audit event", err.Error())
+	}
+	return nil
+}
+
+// logAudit stores an event that has no transaction to join, such as a
+// failed login. Failures are logged and do not fail the request.
+func (s *Service) logAudit(event *models.AuditEvent) {
+	if err := s.GetDB().Create(event).Error; err != nil {
+		hlog.Errorf("Failed to record audit event %s: %v", event.Action, err)
+	}
+}
+
+// diffUser returns the audited fields that differ between two versions of a
+// user. It returns nil when nothing changed.
+func diffUser(before, after *models.User) map[string]models.FieldChange {
+	fields := []struct {
+		name     string
+		from, to interface{}
+	}{
+		{"username", before.Username, after.Username},
+		{"email", before.Email, after.Email},
+		{"first_name", before.FirstName, after.FirstName},
+		{"last_name", before.LastName, after.LastName},
+		{"avatar", before.Avatar, after.Avatar},
+		{"is_active", before.IsActive, after.IsActive},
+	}
+
+	var changes map[string]models.FieldChange
+	for _, field := range fields {
+		if field.from == field.to {
+			continue
+		}
+		if changes == nil {
+			changes = make(map[string]models.FieldChange)
+		}
+		changes[field.name] = models.FieldChange{From: field.from, To: field.to}
+	}
+	return changes
+}
+
+// redactAuditEvents removes the client IPs and field values of the events
+// caused by or concerning the user. It is the only change ever made to
+// stored events and runs when the user's personal data is erased.
+func redactAuditEvents(tx *gorm.DB, userID uint) error {
+	if err := tx.Model(&models.AuditEvent{}).
+		Where("actor_id = ? OR target_id = ?", userID, userID).
+		Updates(map[string]interface{}{"ip": "", "changes": nil}).Error; err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to redact audit events", err.Error())
+	}
+	return nil
+}
+
+// ListAuditEvents returns one page of audit events. The actor_id, target_id
+// and action filters match exactly, the created_* filters bound the time.
+func (s *Service) ListAuditEvents(opts *models.ListOptions) (*models.Page, error) {
+	query := s.GetDB().Model(&models.AuditEvent{})
+
+	if actorID, ok := opts.Filters["actor_id"].(uint); ok {
+		query = query.Where("actor_id = ?", actorID)
+	}
+	if targetID, ok := opts.Filters["target_id"].(uint); ok {
+		query = query.Where("target_id = ?", targetID)
+	}
+	if action, ok := opts.Filters["action"].(string); ok {
+		query = query.Where("action = ?", action)
+	}
+	if after, ok := opts.Filters["created_after"].(time.Time); ok {
+		query = query.Where("created_at >= ?", after)
+	}
+	if before, ok := opts.Filters["created_before"].(time.Time); ok {
+		query = query.Where("created_at < ?", before)
+	}
+
+	page, events, err := services.Paginate(query, opts, opts.Sort, func(event *models.AuditEvent) (interface{}, uint) {
+		if opts.Sort == "id" {
+			return event.ID, event.ID
+		}
+		return event.CreatedAt, event.ID
+	})
+	if errors.Is(err, services.ErrInvalidCursor) {
+		return nil, apperrors.New(apperrors.ErrCodeInvalidInput, "Invalid cursor", "The cursor is malformed or does not match the sort order")
+	}
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to list audit events", err.Error())
+	}
+
+	if events == nil {
+		events = []models.AuditEvent{}
+	}
+	page.Items = events
+	return page, nil
+}
+
+func optionalID(id uint) *uint {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
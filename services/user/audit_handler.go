@@ -0,0 +1,56 @@
+package user
+
+import (
+	"kube/pkg/errors"
+	"kube/pkg/handlers"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// auditListSpec whitelists the sort fields and filters of the audit log
+var auditListSpec = handlers.ListSpec{
+	SortFields:  []string{"created_at", "id"},
+	DefaultDesc: true,
+	Filters: map[string]handlers.FilterType{
+		"actor_id":       handlers.FilterUint,
+		"target_id":      handlers.FilterUint,
+		"action":         handlers.FilterString,
+		"created_after":  handlers.FilterTime,
+		"created_before": handlers.FilterTime,
+	},
+}
+
+// ListAuditEvents godoc
+// @Summary Query audit log
+// @Description List account audit events such as registrations, logins, profile changes and deletions, newest first, with cursor pagination
+// @Tags audit
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Page size, 1-100" default(20)
+// @Param cursor query string false "Cursor from the previous page"
+// @Param sort query string false "created_at or id; prefix with - for descending" default(-created_at)
+// @Param actor_id query int false "User who caused the event"
+// @Param target_id query int false "User the event concerns"
+// @Param action query string false "Event action, e.g. user.login.failure"
+// @Param created_after query string false "Created at or after (RFC 3339)"
+// @Param created_before query string false "Created before (RFC 3339)"
+// @Success 200 {object} map[string]interface{} "Audit events retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid query parameters or cursor"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Missing audit:read permission"
+// @Router /api/v1/audit-events [get]
+func (h *Handler) ListAuditEvents(c *app.RequestContext) {
+	opts, err := h.ParseListOptions(c, auditListSpec)
+	if err != nil {
+		h.SendValidationError(c, err.Error())
+		return
+	}
+
+	page, err := h.service.ListAuditEvents(opts)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, page, "Audit events retrieved successfully")
+}
@@ -0,0 +1,129 @@
+package user
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"kube/internal/testutil"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+)
+
+// auditActions lists the actions of the events matching filters, oldest first
+func auditActions(t *testing.T, s *Service, filters map[string]interface{}) []string {
+	t.Helper()
+	page, err := s.ListAuditEvents(&models.ListOptions{Limit: 100, Sort: "id", Filters: filters})
+	if err != nil {
+		t.Fatalf("ListAuditEvents: %v", err)
+	}
+	var actions []string
+	for _, event := range page.Items.([]models.AuditEvent) {
+		actions = append(actions, event.Action)
+	}
+	return actions
+}
+
+func TestAuditEventsAreRecorded(t *testing.T) {
+	s := newTestService(t)
+	// Registrations are caused by the new user
+	jane, err := s.CreateUser(&models.UserCreateRequest{Username: "jane", Email: "jane@example.com", Password: "correct horse battery"}, RequestMeta{IP: "10.0.0.1", RequestID: "req-1"})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	login(s, "jane", "wrong password", "10.0.0.2")
+	login(s, "nobody@example.com", "wrong password", "10.0.0.3")
+	passwordLogin(t, s, "jane", "correct horse battery")
+
+	owner := RequestMeta{ActorID: jane.ID, IP: "10.0.0.1"}
+	if _, err := s.UpdateUser(jane.ID, &models.UserUpdateRequest{FirstName: "Jane"}, nil, owner); err != nil {
+		t.Fatalf("UpdateUser: %v", err)
+	}
+	if err := s.DeleteUser(jane.ID, owner); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+
+	got := fmt.Sprint(auditActions(t, s, map[string]interface{}{"target_id": jane.ID}))
+	if want := "[user.register user.login.failure user.login.success user.update user.delete]"; got != want {
+		t.Errorf("events of jane = %s, want %s", got, want)
+	}
+
+	page, err := s.ListAuditEvents(&models.ListOptions{Limit: 100, Sort: "id", Filters: map[string]interface{}{"action": models.AuditActionRegister}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	register := page.Items.([]models.AuditEvent)[0]
+	if register.IP != "10.0.0.1" || register.RequestID != "req-1" || register.ActorID == nil || *register.ActorID != jane.ID {
+		t.Errorf("unexpected register event %+v", register)
+	}
+
+	page, err = s.ListAuditEvents(&models.ListOptions{Limit: 100, Sort: "id", Filters: map[string]interface{}{"action": models.AuditActionUpdate}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	update := page.Items.([]models.AuditEvent)[0]
+	if update.ActorID == nil || *update.ActorID != jane.ID || len(update.Changes) != 1 ||
+		update.Changes["first_name"].From != "" || update.Changes["first_name"].To != "Jane" {
+		t.Errorf("unexpected update event %+v", update)
+	}
+
+	// A failed login for an unknown account has no target
+	page, err = s.ListAuditEvents(&models.ListOptions{Limit: 100, Sort: "id", Filters: map[string]interface{}{"action": models.AuditActionLoginFailure}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failures := page.Items.([]models.AuditEvent); len(failures) != 2 || failures[1].TargetID != nil || failures[1].IP != "10.0.0.3" {
+		t.Errorf("unexpected login failures %+v", failures)
+	}
+}
+
+func TestListAuditEventsFilters(t *testing.T) {
+	s := newTestService(t)
+	jane := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	bob := registerUser(t, s, "bob", "bob@example.com", "correct horse battery")
+	if err := s.DeleteUser(bob.ID, RequestMeta{ActorID: jane.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		filters map[string]interface{}
+		want    string
+	}{
+		{"actor", map[string]interface{}{"actor_id": jane.ID}, "[user.register user.delete]"},
+		{"target", map[string]interface{}{"target_id": bob.ID}, "[user.register user.delete]"},
+		{"action", map[string]interface{}{"action": models.AuditActionRegister}, "[user.register user.register]"},
+		{"created after", map[string]interface{}{"created_after": time.Now().Add(time.Minute)}, "[]"},
+		{"created before", map[string]interface{}{"created_before": time.Now().Add(time.Minute)}, "[user.register user.register user.delete]"},
+	} {
+		if got := fmt.Sprint(auditActions(t, s, tt.filters)); got != tt.want {
+			t.Errorf("%s: %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	_, err := s.ListAuditEvents(&models.ListOptions{Limit: 1, Sort: "id", Cursor: "garbage"})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeInvalidInput)
+}
+
+func TestAuditLogRoute(t *testing.T) {
+	s := newTestService(t)
+	router := newTestRouter(s)
+	admin := registerUser(t, s, "alice", "alice@example.com", "correct horse battery")
+	makeAdmin(t, s, admin.ID)
+	adminAuth := bearer(passwordLogin(t, s, "alice", "correct horse battery"))
+
+	for _, tt := range []struct {
+		path string
+		want int
+	}{
+		{"/api/v1/audit-events?action=user.register", 200},
+		{"/api/v1/audit-events?actor_id=abc", 400},
+		{"/api/v1/audit-events?sort=ip", 400},
+		{"/api/v1/audit-events?created_after=yesterday", 400},
+	} {
+		resp := performRequest(router, "GET", tt.path, "", adminAuth)
+		if got := resp.StatusCode(); got != tt.want {
+			t.Errorf("GET %s: status %d, want %d: %s", tt.path, got, tt.want, resp.Body())
+		}
+	}
+}
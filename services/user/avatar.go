@@ -0,0 +1,199 @@
+package user
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif" // register decoders for image.Decode
+	_ "image/jpeg"
+	"image/png"
+	"net/http"
+	"strconv"
+	"strings"
+
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/google/uuid"
+)
+
+// avatarSizes are the edge lengths in pixels of the square thumbnails made
+// of every uploaded avatar. The largest one becomes the avatar URL.
+var avatarSizes = []int{64, 128, 256}
+
+// avatarContentTypes are the sniffed content types accepted as avatars
+var avatarContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// UploadAvatar replaces the avatar of the user with square thumbnails of the
+// uploaded image. The content type is sniffed from the data, the declared
+// one is not trusted. Thumbnails are stored as PNG under a new directory per
+// upload, so their URLs never serve stale cached images. They are written
+// before the profile is updated, which keeps slow storage from holding the
+// row lock; the thumbnails that lose out, the new ones if the update fails
+// and the previous ones otherwise, are deleted afterwards. See updateProfile
+// for expectedVersions.
+func (s *Service) UploadAvatar(userID uint, data []byte, expectedVersions []int, meta RequestMeta) (*models.UserResponse, error) {
+	if len(data) == 0 {
+		return nil, fieldValidationError(map[string]string{"avatar": "is required"})
+	}
+	if len(data) > s.avatarCfg.MaxSize {
+		return nil, apperrors.New(apperrors.ErrCodePayloadTooLarge, "Avatar too large", fmt.Sprintf("The avatar must not be larger than %d bytes", s.avatarCfg.MaxSize))
+	}
+
+	contentType := http.DetectContentType(data)
+	if !avatarContentTypes[contentType] {
+		return nil, apperrors.New(apperrors.ErrCodeUnsupportedMediaType, "Unsupported avatar format", "The avatar must be a JPEG, PNG or GIF image").
+			AddMetadata("content_type", contentType)
+	}
+
+	// Check the dimensions before decoding, so that a small file cannot
+	// claim a huge image and exhaust memory
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeInvalidFormat, "Invalid image", "The avatar could not be decoded")
+	}
+	if config.Width > s.avatarCfg.MaxDimension || config.Height > s.avatarCfg.MaxDimension {
+		return nil, apperrors.New(apperrors.ErrCodeValidationFailed, "Avatar too large", fmt.Sprintf("The avatar must not be larger than %dx%d pixels", s.avatarCfg.MaxDimension, s.avatarCfg.MaxDimension))
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeInvalidFormat, "Invalid image", "The avatar could not be decoded")
+	}
+	square := cropSquare(src)
+
+	thumbnails := make([][]byte, len(avatarSizes))
+	for i, size := range avatarSizes {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, resizeSquare(square, size)); err != nil {
+			return nil, apperrors.Wrap(err, apperrors.ErrCodeInternalError, "Failed to process avatar", err.Error())
+		}
+		thumbnails[i] = buf.Bytes()
+	}
+
+	uploadID := uuid.NewString()
+	urls := make(models.AvatarURLs, len(avatarSizes))
+	for i, size := range avatarSizes {
+		path := avatarPath(userID, uploadID, size)
+		if err := s.storage.UploadFile(path, thumbnails[i]); err != nil {
+			s.deleteAvatarURLs(userID, urls)
+			return nil, apperrors.Wrap(err, apperrors.ErrCodeExternalServiceError, "Failed to store avatar", err.Error())
+		}
+		urls[strconv.Itoa(size)] = s.storage.URL(path)
+	}
+
+	var previous models.AvatarURLs
+	user, err := s.updateProfile(userID, expectedVersions, meta, func(user *models.User) error {
+		previous = user.AvatarURLs
+		user.AvatarURLs = urls
+		user.Avatar = urls[strconv.Itoa(avatarSizes[len(avatarSizes)-1])]
+		return nil
+	})
+	if err != nil {
+		s.deleteAvatarURLs(userID, urls)
+		return nil, err
+	}
+
+	s.deleteAvatarURLs(userID, previous)
+	return user, nil
+}
+
+// setAvatar sets the avatar to an external URL. Thumbnails of a previously
+// uploaded avatar no longer apply; they are dropped and returned, for the
+// caller to delete once the change is committed.
+func setAvatar(user *models.User, avatar string) (dropped models.AvatarURLs) {
+	if avatar != user.Avatar {
+		dropped = user.AvatarURLs
+		user.AvatarURLs = nil
+	}
+	user.Avatar = avatar
+	return dropped
+}
+
+// deleteAvatarFiles removes every stored thumbnail of the user. Failures
+// are only logged.
+func (s *Service) deleteAvatarFiles(userID uint) {
+	if err := s.storage.DeleteDir(avatarDir(userID)); err != nil {
+		hlog.Errorf("Failed to delete avatars of user %d: %v", userID, err)
+	}
+}
+
+// deleteAvatarURLs removes the stored thumbnails behind urls. URLs outside
+// the user's avatar directory, such as external avatars, are left alone.
+// Failures are only logged.
+func (s *Service) deleteAvatarURLs(userID uint, urls models.AvatarURLs) {
+	for _, url := range urls {
+		path, ok := s.storage.Path(url)
+		if !ok || !strings.HasPrefix(path, avatarDir(userID)+"/") {
+			continue
+		}
+		if err := s.storage.DeleteFile(path); err != nil {
+			hlog.Errorf("Failed to delete avatar %s of user %d: %v", path, userID, err)
+		}
+	}
+}
+
+func avatarDir(userID uint) string {
+	return fmt.Sprintf("avatars/%d", userID)
+}
+
+func avatarPath(userID uint, uploadID string, size int) string {
+	return fmt.Sprintf("%s/%s/%d.png", avatarDir(userID), uploadID, size)
+}
+
+// cropSquare returns the largest centered square of src as RGBA
+func cropSquare(src image.Image) *image.RGBA {
+	bounds := src.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	offset := image.Pt(bounds.Min.X+(bounds.Dx()-side)/2, bounds.Min.Y+(bounds.Dy()-side)/2)
+
+	square := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(square, square.Bounds(), src, offset, draw.Src)
+	return square
+}
+
+// resizeSquare scales a square image to size x size pixels. Downscaling
+// averages the source pixels covered by each target pixel, which avoids the
+// aliasing of nearest neighbour sampling; upscaling repeats pixels.
+func resizeSquare(src *image.RGBA, size int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	side := src.Bounds().Dx()
+	if side == 0 {
+		return dst
+	}
+
+	for y := 0; y < size; y++ {
+		y0 := y * side / size
+		y1 := max((y+1)*side/size, y0+1)
+		for x := 0; x < size; x++ {
+			x0 := x * side / size
+			x1 := max((x+1)*side/size, x0+1)
+
+			var r, g, b, a, count uint64
+			for sy := y0; sy < y1; sy++ {
+				i := src.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					r += uint64(src.Pix[i])
+					g += uint64(src.Pix[i+1])
+					b += uint64(src.Pix[i+2])
+					a += uint64(src.Pix[i+3])
+					i += 4
+					count++
+				}
+			}
+
+			j := dst.PixOffset(x, y)
+			dst.Pix[j] = uint8(r / count)
+			dst.Pix[j+1] = uint8(g / count)
+			dst.Pix[j+2] = uint8(b / count)
+			dst.Pix[j+3] = uint8(a / count)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,71 @@
+package user
+
+import (
+	"fmt"
+	"io"
+
+	"kube/pkg/errors"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// UploadAvatar godoc
+// @Summary Upload avatar
+// @Description Upload a JPEG, PNG or GIF image as the profile picture of the authenticated user. The type is detected from the file content. The image is cropped to a centered square and stored as 64, 128 and 256 pixel thumbnails, whose URLs are returned in avatar_urls; avatar is set to the largest one.
+// @Tags users
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param avatar formData file true "Image file"
+// @Param If-Match header string false "ETag from a previous read; the upload fails if the user changed since"
+// @Success 200 {object} map[string]interface{} "Avatar updated successfully"
+// @Failure 400 {object} map[string]interface{} "Missing file, undecodable image or dimensions too large"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Called with an API key"
+// @Failure 412 {object} map[string]interface{} "User modified since the If-Match ETag"
+// @Failure 413 {object} map[string]interface{} "File too large"
+// @Failure 415 {object} map[string]interface{} "Not a JPEG, PNG or GIF image"
+// @Router /api/v1/users/me/avatar [put]
+func (h *Handler) UploadAvatar(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	header, err := c.FormFile("avatar")
+	if err != nil {
+		h.SendValidationError(c, "The avatar file is required")
+		return
+	}
+
+	maxSize := h.service.avatarCfg.MaxSize
+	if header.Size > int64(maxSize) {
+		errors.SendError(c, errors.New(errors.ErrCodePayloadTooLarge, "Avatar too large", fmt.Sprintf("The avatar must not be larger than %d bytes", maxSize)))
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		h.SendInternalError(c, "Failed to read avatar")
+		return
+	}
+	defer file.Close()
+
+	// Read one byte more than allowed, so that the service can reject files
+	// whose declared size was wrong
+	data, err := io.ReadAll(io.LimitReader(file, int64(maxSize)+1))
+	if err != nil {
+		h.SendInternalError(c, "Failed to read avatar")
+		return
+	}
+
+	user, err := h.service.UploadAvatar(userID, data, parseIfMatch(string(c.GetHeader("If-Match"))), requestMeta(c))
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	c.Header("ETag", userETag(user.Version))
+	h.SendSuccess(c, 200, user, "Avatar updated successfully")
+}
@@ -0,0 +1,151 @@
+package user
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"path/filepath"
+	"testing"
+
+	"kube/internal/config"
+	"kube/internal/storage"
+	"kube/internal/testutil"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+)
+
+func testAvatar(t *testing.T, c color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 300; x++ {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func storedAvatars(t *testing.T, s *Service, urls models.AvatarURLs) int {
+	t.Helper()
+	stored := 0
+	for _, url := range urls {
+		path, ok := s.storage.Path(url)
+		if !ok {
+			t.Fatalf("avatar URL %s is not a storage URL", url)
+		}
+		if _, err := s.storage.DownloadFile(path); err == nil {
+			stored++
+		}
+	}
+	return stored
+}
+
+func TestUploadAvatarReplacesPreviousFiles(t *testing.T) {
+	s := newTestService(t)
+	user := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+
+	first, err := s.UploadAvatar(user.ID, testAvatar(t, color.White), nil, RequestMeta{})
+	if err != nil {
+		t.Fatalf("UploadAvatar: %v", err)
+	}
+	if len(first.AvatarURLs) != len(avatarSizes) || first.Avatar != first.AvatarURLs["256"] {
+		t.Fatalf("unexpected avatar URLs %v", first.AvatarURLs)
+	}
+	if n := storedAvatars(t, s, first.AvatarURLs); n != len(avatarSizes) {
+		t.Fatalf("%d thumbnails stored, want %d", n, len(avatarSizes))
+	}
+
+	second, err := s.UploadAvatar(user.ID, testAvatar(t, color.Black), nil, RequestMeta{})
+	if err != nil {
+		t.Fatalf("second UploadAvatar: %v", err)
+	}
+	if second.Avatar == first.Avatar {
+		t.Error("a new upload reused the URL of the previous one")
+	}
+	if n := storedAvatars(t, s, second.AvatarURLs); n != len(avatarSizes) {
+		t.Errorf("%d new thumbnails stored, want %d", n, len(avatarSizes))
+	}
+	if n := storedAvatars(t, s, first.AvatarURLs); n != 0 {
+		t.Errorf("%d previous thumbnails left behind", n)
+	}
+}
+
+func TestUploadAvatarFailedUpdateKeepsCurrentFiles(t *testing.T) {
+	s := newTestService(t)
+	dir := t.TempDir()
+	s.storage = storage.Init(&config.Config{Storage: testutil.StorageConfig(dir)})
+	user := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+
+	current, err := s.UploadAvatar(user.ID, testAvatar(t, color.White), nil, RequestMeta{})
+	if err != nil {
+		t.Fatalf("UploadAvatar: %v", err)
+	}
+
+	// A stale version fails the update after the thumbnails were written
+	_, err = s.UploadAvatar(user.ID, testAvatar(t, color.Black), []int{current.Version - 1}, RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodePreconditionFailed)
+
+	if n := storedAvatars(t, s, current.AvatarURLs); n != len(avatarSizes) {
+		t.Errorf("%d current thumbnails left, want %d", n, len(avatarSizes))
+	}
+	uploads, err := filepath.Glob(filepath.Join(dir, avatarDir(user.ID), "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(uploads) != 1 {
+		t.Errorf("%d avatar uploads stored, want only the current one", len(uploads))
+	}
+}
+
+func TestExternalAvatarDeletesUploadedFiles(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(s *Service, userID uint) (*models.UserResponse, error)
+	}{
+		{"update", func(s *Service, userID uint) (*models.UserResponse, error) {
+			return s.UpdateUser(userID, &models.UserUpdateRequest{FirstName: "Jane", Avatar: "https://cdn.example.com/jane.png"}, nil, RequestMeta{})
+		}},
+		{"patch", func(s *Service, userID uint) (*models.UserResponse, error) {
+			return s.PatchUser(userID, []byte(`{"avatar": "https://cdn.example.com/jane.png"}`), nil, RequestMeta{})
+		}},
+		{"patch clearing the avatar", func(s *Service, userID uint) (*models.UserResponse, error) {
+			return s.PatchUser(userID, []byte(`{"avatar": null}`), nil, RequestMeta{})
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newTestService(t)
+			user := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+
+			uploaded, err := s.UploadAvatar(user.ID, testAvatar(t, color.White), nil, RequestMeta{})
+			if err != nil {
+				t.Fatalf("UploadAvatar: %v", err)
+			}
+
+			// Other fields leave the uploaded avatar alone
+			if _, err := s.PatchUser(user.ID, []byte(`{"first_name": "Jane"}`), nil, RequestMeta{}); err != nil {
+				t.Fatalf("PatchUser: %v", err)
+			}
+			if n := storedAvatars(t, s, uploaded.AvatarURLs); n != len(avatarSizes) {
+				t.Fatalf("%d thumbnails left after an unrelated change, want %d", n, len(avatarSizes))
+			}
+
+			updated, err := tt.update(s, user.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(updated.AvatarURLs) != 0 {
+				t.Errorf("avatar URLs = %v, want none", updated.AvatarURLs)
+			}
+			if n := storedAvatars(t, s, uploaded.AvatarURLs); n != 0 {
+				t.Errorf("%d uploaded thumbnails left behind", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package user
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ExportUserData collects everything stored about the user for a data
+// subject access request
+func (s *Service) ExportUserData(userID uint) (*models.UserExport, error) {
+	db := s.GetDB()
+
+	var user models.User
+	if err := db.Preload("Roles").First(&user, userID).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeUserNotFound, "User not found", "User does not exist")
+	}
+
+	export := &models.UserExport{
+		ExportedAt:    time.Now().UTC(),
+		Profile:       *s.toUserResponse(&user),
+		Sessions:      []models.Session{},
+		Identities:    []models.Identity{},
+		APIKeys:       []models.APIKey{},
+		Channels:      []models.ChannelResponse{},
+		Subscriptions: []models.SubscriptionResponse{},
+		AuditEvents:   []models.AuditEvent{},
+	}
+
+	if err := db.Where("user_id = ?", userID).Order("created_at").Find(&export.Sessions).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to export sessions", err.Error())
+	}
+	if err := db.Where("user_id = ?", userID).Order("created_at").Find(&export.Identities).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to export identities", err.Error())
+	}
+	if err := db.Where("user_id = ?", userID).Order("created_at").Find(&export.APIKeys).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to export API keys", err.Error())
+	}
+	if err := db.Where("actor_id = ? OR target_id = ?", userID, userID).Order("created_at").Find(&export.AuditEvents).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to export audit events", err.Error())
+	}
+
+	var channels []models.Channel
+	if err := db.Where("user_id = ?", userID).Order("created_at").Find(&channels).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to export channels", err.Error())
+	}
+	for _, channel := range channels {
+		export.Channels = append(export.Channels, models.ChannelResponse{
+			ID:              channel.ID,
+			UserID:          channel.UserID,
+			Handle:          channel.Handle,
+			Name:            channel.Name,
+			Description:     channel.Description,
+			BannerImage:     channel.BannerImage,
+			Avatar:          channel.Avatar,
+			IsActive:        channel.IsActive,
+			SubscriberCount: channel.SubscriberCount,
+			CreatedAt:       channel.CreatedAt,
+			UpdatedAt:       channel.UpdatedAt,
+		})
+	}
+
+	var subscriptions []models.Subscription
+	if err := db.Where("user_id = ?", userID).Order("created_at").Find(&subscriptions).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to export subscriptions", err.Error())
+	}
+	for _, subscription := range subscriptions {
+		export.Subscriptions = append(export.Subscriptions, models.SubscriptionResponse{
+			ChannelID:     subscription.ChannelID,
+			NotifyUploads: subscription.NotifyUploads,
+			NotifyLive:    subscription.NotifyLive,
+			CreatedAt:     subscription.CreatedAt,
+		})
+	}
+
+	return export, nil
+}
+
+// WriteExportArchive writes the export as a ZIP archive with one JSON file
+// per section
+func WriteExportArchive(w io.Writer, export *models.UserExport) error {
+	sections := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"sessions.json", export.Sessions},
+		{"identities.json", export.Identities},
+		{"api_keys.json", export.APIKeys},
+		{"channels.json", export.Channels},
+		{"subscriptions.json", export.Subscriptions},
+		{"audit_events.json", export.AuditEvents},
+	}
+
+	archive := zip.NewWriter(w)
+	for _, section := range sections {
+		file, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     section.name,
+			Method:   zip.Deflate,
+			Modified: export.ExportedAt,
+		})
+		if err != nil {
+			return err
+		}
+
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(section.data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// EraseUser anonymizes the personal data of the user on request. The user
+// row is kept, so content created by the user keeps a valid owner, but
+// every field that identifies the person is overwritten and all sessions,
+// linked identities, API keys, pending tokens and uploaded avatars are
+// removed. The account cannot be used afterwards. Audit events are kept with
+// their IPs and field values redacted. confirm must repeat the current
+// username.
+func (s *Service) EraseUser(userID uint, confirm string, meta RequestMeta) error {
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeUserNotFound, "User not found", "User does not exist")
+		}
+		if user.ErasedAt != nil {
+			return apperrors.New(apperrors.ErrCodeInvalidOperation, "Account already erased", "Personal data of this account has already been erased")
+		}
+		if !strings.EqualFold(strings.TrimSpace(confirm), user.Username) {
+			return apperrors.New(apperrors.ErrCodeValidationFailed, "Confirmation does not match", "Repeat your username to confirm the erasure")
+		}
+
+		password, err := s.unusablePasswordHash()
+		if err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeInternalError, "Failed to erase user", err.Error())
+		}
+
+		now := time.Now()
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"username":          fmt.Sprintf("erased-%d", user.ID),
+			"email":             fmt.Sprintf("erased-%d@users.invalid", user.ID),
+			"password":          password,
+			"first_name":        "",
+			"last_name":         "",
+			"avatar":            "",
+			"avatar_urls":       nil,
+			"is_active":         false,
+			"email_verified_at": nil,
+			"mfa_enabled":       false,
+			"mfa_secret":        "",
+			"mfa_last_step":     0,
+			"erased_at":         now,
+			"version":           gorm.Expr("version + 1"),
+		}).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to erase user", err.Error())
+		}
+
+		// Records that only exist for the person and carry their IP
+		// addresses, devices or external accounts
+		for _, model := range []interface{}{
+			&models.Session{},
+			&models.RefreshToken{},
+			&models.UserToken{},
+			&models.RecoveryCode{},
+			&models.Identity{},
+			&models.APIKey{},
+		} {
+			if err := tx.Where("user_id = ?", userID).Delete(model).Error; err != nil {
+				return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to erase user", err.Error())
+			}
+		}
+
+		if err := redactAuditEvents(tx, userID); err != nil {
+			return err
+		}
+		event := newAuditEvent(models.AuditActionErase, meta, userID, nil)
+		event.IP = ""
+		return appendAudit(tx, event)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.deleteAvatarFiles(userID)
+
+	if err := s.revocations.RevokeUser(context.Background(), userID, time.Now().Add(s.accessTTL)); err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeServiceUnavailable, "Failed to revoke tokens", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package user
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"kube/pkg/errors"
+	"kube/pkg/models"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// ExportUserData godoc
+// @Summary Export personal data
+// @Description Download everything stored about the authenticated user: profile, sessions, linked identities, API keys, channels, subscriptions and audit events. The ZIP format contains one JSON file per section.
+// @Tags users
+// @Produce json
+// @Produce application/zip
+// @Security BearerAuth
+// @Param format query string false "Archive format" Enums(json, zip) default(json)
+// @Success 200 {object} models.UserExport "Personal data export"
+// @Failure 400 {object} map[string]interface{} "Unsupported format"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Called with an API key"
+// @Router /api/v1/users/me/export [get]
+func (h *Handler) ExportUserData(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	format := c.DefaultQuery("format", models.ExportFormatJSON)
+	if format != models.ExportFormatJSON && format != models.ExportFormatZIP {
+		h.SendValidationError(c, "Format must be json or zip")
+		return
+	}
+
+	export, err := h.service.ExportUserData(userID)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	var body bytes.Buffer
+	contentType := "application/json; charset=utf-8"
+	if format == models.ExportFormatZIP {
+		contentType = "application/zip"
+		err = WriteExportArchive(&body, export)
+	} else {
+		encoder := json.NewEncoder(&body)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(export)
+	}
+	if err != nil {
+		h.SendInternalError(c, "Failed to build export")
+		return
+	}
+
+	filename := fmt.Sprintf("user-%d-export-%s.%s", userID, export.ExportedAt.Format("20060102"), format)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Header("Cache-Control", "no-store")
+	c.Data(200, contentType, body.Bytes())
+}
+
+// EraseUser godoc
+// @Summary Erase personal data
+// @Description Anonymize the profile of the authenticated user and remove their sessions, linked identities and API keys. Content such as channels is kept under the anonymized account. The account can no longer be used. This cannot be undone.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.ErasureRequest true "Username as confirmation"
+// @Success 200 {object} map[string]interface{} "Personal data erased"
+// @Failure 400 {object} map[string]interface{} "Invalid request data or confirmation does not match"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Called with an API key"
+// @Router /api/v1/users/me/erasure [post]
+func (h *Handler) EraseUser(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	var req models.ErasureRequest
+	if err := c.BindJSON(&req); err != nil {
+		h.SendValidationError(c, "Invalid request data format")
+		return
+	}
+
+	if err := h.service.EraseUser(userID, req.Confirm, requestMeta(c)); err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, nil, "Personal data erased")
+}
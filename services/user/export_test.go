@@ -0,0 +1,122 @@
+package user
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"image/color"
+	"strings"
+	"testing"
+
+	"kube/internal/testutil"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+)
+
+func TestExportUserData(t *testing.T) {
+	s := newTestService(t)
+	jane := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	bob := registerUser(t, s, "bob", "bob@example.com", "correct horse battery")
+	loginFrom(t, s, "jane", "laptop")
+	loginFrom(t, s, "bob", "desktop")
+	key := createAPIKey(t, s, jane.ID)
+	createChannel(t, s, jane.ID, "janes-channel")
+	createChannel(t, s, bob.ID, "bobs-channel", jane.ID)
+
+	export, err := s.ExportUserData(jane.ID)
+	if err != nil {
+		t.Fatalf("ExportUserData: %v", err)
+	}
+	if export.Profile.ID != jane.ID || len(export.Sessions) != 1 || len(export.APIKeys) != 1 ||
+		len(export.Channels) != 1 || export.Channels[0].Handle != "janes-channel" ||
+		len(export.Subscriptions) != 1 || len(export.AuditEvents) != 2 {
+		t.Errorf("unexpected export %+v", export)
+	}
+	for _, session := range export.Sessions {
+		if session.UserID != jane.ID {
+			t.Errorf("session of user %d exported", session.UserID)
+		}
+	}
+
+	var archive bytes.Buffer
+	if err := WriteExportArchive(&archive, export); err != nil {
+		t.Fatalf("WriteExportArchive: %v", err)
+	}
+	files, err := zip.NewReader(bytes.NewReader(archive.Bytes()), int64(archive.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, file := range files.File {
+		names = append(names, file.Name)
+		f, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var content bytes.Buffer
+		content.ReadFrom(f)
+		f.Close()
+		if !json.Valid(content.Bytes()) {
+			t.Errorf("%s is not valid JSON", file.Name)
+		}
+		// Secrets are never exported, not even hashed
+		if strings.Contains(content.String(), key) || strings.Contains(content.String(), hashToken(key)) {
+			t.Errorf("%s contains the API key", file.Name)
+		}
+	}
+	if got := strings.Join(names, " "); got != "profile.json sessions.json identities.json api_keys.json channels.json subscriptions.json audit_events.json" {
+		t.Errorf("archive files = %s", got)
+	}
+}
+
+func TestEraseUser(t *testing.T) {
+	s := newTestService(t)
+	jane := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	login := loginFrom(t, s, "jane", "laptop")
+	key := createAPIKey(t, s, jane.ID)
+	avatar, err := s.UploadAvatar(jane.ID, testAvatar(t, color.White), nil, RequestMeta{})
+	if err != nil {
+		t.Fatalf("UploadAvatar: %v", err)
+	}
+
+	err = s.EraseUser(jane.ID, "someone-else", RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeValidationFailed)
+
+	if err := s.EraseUser(jane.ID, " Jane ", RequestMeta{IP: "10.0.0.1"}); err != nil {
+		t.Fatalf("EraseUser: %v", err)
+	}
+
+	var user models.User
+	if err := s.GetDB().First(&user, jane.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(user.Username+user.Email, "jane") || user.IsActive || user.ErasedAt == nil || user.Avatar != "" {
+		t.Errorf("personal data left on the user row: %+v", user)
+	}
+
+	_, err = s.Login(&models.UserLoginRequest{Identifier: "jane", Password: "correct horse battery"}, RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeInvalidCredentials)
+	if !isRevoked(t, s, login.AccessToken) {
+		t.Error("access token still valid after the erasure")
+	}
+	if _, ok, _ := s.ValidateAPIKey(context.Background(), key, ""); ok {
+		t.Error("API key still valid after the erasure")
+	}
+	if n := storedAvatars(t, s, avatar.AvatarURLs); n != 0 {
+		t.Errorf("%d avatar thumbnails left", n)
+	}
+	for _, model := range []interface{}{&models.Session{}, &models.RefreshToken{}, &models.UserToken{}, &models.APIKey{}} {
+		if n := testutil.CountRows(t, s.GetDB(), model, "user_id = ?", jane.ID); n != 0 {
+			t.Errorf("%d %T rows left", n, model)
+		}
+	}
+	if n := testutil.CountRows(t, s.GetDB(), &models.AuditEvent{}, "(actor_id = ? OR target_id = ?) AND ip <> ''", jane.ID, jane.ID); n != 0 {
+		t.Errorf("%d audit events still carry an IP", n)
+	}
+
+	testutil.AssertErrorCode(t, s.EraseUser(jane.ID, "erased-1", RequestMeta{}), apperrors.ErrCodeInvalidOperation)
+
+	// The address can be used again
+	registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+}
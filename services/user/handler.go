@@ -1,6 +1,9 @@
 package user
 
 import (
+	"strings"
+
+	"kube/internal/middleware"
 	"kube/pkg/errors"
 	"kube/pkg/handlers"
 	"kube/pkg/models"
@@ -22,13 +25,14 @@ func NewHandler(service *Service) *Handler {
 
 // Register godoc
 // @Summary Register a new user
-// @Description Create a new user account with the provided information
+// @Description Create a new user account with the provided information. Username and email are trimmed, NFKC normalized and lowercased; usernames are 3-30 characters of a-z, 0-9, ".", "_" and "-".
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param user body models.UserCreateRequest true "User registration data"
 // @Success 201 {object} map[string]interface{} "User created successfully"
-// @Failure 400 {object} map[string]interface{} "Invalid request data or user already exists"
+// @Failure 400 {object} map[string]interface{} "Invalid request data, or password rejected by the policy, see error.metadata.violations"
+// @Failure 409 {object} map[string]interface{} "User already exists"
 // @Router /api/v1/users/register [post]
 func (h *Handler) Register(c *app.RequestContext) {
 	var req models.UserCreateRequest
@@ -37,7 +41,7 @@ func (h *Handler) Register(c *app.RequestContext) {
 		return
 	}
 
-	user, err := h.service.CreateUser(&req)
+	user, err := h.service.CreateUser(&req, requestMeta(c))
 	if err != nil {
 		errors.SendError(c, err)
 		return
@@ -48,13 +52,16 @@ func (h *Handler) Register(c *app.RequestContext) {
 
 // Login godoc
 // @Summary User login
-// @Description Authenticate user with email and password
+// @Description Authenticate user with a username or email ("identifier") and password. Identifiers are case-insensitive. A locked account is only reported as locked when the correct password is given; otherwise the response is the same as for an unknown account.
 // @Tags users
 // @Accept json
 // @Produce json
 // @Param credentials body models.UserLoginRequest true "Login credentials"
 // @Success 200 {object} map[string]interface{} "Login successful"
-// @Failure 401 {object} map[string]interface{} "Invalid credentials or account deactivated"
+// @Failure 401 {object} map[string]interface{} "Invalid credentials"
+// @Failure 403 {object} map[string]interface{} "Account deactivated or email not verified"
+// @Failure 423 {object} map[string]interface{} "Correct password, but the account is temporarily locked, see Retry-After"
+// @Failure 429 {object} map[string]interface{} "Too many failed logins from this address"
 // @Failure 400 {object} map[string]interface{} "Invalid request data"
 // @Router /api/v1/users/login [post]
 func (h *Handler) Login(c *app.RequestContext) {
@@ -64,31 +71,260 @@ func (h *Handler) Login(c *app.RequestContext) {
 		return
 	}
 
-	user, token, err := h.service.Login(&req)
+	response, err := h.service.Login(&req, requestMeta(c))
 	if err != nil {
 		errors.SendError(c, err)
 		return
 	}
 
-	response := map[string]interface{}{
-		"user":  user,
-		"token": token,
-	}
 	h.SendSuccess(c, 200, response, "Login successful")
 }
 
+// RefreshToken godoc
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token and a rotated refresh token. Reusing a refresh token revokes every token issued from the same login.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body models.TokenRefreshRequest true "Refresh token"
+// @Success 200 {object} map[string]interface{} "Token refreshed successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request data"
+// @Failure 401 {object} map[string]interface{} "Invalid, expired or reused refresh token"
+// @Router /api/v1/users/token/refresh [post]
+func (h *Handler) RefreshToken(c *app.RequestContext) {
+	var req models.TokenRefreshRequest
+	if err := c.BindJSON(&req); err != nil {
+		h.SendValidationError(c, "Invalid request data format")
+		return
+	}
+
+	tokens, err := h.service.RefreshTokens(&req, requestMeta(c))
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, tokens, "Token refreshed successfully")
+}
+
+// JWKS godoc
+// @Summary JSON Web Key Set
+// @Description Public keys that verify access tokens, in the standard JWKS format consumed by other services. Empty when tokens are signed with HS256.
+// @Tags auth
+// @Produce json
+// @Success 200 {object} auth.JWKS "JSON Web Key Set"
+// @Router /.well-known/jwks.json [get]
+func (h *Handler) JWKS(c *app.RequestContext) {
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(200, h.service.JWKS())
+}
+
+// VerifyEmail godoc
+// @Summary Verify email address
+// @Description Confirm the email address of an account using the token from the verification email
+// @Tags users
+// @Produce json
+// @Param token query string true "Verification token"
+// @Success 200 {object} map[string]interface{} "Email verified successfully"
+// @Failure 400 {object} map[string]interface{} "Missing token"
+// @Failure 401 {object} map[string]interface{} "Invalid, used or expired token"
+// @Router /api/v1/users/verify [get]
+func (h *Handler) VerifyEmail(c *app.RequestContext) {
+	token := c.Query("token")
+	if token == "" {
+		h.SendValidationError(c, "Verification token is required")
+		return
+	}
+
+	user, err := h.service.VerifyEmail(token)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, user, "Email verified successfully")
+}
+
+// ResendVerification godoc
+// @Summary Resend verification email
+// @Description Send a new verification link. The response is the same whether or not the email is registered, verified or throttled; at most one email per account is sent per EMAIL_VERIFICATION_RESEND_INTERVAL seconds.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body models.EmailVerificationResendRequest true "Account email"
+// @Success 200 {object} map[string]interface{} "Verification email sent if the account exists"
+// @Failure 400 {object} map[string]interface{} "Invalid request data"
+// @Router /api/v1/users/verify/resend [post]
+func (h *Handler) ResendVerification(c *app.RequestContext) {
+	var req models.EmailVerificationResendRequest
+	if err := c.BindJSON(&req); err != nil {
+		h.SendValidationError(c, "Invalid request data format")
+		return
+	}
+
+	if err := h.service.ResendVerification(&req); err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, nil, "If the email is registered and unverified, a verification link has been sent")
+}
+
+// ForgotPassword godoc
+// @Summary Request password reset
+// @Description Email a single-use password reset link. The response is the same whether or not the email is registered. An address receives at most one email per PASSWORD_RESET_INTERVAL; further requests are accepted but ignored.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body models.PasswordForgotRequest true "Account email"
+// @Success 200 {object} map[string]interface{} "Reset email sent if the account exists"
+// @Failure 400 {object} map[string]interface{} "Invalid request data"
+// @Failure 429 {object} map[string]interface{} "Too many requests from this client IP, see error.metadata.retry_after"
+// @Router /api/v1/users/password/forgot [post]
+func (h *Handler) ForgotPassword(c *app.RequestContext) {
+	var req models.PasswordForgotRequest
+	if err := c.BindJSON(&req); err != nil {
+		h.SendValidationError(c, "Invalid request data format")
+		return
+	}
+
+	if err := h.service.ForgotPassword(&req, requestMeta(c)); err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, nil, "If the email is registered, a reset link has been sent")
+}
+
+// ResetPassword godoc
+// @Summary Reset password
+// @Description Set a new password using a reset token. All existing sessions are logged out.
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param request body models.PasswordResetRequest true "Reset token and new password"
+// @Success 200 {object} map[string]interface{} "Password reset successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request data, or password rejected by the policy, see error.metadata.violations"
+// @Failure 401 {object} map[string]interface{} "Invalid, used or expired token"
+// @Router /api/v1/users/password/reset [post]
+func (h *Handler) ResetPassword(c *app.RequestContext) {
+	var req models.PasswordResetRequest
+	if err := c.BindJSON(&req); err != nil {
+		h.SendValidationError(c, "Invalid request data format")
+		return
+	}
+
+	if err := h.service.ResetPassword(&req, requestMeta(c)); err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, nil, "Password reset successfully")
+}
+
+// Logout godoc
+// @Summary User logout
+// @Description Revoke the current session, or every session of the user when "all" is true
+// @Tags users
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.LogoutRequest false "Logout options"
+// @Success 200 {object} map[string]interface{} "Logout successful"
+// @Failure 400 {object} map[string]interface{} "Invalid request data"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Router /api/v1/users/logout [post]
+func (h *Handler) Logout(c *app.RequestContext) {
+	var req models.LogoutRequest
+	if len(c.Request.Body()) > 0 {
+		if err := c.BindJSON(&req); err != nil {
+			h.SendValidationError(c, "Invalid request data format")
+			return
+		}
+	}
+
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		h.SendUnauthorized(c, "Authentication required")
+		return
+	}
+
+	if err := h.service.Logout(claims, req.All); err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, nil, "Logout successful")
+}
+
+// userListSpec whitelists the sort fields and filters of the user listing
+var userListSpec = handlers.ListSpec{
+	SortFields:  []string{"created_at", "id", "username", "email"},
+	DefaultDesc: true,
+	Filters: map[string]handlers.FilterType{
+		"is_active":      handlers.FilterBool,
+		"email":          handlers.FilterString,
+		"username":       handlers.FilterString,
+		"created_after":  handlers.FilterTime,
+		"created_before": handlers.FilterTime,
+	},
+}
+
+// ListUsers godoc
+// @Summary List users
+// @Description List users with cursor pagination. Pass next_cursor from the previous page as cursor to continue.
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Page size, 1-100" default(20)
+// @Param cursor query string false "Cursor from the previous page"
+// @Param sort query string false "created_at, id, username or email; prefix with - for descending" default(-created_at)
+// @Param is_active query bool false "Filter by active state"
+// @Param email query string false "Email prefix"
+// @Param username query string false "Username prefix"
+// @Param created_after query string false "Created at or after (RFC 3339)"
+// @Param created_before query string false "Created before (RFC 3339)"
+// @Success 200 {object} map[string]interface{} "Users retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid query parameters or cursor"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Missing users:read permission"
+// @Router /api/v1/users [get]
+func (h *Handler) ListUsers(c *app.RequestContext) {
+	opts, err := h.ParseListOptions(c, userListSpec)
+	if err != nil {
+		h.SendValidationError(c, err.Error())
+		return
+	}
+
+	page, err := h.service.ListUsers(opts)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, page, "Users retrieved successfully")
+}
+
 // GetUser godoc
 // @Summary Get user by ID
-// @Description Retrieve user information by user ID
+// @Description Retrieve user information by user ID. API keys need the users:read scope.
 // @Tags users
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "User ID"
-// @Success 200 {object} map[string]interface{} "User information"
+// @Success 200 {object} map[string]interface{} "User information, with the version as ETag header"
 // @Failure 400 {object} map[string]interface{} "Invalid user ID"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "API key without the users:read scope"
 // @Failure 404 {object} map[string]interface{} "User not found"
 // @Router /api/v1/users/{id} [get]
 func (h *Handler) GetUser(c *app.RequestContext) {
+	if err := requireAPIKeyScope(c, models.PermissionUsersRead); err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
 	id, err := h.GetParamUint(c, "id")
 	if err != nil {
 		h.SendValidationError(c, "Invalid user ID format")
@@ -101,6 +337,7 @@ func (h *Handler) GetUser(c *app.RequestContext) {
 		return
 	}
 
+	c.Header("ETag", userETag(user.Version))
 	h.SendSuccess(c, 200, user, "User retrieved successfully")
 }
 
@@ -110,11 +347,16 @@ func (h *Handler) GetUser(c *app.RequestContext) {
 // @Tags users
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "User ID"
+// @Param If-Match header string false "ETag from a previous read; the update fails if the user changed since"
 // @Param user body models.UserUpdateRequest true "User update data"
 // @Success 200 {object} map[string]interface{} "User updated successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid request data or user ID"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Not the account owner and missing permission, or an API key without the permission scope"
 // @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 412 {object} map[string]interface{} "User modified since the If-Match ETag"
 // @Router /api/v1/users/{id} [put]
 func (h *Handler) UpdateUser(c *app.RequestContext) {
 	id, err := h.GetParamUint(c, "id")
@@ -123,30 +365,87 @@ func (h *Handler) UpdateUser(c *app.RequestContext) {
 		return
 	}
 
+	if err := authorizeUserAccess(c, id, models.PermissionUsersUpdate); err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
 	var req models.UserUpdateRequest
 	if err := c.BindJSON(&req); err != nil {
 		h.SendValidationError(c, "Invalid request data format")
 		return
 	}
 
-	user, err := h.service.UpdateUser(uint(id), &req)
+	user, err := h.service.UpdateUser(uint(id), &req, parseIfMatch(string(c.GetHeader("If-Match"))), requestMeta(c))
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	c.Header("ETag", userETag(user.Version))
+	h.SendSuccess(c, 200, user, "User updated successfully")
+}
+
+// PatchUser godoc
+// @Summary Partially update user information
+// @Description Apply a JSON merge patch (RFC 7396) to the profile. Omitted fields are left unchanged and null clears a field. Send If-Match with the ETag from a previous read to avoid overwriting concurrent changes.
+// @Tags users
+// @Accept application/merge-patch+json
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param If-Match header string false "ETag from a previous read; the update fails if the user changed since"
+// @Param patch body models.UserPatchRequest true "Merge patch"
+// @Success 200 {object} map[string]interface{} "User updated successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid patch document or field values, see error.metadata.fields"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Not the account owner and missing permission, or an API key without the permission scope"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Failure 412 {object} map[string]interface{} "User modified since the If-Match ETag"
+// @Failure 415 {object} map[string]interface{} "Unsupported content type"
+// @Router /api/v1/users/{id} [patch]
+func (h *Handler) PatchUser(c *app.RequestContext) {
+	id, err := h.GetParamUint(c, "id")
+	if err != nil {
+		h.SendValidationError(c, "Invalid user ID format")
+		return
+	}
+
+	if err := authorizeUserAccess(c, id, models.PermissionUsersUpdate); err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	contentType := strings.TrimSpace(strings.Split(string(c.ContentType()), ";")[0])
+	if contentType != "application/merge-patch+json" && contentType != "application/json" {
+		h.SendError(c, 415, "unsupported_media_type", "Content-Type must be application/merge-patch+json")
+		return
+	}
+
+	user, err := h.service.PatchUser(id, c.Request.Body(), parseIfMatch(string(c.GetHeader("If-Match"))), requestMeta(c))
 	if err != nil {
 		errors.SendError(c, err)
 		return
 	}
 
+	c.Header("ETag", userETag(user.Version))
 	h.SendSuccess(c, 200, user, "User updated successfully")
 }
 
 // DeleteUser godoc
 // @Summary Delete user
-// @Description Delete a user account by ID
+// @Description Delete a user account by ID. The account can be restored by an administrator during the grace period and is permanently purged after the retention period.
 // @Tags users
 // @Accept json
 // @Produce json
+// @Security BearerAuth
 // @Param id path int true "User ID"
 // @Success 200 {object} map[string]interface{} "User deleted successfully"
 // @Failure 400 {object} map[string]interface{} "Invalid user ID or deletion failed"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Not the account owner and missing permission, or an API key without the permission scope"
+// @Failure 404 {object} map[string]interface{} "User not found"
 // @Router /api/v1/users/{id} [delete]
 func (h *Handler) DeleteUser(c *app.RequestContext) {
 	id, err := h.GetParamUint(c, "id")
@@ -155,10 +454,70 @@ func (h *Handler) DeleteUser(c *app.RequestContext) {
 		return
 	}
 
-	if err := h.service.DeleteUser(uint(id)); err != nil {
+	if err := authorizeUserAccess(c, id, models.PermissionUsersDelete); err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	if err := h.service.DeleteUser(uint(id), requestMeta(c)); err != nil {
 		errors.SendError(c, err)
 		return
 	}
 
 	h.SendSuccess(c, 200, nil, "User deleted successfully")
 }
+
+// RestoreUser godoc
+// @Summary Restore deleted user
+// @Description Undo the deletion of a user account within the grace period
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{} "User restored successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID or grace period expired"
+// @Failure 403 {object} map[string]interface{} "Missing users:restore permission"
+// @Failure 404 {object} map[string]interface{} "No deleted user with this ID"
+// @Router /api/v1/users/{id}/restore [post]
+func (h *Handler) RestoreUser(c *app.RequestContext) {
+	id, err := h.GetParamUint(c, "id")
+	if err != nil {
+		h.SendValidationError(c, "Invalid user ID format")
+		return
+	}
+
+	user, err := h.service.RestoreUser(id, requestMeta(c))
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, user, "User restored successfully")
+}
+
+// UnlockUser godoc
+// @Summary Unlock user account
+// @Description Clear the failed login counter and lockout of an account
+// @Tags users
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{} "User unlocked successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID"
+// @Failure 403 {object} map[string]interface{} "Missing users:unlock permission"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Router /api/v1/users/{id}/unlock [post]
+func (h *Handler) UnlockUser(c *app.RequestContext) {
+	id, err := h.GetParamUint(c, "id")
+	if err != nil {
+		h.SendValidationError(c, "Invalid user ID format")
+		return
+	}
+
+	if err := h.service.UnlockUser(id); err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, nil, "User unlocked successfully")
+}
@@ -0,0 +1,95 @@
+package user
+
+import (
+	"net/mail"
+	"strings"
+
+	apperrors "kube/pkg/errors"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	minUsernameLength = 3
+	maxUsernameLength = 30
+	maxEmailLength    = 254
+)
+
+// reservedUsernames cannot be registered because they collide with routes,
+// such as /me, or could be mistaken for staff accounts
+var reservedUsernames = map[string]bool{
+	"me":            true,
+	"admin":         true,
+	"administrator": true,
+	"root":          true,
+	"system":        true,
+	"support":       true,
+	"security":      true,
+	"moderator":     true,
+	"staff":         true,
+	"api":           true,
+	"login":         true,
+	"logout":        true,
+	"register":      true,
+	"settings":      true,
+	"help":          true,
+	"null":          true,
+	"undefined":     true,
+}
+
+// reservedUsernamePrefixes are used for generated usernames, such as those
+// of erased accounts
+var reservedUsernamePrefixes = []string{"erased-"}
+
+// normalizeIdentifier returns the canonical form of an email or username:
+// trimmed, Unicode NFKC normalized and lowercased. Identifiers are stored and
+// looked up in this form, so that "Alice@X.com" and "alice@x.com" are the
+// same account.
+func normalizeIdentifier(value string) string {
+	return strings.ToLower(norm.NFKC.String(strings.TrimSpace(value)))
+}
+
+// validateUsername checks a normalized username: 3-30 characters of a-z,
+// 0-9, ".", "_" and "-", starting with a letter or digit, and not reserved
+func validateUsername(username string) error {
+	if isReservedUsername(username) {
+		return apperrors.New(apperrors.ErrCodeValidationFailed, "Username not available", "This username is reserved").
+			AddMetadata("fields", map[string]string{"username": "is reserved"})
+	}
+
+	if len(username) < minUsernameLength || len(username) > maxUsernameLength {
+		return fieldValidationError(map[string]string{"username": "must be between 3 and 30 characters long"})
+	}
+
+	for i, r := range username {
+		alphanumeric := (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9')
+		if i == 0 && !alphanumeric {
+			return fieldValidationError(map[string]string{"username": "must start with a letter or digit"})
+		}
+		if !alphanumeric && r != '.' && r != '_' && r != '-' {
+			return fieldValidationError(map[string]string{"username": "may only contain letters, digits, '.', '_' and '-'"})
+		}
+	}
+	return nil
+}
+
+func isReservedUsername(username string) bool {
+	if reservedUsernames[username] {
+		return true
+	}
+	for _, prefix := range reservedUsernamePrefixes {
+		if strings.HasPrefix(username, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// validateEmail checks that a normalized email is a plain address
+func validateEmail(email string) error {
+	address, err := mail.ParseAddress(email)
+	if err != nil || address.Address != email || len(email) > maxEmailLength {
+		return fieldValidationError(map[string]string{"email": "must be a valid email address"})
+	}
+	return nil
+}
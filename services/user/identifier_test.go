@@ -0,0 +1,107 @@
+package user
+
+import (
+	"strings"
+	"testing"
+
+	"kube/internal/testutil"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+)
+
+func TestNormalizeIdentifier(t *testing.T) {
+	for value, want := range map[string]string{
+		"  Jane@Example.COM ": "jane@example.com",
+		"JaneDoe":             "janedoe",
+		"ｊａｎｅ":                "jane", // fullwidth letters
+		"ﬁona":                "fiona",
+	} {
+		if got := normalizeIdentifier(value); got != want {
+			t.Errorf("normalizeIdentifier(%q) = %q, want %q", value, got, want)
+		}
+	}
+}
+
+func TestValidateIdentifiers(t *testing.T) {
+	for username, ok := range map[string]bool{
+		"jane":                  true,
+		"jane.doe_99-x":         true,
+		"7up":                   true,
+		"jo":                    false,
+		strings.Repeat("a", 31): false,
+		"_jane":                 false,
+		"jane doe":              false,
+		"jane@example":          false,
+		"jäne":                  false,
+		"admin":                 false,
+		"me":                    false,
+		"erased-42":             false,
+	} {
+		if err := validateUsername(username); (err == nil) != ok {
+			t.Errorf("validateUsername(%q) = %v", username, err)
+		}
+	}
+
+	for email, ok := range map[string]bool{
+		"jane@example.com":                 true,
+		"jane+videos@example.co.uk":        true,
+		"jane":                             false,
+		"Jane <jane@example.com>":          false,
+		"jane@example.com, bob@x.com":      false,
+		strings.Repeat("a", 250) + "@x.io": false,
+	} {
+		if err := validateEmail(email); (err == nil) != ok {
+			t.Errorf("validateEmail(%q) = %v", email, err)
+		}
+	}
+}
+
+func TestCreateUserNormalizesIdentifiers(t *testing.T) {
+	s := newTestService(t)
+	user := registerUser(t, s, " JaneDoe ", "Jane.Doe@Example.COM", "correct horse battery")
+	if user.Username != "janedoe" || user.Email != "jane.doe@example.com" {
+		t.Errorf("stored %q / %q, want normalized identifiers", user.Username, user.Email)
+	}
+
+	for _, req := range []models.UserCreateRequest{
+		{Username: "JANEDOE", Email: "other@example.com"},
+		{Username: "ｊａｎｅｄｏｅ", Email: "other@example.com"},
+		{Username: "other", Email: "JANE.DOE@example.com"},
+	} {
+		req.Password = "correct horse battery"
+		_, err := s.CreateUser(&req, RequestMeta{})
+		testutil.AssertErrorCode(t, err, apperrors.ErrCodeUserAlreadyExists)
+	}
+
+	_, err := s.CreateUser(&models.UserCreateRequest{Username: "Admin", Email: "boss@example.com", Password: "correct horse battery"}, RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeValidationFailed)
+}
+
+func TestLoginByUsernameOrEmail(t *testing.T) {
+	s := newTestService(t)
+	jane := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+
+	for _, req := range []models.UserLoginRequest{
+		{Identifier: "jane"},
+		{Identifier: " JANE "},
+		{Identifier: "Jane@Example.com"},
+		{Email: "jane@example.com"}, // clients from before the identifier field
+	} {
+		req.Password = "correct horse battery"
+		resp, err := s.Login(&req, RequestMeta{})
+		if err != nil {
+			t.Errorf("Login(%+v): %v", req, err)
+			continue
+		}
+		if resp.User.ID != jane.ID {
+			t.Errorf("Login(%+v) logged in user %d", req, resp.User.ID)
+		}
+	}
+
+	_, err := s.Login(&models.UserLoginRequest{Password: "correct horse battery"}, RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeMissingRequired)
+
+	// The username is not accepted as an email and vice versa
+	_, err = s.Login(&models.UserLoginRequest{Identifier: "jane@", Password: "correct horse battery"}, RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeInvalidCredentials)
+}
@@ -0,0 +1,174 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const purgeBatchSize = 100
+
+// RestoreUser undeletes a soft-deleted user within the grace period. Sessions
+// ended by the deletion stay ended; the user has to log in again.
+func (s *Service) RestoreUser(id uint, meta RequestMeta) (*models.UserResponse, error) {
+	var user models.User
+
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("deleted_at IS NOT NULL").First(&user, id).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return apperrors.New(apperrors.ErrCodeUserNotFound, "Deleted user not found", "No deleted user with ID "+strconv.FormatUint(uint64(id), 10))
+			}
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to find user", err.Error())
+		}
+
+		deadline := user.DeletedAt.Time.Add(s.restoreGracePeriod())
+		if time.Now().After(deadline) {
+			return apperrors.New(apperrors.ErrCodeInvalidOperation, "Restore period expired", "The account was deleted on "+user.DeletedAt.Time.Format(time.RFC3339)+" and can no longer be restored")
+		}
+
+		if err := tx.Unscoped().Model(&user).Update("deleted_at", nil).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to restore user", err.Error())
+		}
+		user.DeletedAt = gorm.DeletedAt{}
+
+		if err := appendAudit(tx, newAuditEvent(models.AuditActionRestore, meta, id, nil)); err != nil {
+			return err
+		}
+
+		return tx.Preload("Roles").First(&user, id).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toUserResponse(&user), nil
+}
+
+// PurgeDeletedUsers permanently deletes users that were soft-deleted longer
+// than the retention period ago, together with their dependent records, their
+// channels and the stored files of their avatars and channel videos. It frees
+// their email addresses and usernames for new registrations.
+func (s *Service) PurgeDeletedUsers(ctx context.Context) (int64, error) {
+	cutoff := time.Now().Add(-time.Duration(s.accountCfg.PurgeRetention) * time.Hour)
+
+	var purged int64
+	for {
+		var ids []uint
+		if err := s.GetDB().WithContext(ctx).Unscoped().Model(&models.User{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).
+			Limit(purgeBatchSize).
+			Pluck("id", &ids).Error; err != nil {
+			return purged, err
+		}
+		if len(ids) == 0 {
+			return purged, nil
+		}
+
+		var storageKeys []string
+		err := s.WithTransaction(func(tx *gorm.DB) error {
+			// The role join table has no cascading foreign key
+			if err := tx.Exec("DELETE FROM user_roles WHERE user_id IN ?", ids).Error; err != nil {
+				return err
+			}
+			if err := releaseSubscriptions(tx, ids); err != nil {
+				return err
+			}
+
+			// The users' channels and their videos go with the users through
+			// cascading foreign keys. Locking the channel rows keeps new
+			// videos out until the deletion commits, as in DeleteChannel.
+			var channelIDs []uint
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&models.Channel{}).
+				Where("user_id IN ?", ids).Pluck("id", &channelIDs).Error; err != nil {
+				return err
+			}
+			if len(channelIDs) > 0 {
+				if err := tx.Model(&models.Video{}).Where("channel_id IN ?", channelIDs).Pluck("storage_key", &storageKeys).Error; err != nil {
+					return err
+				}
+			}
+
+			return tx.Unscoped().Delete(&models.User{}, ids).Error
+		})
+		if err != nil {
+			return purged, err
+		}
+
+		for _, id := range ids {
+			s.deleteAvatarFiles(id)
+		}
+		for _, key := range storageKeys {
+			if err := s.storage.DeleteFile(key); err != nil {
+				hlog.Errorf("Failed to delete video file %s of a purged user: %v", key, err)
+			}
+		}
+		purged += int64(len(ids))
+	}
+}
+
+// RunPurger purges deleted users every PurgeInterval minutes until ctx is
+// cancelled. It returns immediately when the interval is zero.
+func (s *Service) RunPurger(ctx context.Context) {
+	if s.accountCfg.PurgeInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(s.accountCfg.PurgeInterval) * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		purged, err := s.PurgeDeletedUsers(ctx)
+		if err != nil {
+			hlog.Errorf("Failed to purge deleted users: %v", err)
+		} else if purged > 0 {
+			hlog.Infof("Purged %d deleted users", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) restoreGracePeriod() time.Duration {
+	return time.Duration(s.accountCfg.RestoreGracePeriod) * time.Hour
+}
+
+// releaseSubscriptions lowers the subscriber counts of the channels the users
+// subscribed to. The subscriptions themselves are removed by their cascading
+// foreign key, which does not touch the denormalized counts. Channels are
+// updated in ID order, so concurrent purges and subscriptions lock them in
+// the same order.
+func releaseSubscriptions(tx *gorm.DB, userIDs []uint) error {
+	var released []struct {
+		ChannelID uint
+		Count     int64
+	}
+	if err := tx.Model(&models.Subscription{}).
+		Select("channel_id, COUNT(*) AS count").
+		Where("user_id IN ?", userIDs).
+		Group("channel_id").
+		Order("channel_id").
+		Scan(&released).Error; err != nil {
+		return err
+	}
+
+	for _, r := range released {
+		if err := tx.Model(&models.Channel{}).Where("id = ?", r.ChannelID).
+			UpdateColumn("subscriber_count", gorm.Expr("CASE WHEN subscriber_count > ? THEN subscriber_count - ? ELSE 0 END", r.Count, r.Count)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"image/color"
+	"testing"
+	"time"
+
+	"kube/internal/config"
+	"kube/internal/storage"
+	"kube/internal/testutil"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+func withAccountConfig(cfg *config.Config) {
+	cfg.Account = config.AccountConfig{RestoreGracePeriod: 24, PurgeRetention: 24}
+}
+
+// deleteUserAt soft-deletes the user as if DeleteUser had run at deletedAt
+func deleteUserAt(t *testing.T, s *Service, userID uint, deletedAt time.Time) {
+	t.Helper()
+	if err := s.DeleteUser(userID, RequestMeta{}); err != nil {
+		t.Fatalf("DeleteUser: %v", err)
+	}
+	if err := s.GetDB().Unscoped().Model(&models.User{}).Where("id = ?", userID).Update("deleted_at", deletedAt).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func createChannel(t *testing.T, s *Service, ownerID uint, handle string, subscribers ...uint) *models.Channel {
+	t.Helper()
+	channel := models.Channel{UserID: ownerID, Handle: handle, Name: handle, IsActive: true, SubscriberCount: int64(len(subscribers))}
+	if err := s.GetDB().Create(&channel).Error; err != nil {
+		t.Fatal(err)
+	}
+	for _, userID := range subscribers {
+		if err := s.GetDB().Create(&models.Subscription{UserID: userID, ChannelID: channel.ID}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &channel
+}
+
+func createVideo(t *testing.T, s *Service, channel *models.Channel, key string) {
+	t.Helper()
+	if err := s.storage.UploadFile(key, []byte("video")); err != nil {
+		t.Fatal(err)
+	}
+	video := models.Video{UserID: &channel.UserID, ChannelID: channel.ID, Title: key, Status: models.VideoStatusUploaded, StorageKey: key}
+	if err := s.GetDB().Create(&video).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	s := newTestService(t, withAccountConfig)
+
+	purged := registerUser(t, s, "purged", "purged@example.com", "correct horse battery")
+	kept := registerUser(t, s, "kept", "kept@example.com", "correct horse battery")
+	recent := registerUser(t, s, "recent", "recent@example.com", "correct horse battery")
+	other := registerUser(t, s, "other", "other@example.com", "correct horse battery")
+
+	avatar, err := s.UploadAvatar(purged.ID, testAvatar(t, color.White), nil, RequestMeta{})
+	if err != nil {
+		t.Fatalf("UploadAvatar: %v", err)
+	}
+
+	ownChannel := createChannel(t, s, purged.ID, "purged-channel", kept.ID)
+	createVideo(t, s, ownChannel, "videos/purged/source.mp4")
+	keptChannel := createChannel(t, s, kept.ID, "kept-channel", purged.ID, recent.ID, other.ID)
+	createVideo(t, s, keptChannel, "videos/kept/source.mp4")
+	if err := s.GetDB().Create(&models.ChannelMember{ChannelID: keptChannel.ID, UserID: purged.ID, Role: models.ChannelRoleEditor}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	deleteUserAt(t, s, purged.ID, time.Now().Add(-48*time.Hour))
+	deleteUserAt(t, s, recent.ID, time.Now().Add(-time.Hour))
+
+	n, err := s.PurgeDeletedUsers(context.Background())
+	if err != nil {
+		t.Fatalf("PurgeDeletedUsers: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("purged %d users, want 1", n)
+	}
+
+	// Soft-deleted rows count too
+	db := s.GetDB().Unscoped().Session(&gorm.Session{})
+	for _, tt := range []struct {
+		name  string
+		model interface{}
+		query string
+		args  []interface{}
+		want  int64
+	}{
+		{"purged user", &models.User{}, "id = ?", []interface{}{purged.ID}, 0},
+		{"recently deleted user", &models.User{}, "id = ?", []interface{}{recent.ID}, 1},
+		{"channel memberships", &models.ChannelMember{}, "user_id = ?", []interface{}{purged.ID}, 0},
+		{"channels of the purged user", &models.Channel{}, "user_id = ?", []interface{}{purged.ID}, 0},
+		{"videos of the purged user", &models.Video{}, "channel_id = ?", []interface{}{ownChannel.ID}, 0},
+		{"subscriptions of the purged user", &models.Subscription{}, "user_id = ?", []interface{}{purged.ID}, 0},
+	} {
+		if got := testutil.CountRows(t, db, tt.model, tt.query, tt.args...); got != tt.want {
+			t.Errorf("%s: %d rows, want %d", tt.name, got, tt.want)
+		}
+	}
+
+	var roles []uint
+	if err := s.GetDB().Table("user_roles").Distinct().Order("user_id").Pluck("user_id", &roles).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(roles) != 3 || roles[0] != kept.ID || roles[1] != recent.ID || roles[2] != other.ID {
+		t.Errorf("users with roles = %v, want the remaining users", roles)
+	}
+
+	var channel models.Channel
+	if err := s.GetDB().First(&channel, keptChannel.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if channel.SubscriberCount != 2 {
+		t.Errorf("subscriber count = %d, want 2", channel.SubscriberCount)
+	}
+
+	if _, err := s.storage.DownloadFile("videos/purged/source.mp4"); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("video file of the purged user: %v, want it deleted", err)
+	}
+	if _, err := s.storage.DownloadFile("videos/kept/source.mp4"); err != nil {
+		t.Errorf("video file of another channel deleted: %v", err)
+	}
+	if n := storedAvatars(t, s, avatar.AvatarURLs); n != 0 {
+		t.Errorf("%d avatar thumbnails left", n)
+	}
+
+	// The address is free again
+	registerUser(t, s, "purged", "purged@example.com", "correct horse battery")
+}
+
+func TestPurgeClampsSubscriberCount(t *testing.T) {
+	s := newTestService(t, withAccountConfig)
+
+	owner := registerUser(t, s, "owner", "owner@example.com", "correct horse battery")
+	purged := registerUser(t, s, "purged", "purged@example.com", "correct horse battery")
+	channel := createChannel(t, s, owner.ID, "drifted", purged.ID)
+	if err := s.GetDB().Model(channel).UpdateColumn("subscriber_count", 0).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	deleteUserAt(t, s, purged.ID, time.Now().Add(-48*time.Hour))
+	if _, err := s.PurgeDeletedUsers(context.Background()); err != nil {
+		t.Fatalf("PurgeDeletedUsers: %v", err)
+	}
+
+	if err := s.GetDB().First(channel, channel.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if channel.SubscriberCount != 0 {
+		t.Errorf("subscriber count = %d, want 0", channel.SubscriberCount)
+	}
+}
+
+func TestRestoreUser(t *testing.T) {
+	s := newTestService(t, withAccountConfig)
+
+	restorable := registerUser(t, s, "restorable", "restorable@example.com", "correct horse battery")
+	expired := registerUser(t, s, "expired", "expired@example.com", "correct horse battery")
+	deleteUserAt(t, s, restorable.ID, time.Now().Add(-time.Hour))
+	deleteUserAt(t, s, expired.ID, time.Now().Add(-48*time.Hour))
+
+	_, err := s.Login(&models.UserLoginRequest{Identifier: "restorable", Password: "correct horse battery"}, RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeInvalidCredentials)
+
+	if _, err := s.RestoreUser(restorable.ID, RequestMeta{}); err != nil {
+		t.Fatalf("RestoreUser: %v", err)
+	}
+	passwordLogin(t, s, "restorable", "correct horse battery")
+
+	_, err = s.RestoreUser(expired.ID, RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeInvalidOperation)
+
+	_, err = s.RestoreUser(restorable.ID, RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeUserNotFound)
+}
@@ -0,0 +1,65 @@
+package user
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+	"kube/pkg/services"
+)
+
+// ListUsers returns one page of users. The email and username filters match
+// by prefix, the created_* filters bound the creation time.
+func (s *Service) ListUsers(opts *models.ListOptions) (*models.Page, error) {
+	query := s.GetDB().Model(&models.User{}).Preload("Roles")
+
+	if active, ok := opts.Filters["is_active"].(bool); ok {
+		query = query.Where("is_active = ?", active)
+	}
+	if email, ok := opts.Filters["email"].(string); ok {
+		query = query.Where("LOWER(email) LIKE ? ESCAPE '\\'", escapeLike(normalizeIdentifier(email))+"%")
+	}
+	if username, ok := opts.Filters["username"].(string); ok {
+		query = query.Where("LOWER(username) LIKE ? ESCAPE '\\'", escapeLike(normalizeIdentifier(username))+"%")
+	}
+	if after, ok := opts.Filters["created_after"].(time.Time); ok {
+		query = query.Where("created_at >= ?", after)
+	}
+	if before, ok := opts.Filters["created_before"].(time.Time); ok {
+		query = query.Where("created_at < ?", before)
+	}
+
+	page, users, err := services.Paginate(query, opts, opts.Sort, func(user *models.User) (interface{}, uint) {
+		switch opts.Sort {
+		case "username":
+			return user.Username, user.ID
+		case "email":
+			return user.Email, user.ID
+		case "id":
+			return user.ID, user.ID
+		default:
+			return user.CreatedAt, user.ID
+		}
+	})
+	if errors.Is(err, services.ErrInvalidCursor) {
+		return nil, apperrors.New(apperrors.ErrCodeInvalidInput, "Invalid cursor", "The cursor is malformed or does not match the sort order")
+	}
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to list users", err.Error())
+	}
+
+	items := make([]*models.UserResponse, 0, len(users))
+	for i := range users {
+		items = append(items, s.toUserResponse(&users[i]))
+	}
+	page.Items = items
+	return page, nil
+}
+
+// escapeLike escapes the LIKE wildcards in a user supplied prefix. Queries
+// name the escape character explicitly, SQLite has no default one.
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
@@ -0,0 +1,127 @@
+package user
+
+import (
+	"fmt"
+	"testing"
+
+	"kube/internal/testutil"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+)
+
+// listAll follows the cursors of ListUsers and returns the usernames in
+// page order
+func listAll(t *testing.T, s *Service, opts models.ListOptions) []string {
+	t.Helper()
+	var usernames []string
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("pagination does not end")
+		}
+		page, err := s.ListUsers(&opts)
+		if err != nil {
+			t.Fatalf("ListUsers: %v", err)
+		}
+		for _, user := range page.Items.([]*models.UserResponse) {
+			usernames = append(usernames, user.Username)
+		}
+		if !page.HasMore {
+			if page.NextCursor != "" {
+				t.Error("last page has a cursor")
+			}
+			return usernames
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+func TestListUsersPaginates(t *testing.T) {
+	s := newTestService(t)
+	for _, name := range []string{"dave", "alice", "erin", "carol", "bob"} {
+		registerUser(t, s, name, name+"@example.com", "correct horse battery")
+	}
+
+	for _, tt := range []struct {
+		name string
+		opts models.ListOptions
+		want string
+	}{
+		{"by username", models.ListOptions{Limit: 2, Sort: "username"}, "[alice bob carol dave erin]"},
+		{"by username descending", models.ListOptions{Limit: 2, Sort: "username", Desc: true}, "[erin dave carol bob alice]"},
+		{"newest first", models.ListOptions{Limit: 2, Sort: "created_at", Desc: true}, "[bob carol erin alice dave]"},
+		{"by ID", models.ListOptions{Limit: 3, Sort: "id"}, "[dave alice erin carol bob]"},
+		{"one page", models.ListOptions{Limit: 5, Sort: "email"}, "[alice bob carol dave erin]"},
+	} {
+		if got := fmt.Sprint(listAll(t, s, tt.opts)); got != tt.want {
+			t.Errorf("%s: %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestListUsersFilters(t *testing.T) {
+	s := newTestService(t)
+	for _, name := range []string{"jane", "janet", "jan_e", "bob"} {
+		registerUser(t, s, name, name+"@example.com", "correct horse battery")
+	}
+	if err := s.GetDB().Model(&models.User{}).Where("username = ?", "janet").Update("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range []struct {
+		name    string
+		filters map[string]interface{}
+		want    string
+	}{
+		{"username prefix", map[string]interface{}{"username": "JAN"}, "[jan_e jane janet]"},
+		{"wildcards are literal", map[string]interface{}{"username": "jan_"}, "[jan_e]"},
+		{"email prefix", map[string]interface{}{"email": "bob@"}, "[bob]"},
+		{"inactive", map[string]interface{}{"is_active": false}, "[janet]"},
+		{"active with prefix", map[string]interface{}{"is_active": true, "username": "jan"}, "[jan_e jane]"},
+	} {
+		got := listAll(t, s, models.ListOptions{Limit: 10, Sort: "username", Filters: tt.filters})
+		if fmt.Sprint(got) != tt.want {
+			t.Errorf("%s: %v, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestListUsersRejectsForeignCursors(t *testing.T) {
+	s := newTestService(t)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		registerUser(t, s, name, name+"@example.com", "correct horse battery")
+	}
+
+	page, err := s.ListUsers(&models.ListOptions{Limit: 1, Sort: "username"})
+	if err != nil || page.NextCursor == "" {
+		t.Fatalf("ListUsers = (%+v, %v), want a next page", page, err)
+	}
+
+	_, err = s.ListUsers(&models.ListOptions{Limit: 1, Sort: "email", Cursor: page.NextCursor})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeInvalidInput)
+	_, err = s.ListUsers(&models.ListOptions{Limit: 1, Sort: "username", Desc: true, Cursor: page.NextCursor})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeInvalidInput)
+	_, err = s.ListUsers(&models.ListOptions{Limit: 1, Sort: "username", Cursor: "not-a-cursor"})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeInvalidInput)
+}
+
+func TestListUsersRouteValidatesQuery(t *testing.T) {
+	s := newTestService(t)
+	router := newTestRouter(s)
+	admin := registerUser(t, s, "alice", "alice@example.com", "correct horse battery")
+	makeAdmin(t, s, admin.ID)
+	auth := bearer(passwordLogin(t, s, "alice", "correct horse battery"))
+
+	for query, want := range map[string]int{
+		"?limit=10&sort=-username&is_active=true": 200,
+		"?limit=0":                 400,
+		"?limit=101":               400,
+		"?sort=password":           400,
+		"?is_active=maybe":         400,
+		"?created_after=yesterday": 400,
+		"?cursor=not-a-cursor":     400,
+	} {
+		if got := performRequest(router, "GET", "/api/v1/users"+query, "", auth).StatusCode(); got != want {
+			t.Errorf("%s: status %d, want %d", query, got, want)
+		}
+	}
+}
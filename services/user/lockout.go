@@ -0,0 +1,148 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// UnlockUser clears the failed login state of an account
+func (s *Service) UnlockUser(id uint) error {
+	result := s.GetDB().Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"failed_logins": 0,
+		"lockout_count": 0,
+		"locked_until":  nil,
+	})
+	if result.Error != nil {
+		return apperrors.Wrap(result.Error, apperrors.ErrCodeDatabaseError, "Failed to unlock user", result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.New(apperrors.ErrCodeUserNotFound, "User not found", "User does not exist")
+	}
+	return nil
+}
+
+// checkClientThrottle rejects logins from a client IP with too many recent
+// failures, regardless of the account being targeted
+func (s *Service) checkClientThrottle(ip string) error {
+	if s.authCfg.IPFailureThreshold <= 0 || ip == "" {
+		return nil
+	}
+
+	failures, resetIn, err := s.attempts.Failures(context.Background(), ipAttemptKey(ip))
+	if err != nil {
+		hlog.Warnf("Failed to read login failures for %s: %v", ip, err)
+		return nil
+	}
+
+	if failures >= s.authCfg.IPFailureThreshold {
+		return apperrors.New(apperrors.ErrCodeRateLimitExceeded, "Too many failed logins", "Too many failed login attempts from this address").
+			AddMetadata("retry_after", int(resetIn.Seconds())+1)
+	}
+	return nil
+}
+
+// recordClientFailure counts a failed login against the client IP and
+// records it in the audit log, without counting it against the account
+func (s *Service) recordClientFailure(userID uint, meta RequestMeta) {
+	s.logAudit(newAuditEvent(models.AuditActionLoginFailure, meta, userID, nil))
+
+	ip := meta.IP
+	if ip != "" {
+		window := time.Duration(s.authCfg.IPFailureWindow) * time.Second
+		if _, err := s.attempts.RecordFailure(context.Background(), ipAttemptKey(ip), window); err != nil {
+			hlog.Warnf("Failed to record login failure for %s: %v", ip, err)
+		}
+	}
+}
+
+// recordLoginFailure counts a failed login like recordClientFailure and,
+// when userID is set, against the account. It returns an
+// ErrCodeAccountLocked error if this failure locked the account.
+func (s *Service) recordLoginFailure(userID uint, meta RequestMeta) error {
+	s.recordClientFailure(userID, meta)
+
+	if userID == 0 || s.authCfg.LockoutThreshold <= 0 {
+		return nil
+	}
+
+	var lockErr error
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{"failed_logins": user.FailedLogins + 1}
+		if user.FailedLogins+1 >= s.authCfg.LockoutThreshold {
+			lockedUntil := time.Now().Add(s.lockoutDuration(user.LockoutCount))
+			updates = map[string]interface{}{
+				"failed_logins": 0,
+				"lockout_count": user.LockoutCount + 1,
+				"locked_until":  lockedUntil,
+			}
+			lockErr = accountLockedError(lockedUntil)
+		}
+
+		return tx.Model(&user).Updates(updates).Error
+	})
+
+	if err != nil {
+		hlog.Errorf("Failed to record login failure for user %d: %v", userID, err)
+	}
+	return lockErr
+}
+
+// resetLoginFailures clears the failed login state after a successful login
+func resetLoginFailures(tx *gorm.DB, user *models.User) error {
+	if user.FailedLogins == 0 && user.LockoutCount == 0 && user.LockedUntil == nil {
+		return nil
+	}
+
+	if err := tx.Model(user).Updates(map[string]interface{}{
+		"failed_logins": 0,
+		"lockout_count": 0,
+		"locked_until":  nil,
+	}).Error; err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to reset login failures", err.Error())
+	}
+	return nil
+}
+
+// lockoutDuration doubles the base duration for every previous consecutive
+// lockout, up to the configured maximum
+func (s *Service) lockoutDuration(previousLockouts int) time.Duration {
+	duration := time.Duration(s.authCfg.LockoutBaseDuration) * time.Second
+	maxDuration := time.Duration(s.authCfg.LockoutMaxDuration) * time.Second
+
+	for i := 0; i < previousLockouts && duration < maxDuration; i++ {
+		duration *= 2
+	}
+	if duration > maxDuration {
+		duration = maxDuration
+	}
+	return duration
+}
+
+func checkAccountLock(user *models.User) error {
+	if user.LockedUntil != nil && time.Now().Before(*user.LockedUntil) {
+		return accountLockedError(*user.LockedUntil)
+	}
+	return nil
+}
+
+func accountLockedError(lockedUntil time.Time) error {
+	return apperrors.New(apperrors.ErrCodeAccountLocked, "Account locked", "Too many failed login attempts, try again later").
+		AddMetadata("retry_after", int(time.Until(lockedUntil).Seconds())+1).
+		AddMetadata("locked_until", lockedUntil.UTC().Format(time.RFC3339))
+}
+
+func ipAttemptKey(ip string) string {
+	return "login:ip:" + ip
+}
@@ -0,0 +1,115 @@
+package user
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"kube/internal/config"
+	"kube/internal/testutil"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+)
+
+func withLockout(threshold, ipThreshold int) func(*config.Config) {
+	return func(cfg *config.Config) {
+		cfg.Auth.LockoutThreshold = threshold
+		cfg.Auth.LockoutBaseDuration = 60
+		cfg.Auth.LockoutMaxDuration = 300
+		cfg.Auth.IPFailureThreshold = ipThreshold
+		cfg.Auth.IPFailureWindow = 900
+	}
+}
+
+func login(s *Service, identifier, password, ip string) error {
+	_, err := s.Login(&models.UserLoginRequest{Identifier: identifier, Password: password}, RequestMeta{IP: ip})
+	return err
+}
+
+func TestLoginLocksAccountAfterRepeatedFailures(t *testing.T) {
+	s := newTestService(t, withLockout(3, 0))
+	jane := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+
+	// A successful login resets the count
+	for i := 0; i < 2; i++ {
+		testutil.AssertErrorCode(t, login(s, "jane", "wrong password", ""), apperrors.ErrCodeInvalidCredentials)
+	}
+	passwordLogin(t, s, "jane", "correct horse battery")
+
+	for i := 0; i < 3; i++ {
+		testutil.AssertErrorCode(t, login(s, "jane", "wrong password", ""), apperrors.ErrCodeInvalidCredentials)
+	}
+
+	err := login(s, "jane", "correct horse battery", "")
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeAccountLocked)
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) {
+		if retryAfter, _ := appErr.Metadata["retry_after"].(int); retryAfter < 59 || retryAfter > 61 {
+			t.Errorf("retry_after = %v, want about 60 seconds", appErr.Metadata["retry_after"])
+		}
+	}
+
+	// Without the password the lock is not revealed
+	testutil.AssertErrorCode(t, login(s, "jane", "wrong password", ""), apperrors.ErrCodeInvalidCredentials)
+
+	if err := s.UnlockUser(jane.ID); err != nil {
+		t.Fatalf("UnlockUser: %v", err)
+	}
+	passwordLogin(t, s, "jane", "correct horse battery")
+}
+
+func TestLockoutDurationDoublesUpToTheMaximum(t *testing.T) {
+	s := newTestService(t, withLockout(3, 0))
+
+	for previous, want := range []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute} {
+		if got := s.lockoutDuration(previous); got != want {
+			t.Errorf("lockoutDuration(%d) = %v, want %v", previous, got, want)
+		}
+	}
+}
+
+func TestLoginThrottlesClientIP(t *testing.T) {
+	s := newTestService(t, withLockout(0, 3))
+	registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+
+	// Unknown accounts count as well
+	testutil.AssertErrorCode(t, login(s, "nobody", "wrong password", "10.0.0.1"), apperrors.ErrCodeInvalidCredentials)
+	for i := 0; i < 2; i++ {
+		testutil.AssertErrorCode(t, login(s, "jane", "wrong password", "10.0.0.1"), apperrors.ErrCodeInvalidCredentials)
+	}
+
+	testutil.AssertErrorCode(t, login(s, "jane", "correct horse battery", "10.0.0.1"), apperrors.ErrCodeRateLimitExceeded)
+	if err := login(s, "jane", "correct horse battery", "10.0.0.2"); err != nil {
+		t.Errorf("login from another address: %v", err)
+	}
+}
+
+func TestConsecutiveLockoutsLastLonger(t *testing.T) {
+	s := newTestService(t, withLockout(2, 0))
+	jane := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+
+	lockedFor := func() time.Duration {
+		t.Helper()
+		for i := 0; i < 2; i++ {
+			login(s, "jane", "wrong password", "")
+		}
+		var user models.User
+		if err := s.GetDB().First(&user, jane.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if user.LockedUntil == nil {
+			t.Fatal("account not locked")
+		}
+		lockedFor := time.Until(*user.LockedUntil).Round(time.Minute)
+
+		// Let the lock run out without a successful login
+		if err := s.GetDB().Model(&user).Update("locked_until", time.Now().Add(-time.Second)).Error; err != nil {
+			t.Fatal(err)
+		}
+		return lockedFor
+	}
+
+	if first, second := lockedFor(), lockedFor(); first != time.Minute || second != 2*time.Minute {
+		t.Errorf("locked for %v, then %v, want 1m0s, then 2m0s", first, second)
+	}
+}
@@ -0,0 +1,365 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"strconv"
+	"strings"
+	"time"
+
+	"kube/internal/auth"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	totpSkew             = 1 // accept codes from the previous and next period
+	recoveryCodeCount    = 10
+	recoveryCodeBytes    = 5 // 8 base32 characters
+	mfaChallengeAudience = "mfa-challenge"
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// EnrollTOTP starts TOTP enrollment by generating a new secret. MFA is only
+// enabled once a code generated from the secret is confirmed.
+func (s *Service) EnrollTOTP(userID uint) (*models.MFAEnrollmentResponse, error) {
+	var user models.User
+	if err := s.GetDB().First(&user, userID).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeUserNotFound, "User not found", "User does not exist")
+	}
+
+	if user.MFAEnabled {
+		return nil, apperrors.New(apperrors.ErrCodeInvalidOperation, "MFA already enabled", "Disable two-factor authentication before enrolling again")
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeInternalError, "Failed to generate secret", err.Error())
+	}
+
+	if err := s.GetDB().Model(&user).Update("mfa_secret", secret).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to start enrollment", err.Error())
+	}
+
+	totp, err := newUserTOTP(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	uri := totp.URI(s.authCfg.MFAIssuer, user.Email)
+	return &models.MFAEnrollmentResponse{
+		Secret:     secret,
+		OTPAuthURI: uri,
+		QRPayload:  uri,
+	}, nil
+}
+
+// ConfirmTOTP enables MFA after checking a code from the pending secret and
+// returns a fresh set of recovery codes
+func (s *Service) ConfirmTOTP(userID uint, code string) (*models.RecoveryCodesResponse, error) {
+	var codes []string
+
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeUserNotFound, "User not found", "User does not exist")
+		}
+
+		if user.MFAEnabled {
+			return apperrors.New(apperrors.ErrCodeInvalidOperation, "MFA already enabled", "Two-factor authentication is already enabled")
+		}
+		if user.MFASecret == "" {
+			return apperrors.New(apperrors.ErrCodeInvalidOperation, "No pending enrollment", "Start TOTP enrollment first")
+		}
+
+		if err := verifyTOTPCode(tx, &user, code); err != nil {
+			return err
+		}
+
+		if err := tx.Model(&user).Update("mfa_enabled", true).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to enable MFA", err.Error())
+		}
+
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// DisableTOTP turns MFA off after checking a TOTP or recovery code
+func (s *Service) DisableTOTP(userID uint, code string) error {
+	return s.WithTransaction(func(tx *gorm.DB) error {
+		user, err := lockMFAUser(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := verifySecondFactor(tx, user, code); err != nil {
+			return err
+		}
+
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"mfa_enabled":   false,
+			"mfa_secret":    "",
+			"mfa_last_step": 0,
+		}).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to disable MFA", err.Error())
+		}
+
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to delete recovery codes", err.Error())
+		}
+		return nil
+	})
+}
+
+// RegenerateRecoveryCodes replaces every recovery code after checking a TOTP
+// code
+func (s *Service) RegenerateRecoveryCodes(userID uint, code string) (*models.RecoveryCodesResponse, error) {
+	var codes []string
+
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		user, err := lockMFAUser(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		if err := verifyTOTPCode(tx, user, code); err != nil {
+			return err
+		}
+
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// CompleteMFALogin exchanges an MFA challenge token and a TOTP or recovery
+// code for an access/refresh token pair. Each challenge can be used once: it
+// is consumed atomically in the revocation store once the code is verified,
+// before any tokens are issued.
+func (s *Service) CompleteMFALogin(req *models.MFALoginRequest, meta RequestMeta) (*models.LoginResponse, error) {
+	if err := s.checkClientThrottle(meta.IP); err != nil {
+		return nil, err
+	}
+
+	challenge, err := s.parseMFAChallenge(req.MFAToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rejects used challenges before the code is checked; consuming the
+	// challenge below is what makes it single-use
+	revoked, err := s.revocations.IsRevoked(context.Background(), challenge.ID)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeServiceUnavailable, "Failed to check challenge", err.Error())
+	}
+	if revoked {
+		return nil, apperrors.New(apperrors.ErrCodeTokenInvalid, "Invalid MFA token", "MFA token has already been used")
+	}
+
+	userID, err := strconv.ParseUint(challenge.Subject, 10, 64)
+	if err != nil {
+		return nil, apperrors.New(apperrors.ErrCodeTokenInvalid, "Invalid MFA token", "MFA token is malformed")
+	}
+
+	var response *models.LoginResponse
+	err = s.WithTransaction(func(tx *gorm.DB) error {
+		user, err := lockMFAUser(tx, uint(userID))
+		if err != nil {
+			return err
+		}
+
+		if !user.IsActive {
+			return apperrors.New(apperrors.ErrCodeAccountDeactivated, "Account deactivated", "Your account has been deactivated")
+		}
+
+		if err := checkAccountLock(user); err != nil {
+			return err
+		}
+
+		if err := verifySecondFactor(tx, user, req.Code); err != nil {
+			return err
+		}
+
+		// A wrong code does not use the challenge up, a correct one does.
+		// Should the transaction fail after this, the user logs in again.
+		consumed, err := s.revocations.RevokeIfAbsent(context.Background(), challenge.ID, challenge.ExpiresAt.Time)
+		if err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeServiceUnavailable, "Failed to consume challenge", err.Error())
+		}
+		if !consumed {
+			return apperrors.New(apperrors.ErrCodeTokenInvalid, "Invalid MFA token", "MFA token has already been used")
+		}
+
+		if err := resetLoginFailures(tx, user); err != nil {
+			return err
+		}
+
+		tokens, err := s.startSession(tx, user, meta)
+		if err != nil {
+			return err
+		}
+
+		response = &models.LoginResponse{
+			User:          s.toUserResponse(user),
+			TokenResponse: tokens,
+		}
+		return nil
+	})
+
+	if err != nil {
+		if appErr := apperrors.GetAppError(err); appErr != nil && appErr.Code == apperrors.ErrCodeInvalidCredentials {
+			if lockErr := s.recordLoginFailure(uint(userID), meta); lockErr != nil {
+				return nil, lockErr
+			}
+		}
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// issueMFAChallenge signs a short-lived token proving that the password step
+// succeeded. It is signed with the access token signer, so it is as hard to
+// forge as an access token; the audience keeps it from being accepted as one.
+func (s *Service) issueMFAChallenge(user *models.User) (string, error) {
+	now := time.Now()
+	return s.signer.Sign(jwt.RegisteredClaims{
+		ID:        uuid.New().String(),
+		Subject:   strconv.FormatUint(uint64(user.ID), 10),
+		Audience:  jwt.ClaimStrings{mfaChallengeAudience},
+		IssuedAt:  jwt.NewNumericDate(now),
+		ExpiresAt: jwt.NewNumericDate(now.Add(time.Duration(s.authCfg.MFAChallengeExpiresIn) * time.Minute)),
+	})
+}
+
+func (s *Service) parseMFAChallenge(tokenString string) (*jwt.RegisteredClaims, error) {
+	claims := &jwt.RegisteredClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, s.signer.Keyfunc,
+		jwt.WithValidMethods(s.signer.Algorithms()), jwt.WithAudience(mfaChallengeAudience))
+
+	if err != nil || !token.Valid || claims.ExpiresAt == nil {
+		return nil, apperrors.New(apperrors.ErrCodeTokenInvalid, "Invalid MFA token", "MFA token is invalid or expired")
+	}
+	return claims, nil
+}
+
+// verifySecondFactor accepts either a TOTP code or an unused recovery code
+func verifySecondFactor(tx *gorm.DB, user *models.User, code string) error {
+	code = strings.TrimSpace(code)
+	if len(code) == auth.TOTPDefaultDigits {
+		if _, err := strconv.Atoi(code); err == nil {
+			return verifyTOTPCode(tx, user, code)
+		}
+	}
+	return consumeRecoveryCode(tx, user.ID, code)
+}
+
+// verifyTOTPCode checks a code against the user's secret and records the
+// accepted step so the same code cannot be used twice
+func verifyTOTPCode(tx *gorm.DB, user *models.User, code string) error {
+	totp, err := newUserTOTP(user.MFASecret)
+	if err != nil {
+		return err
+	}
+
+	step, ok := totp.ValidateAfter(strings.TrimSpace(code), time.Now(), totpSkew, user.MFALastStep)
+	if !ok {
+		return apperrors.New(apperrors.ErrCodeInvalidCredentials, "Invalid code", "The authentication code is incorrect")
+	}
+
+	if err := tx.Model(user).Update("mfa_last_step", step).Error; err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to record code usage", err.Error())
+	}
+	return nil
+}
+
+func consumeRecoveryCode(tx *gorm.DB, userID uint, code string) error {
+	result := tx.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return apperrors.Wrap(result.Error, apperrors.ErrCodeDatabaseError, "Failed to use recovery code", result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.New(apperrors.ErrCodeInvalidCredentials, "Invalid code", "The authentication code is incorrect")
+	}
+	return nil
+}
+
+// replaceRecoveryCodes deletes the user's recovery codes and returns a new
+// set in plain text. Only hashes are stored.
+func replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to delete recovery codes", err.Error())
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, recoveryCodeBytes)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, apperrors.Wrap(err, apperrors.ErrCodeInternalError, "Failed to generate recovery codes", err.Error())
+		}
+
+		encoded := strings.ToLower(recoveryCodeEncoding.EncodeToString(buf))
+		code := encoded[:4] + "-" + encoded[4:]
+		codes = append(codes, code)
+		records = append(records, models.RecoveryCode{
+			UserID:    userID,
+			CodeHash:  hashRecoveryCode(code),
+			CreatedAt: time.Now(),
+		})
+	}
+
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to store recovery codes", err.Error())
+	}
+	return codes, nil
+}
+
+// hashRecoveryCode ignores case and separators so that codes can be typed
+// loosely
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return hashToken(normalized)
+}
+
+func lockMFAUser(tx *gorm.DB, userID uint) (*models.User, error) {
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeUserNotFound, "User not found", "User does not exist")
+	}
+
+	if !user.MFAEnabled {
+		return nil, apperrors.New(apperrors.ErrCodeInvalidOperation, "MFA not enabled", "Two-factor authentication is not enabled")
+	}
+	return &user, nil
+}
+
+func newUserTOTP(secret string) (*auth.TOTP, error) {
+	key, err := auth.DecodeTOTPSecret(secret)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeInternalError, "Invalid MFA secret", err.Error())
+	}
+	return auth.NewTOTP(key), nil
+}
@@ -0,0 +1,163 @@
+package user
+
+import (
+	"kube/pkg/errors"
+	"kube/pkg/models"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// CompleteMFALogin godoc
+// @Summary Complete login with MFA
+// @Description Exchange the MFA token returned by login and a TOTP or recovery code for access and refresh tokens
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Param request body models.MFALoginRequest true "MFA token and code"
+// @Success 200 {object} map[string]interface{} "Login successful"
+// @Failure 400 {object} map[string]interface{} "Invalid request data"
+// @Failure 401 {object} map[string]interface{} "Invalid MFA token or code"
+// @Failure 423 {object} map[string]interface{} "Account temporarily locked, see Retry-After"
+// @Router /api/v1/users/login/mfa [post]
+func (h *Handler) CompleteMFALogin(c *app.RequestContext) {
+	var req models.MFALoginRequest
+	if err := c.BindJSON(&req); err != nil {
+		h.SendValidationError(c, "Invalid request data format")
+		return
+	}
+
+	response, err := h.service.CompleteMFALogin(&req, requestMeta(c))
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, response, "Login successful")
+}
+
+// EnrollTOTP godoc
+// @Summary Start TOTP enrollment
+// @Description Generate a TOTP secret and otpauth URI for an authenticator app. MFA is enabled after confirming a code.
+// @Tags mfa
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Enrollment started"
+// @Failure 400 {object} map[string]interface{} "MFA already enabled"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Router /api/v1/users/me/mfa/totp [post]
+func (h *Handler) EnrollTOTP(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	enrollment, err := h.service.EnrollTOTP(userID)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, enrollment, "Enrollment started")
+}
+
+// ConfirmTOTP godoc
+// @Summary Confirm TOTP enrollment
+// @Description Enable MFA by confirming a code from the authenticator app. Returns recovery codes that are shown only once.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.MFACodeRequest true "TOTP code"
+// @Success 200 {object} map[string]interface{} "Two-factor authentication enabled"
+// @Failure 400 {object} map[string]interface{} "Invalid request data or no pending enrollment"
+// @Failure 401 {object} map[string]interface{} "Invalid code"
+// @Router /api/v1/users/me/mfa/totp/confirm [post]
+func (h *Handler) ConfirmTOTP(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	var req models.MFACodeRequest
+	if err := c.BindJSON(&req); err != nil {
+		h.SendValidationError(c, "Invalid request data format")
+		return
+	}
+
+	codes, err := h.service.ConfirmTOTP(userID, req.Code)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, codes, "Two-factor authentication enabled")
+}
+
+// DisableTOTP godoc
+// @Summary Disable TOTP
+// @Description Turn off MFA using a TOTP or recovery code
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.MFACodeRequest true "TOTP or recovery code"
+// @Success 200 {object} map[string]interface{} "Two-factor authentication disabled"
+// @Failure 400 {object} map[string]interface{} "Invalid request data or MFA not enabled"
+// @Failure 401 {object} map[string]interface{} "Invalid code"
+// @Router /api/v1/users/me/mfa/totp [delete]
+func (h *Handler) DisableTOTP(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	var req models.MFACodeRequest
+	if err := c.BindJSON(&req); err != nil {
+		h.SendValidationError(c, "Invalid request data format")
+		return
+	}
+
+	if err := h.service.DisableTOTP(userID, req.Code); err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, nil, "Two-factor authentication disabled")
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary Regenerate recovery codes
+// @Description Replace all recovery codes after confirming a TOTP code. The new codes are shown only once.
+// @Tags mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body models.MFACodeRequest true "TOTP code"
+// @Success 200 {object} map[string]interface{} "Recovery codes regenerated"
+// @Failure 400 {object} map[string]interface{} "Invalid request data or MFA not enabled"
+// @Failure 401 {object} map[string]interface{} "Invalid code"
+// @Router /api/v1/users/me/mfa/recovery-codes [post]
+func (h *Handler) RegenerateRecoveryCodes(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	var req models.MFACodeRequest
+	if err := c.BindJSON(&req); err != nil {
+		h.SendValidationError(c, "Invalid request data format")
+		return
+	}
+
+	codes, err := h.service.RegenerateRecoveryCodes(userID, req.Code)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, codes, "Recovery codes regenerated")
+}
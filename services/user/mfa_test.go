@@ -0,0 +1,127 @@
+package user
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"kube/internal/auth"
+	"kube/internal/testutil"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+)
+
+// enableMFA turns on TOTP for the user and returns the generator and the
+// recovery codes
+func enableMFA(t *testing.T, s *Service, userID uint) (*auth.TOTP, []string) {
+	t.Helper()
+
+	enrollment, err := s.EnrollTOTP(userID)
+	if err != nil {
+		t.Fatalf("EnrollTOTP: %v", err)
+	}
+	secret, err := auth.DecodeTOTPSecret(enrollment.Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	totp := auth.NewTOTP(secret)
+	codes, err := s.ConfirmTOTP(userID, totp.Code(time.Now()))
+	if err != nil {
+		t.Fatalf("ConfirmTOTP: %v", err)
+	}
+	return totp, codes.RecoveryCodes
+}
+
+// mfaChallenge logs in with the password and returns the MFA challenge
+func mfaChallenge(t *testing.T, s *Service, identifier, password string) string {
+	t.Helper()
+	resp, err := s.Login(&models.UserLoginRequest{Identifier: identifier, Password: password}, RequestMeta{})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	if !resp.MFARequired || resp.MFAToken == "" || resp.TokenResponse != nil {
+		t.Fatalf("Login did not ask for the second factor: %+v", resp)
+	}
+	return resp.MFAToken
+}
+
+func TestCompleteMFALogin(t *testing.T) {
+	s := newTestService(t)
+	user := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	_, recoveryCodes := enableMFA(t, s, user.ID)
+
+	challenge := mfaChallenge(t, s, "jane", "correct horse battery")
+
+	// A wrong code does not use the challenge up
+	_, err := s.CompleteMFALogin(&models.MFALoginRequest{MFAToken: challenge, Code: "000000"}, RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeInvalidCredentials)
+
+	resp, err := s.CompleteMFALogin(&models.MFALoginRequest{MFAToken: challenge, Code: recoveryCodes[0]}, RequestMeta{})
+	if err != nil {
+		t.Fatalf("CompleteMFALogin: %v", err)
+	}
+	if resp.TokenResponse == nil || resp.User.ID != user.ID {
+		t.Fatalf("unexpected response %+v", resp)
+	}
+
+	_, err = s.CompleteMFALogin(&models.MFALoginRequest{MFAToken: challenge, Code: recoveryCodes[1]}, RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeTokenInvalid)
+
+	// The recovery code is spent as well
+	_, err = s.CompleteMFALogin(&models.MFALoginRequest{MFAToken: mfaChallenge(t, s, "jane", "correct horse battery"), Code: recoveryCodes[0]}, RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeInvalidCredentials)
+}
+
+func TestCompleteMFALoginChallengeIsSingleUseUnderConcurrency(t *testing.T) {
+	s := newTestService(t)
+	user := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	_, recoveryCodes := enableMFA(t, s, user.ID)
+	challenge := mfaChallenge(t, s, "jane", "correct horse battery")
+
+	// Different recovery codes, so only the challenge is shared
+	const attempts = 4
+	var (
+		wg        sync.WaitGroup
+		start     = make(chan struct{})
+		errs      = make([]error, attempts)
+		successes = 0
+	)
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			_, errs[i] = s.CompleteMFALogin(&models.MFALoginRequest{MFAToken: challenge, Code: recoveryCodes[i]}, RequestMeta{})
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	for _, err := range errs {
+		if err == nil {
+			successes++
+			continue
+		}
+		testutil.AssertErrorCode(t, err, apperrors.ErrCodeTokenInvalid)
+	}
+	if successes != 1 {
+		t.Errorf("%d logins completed with one challenge, want 1", successes)
+	}
+	if n := testutil.CountRows(t, s.GetDB(), &models.Session{}, "user_id = ?", user.ID); n != 1 {
+		t.Errorf("%d sessions started, want 1", n)
+	}
+}
+
+func TestCompleteMFALoginRejectsReusedTOTPStep(t *testing.T) {
+	s := newTestService(t)
+	user := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	totp, _ := enableMFA(t, s, user.ID)
+
+	// ConfirmTOTP used the current step already
+	_, err := s.CompleteMFALogin(&models.MFALoginRequest{MFAToken: mfaChallenge(t, s, "jane", "correct horse battery"), Code: totp.Code(time.Now())}, RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeInvalidCredentials)
+
+	if _, err := s.CompleteMFALogin(&models.MFALoginRequest{MFAToken: mfaChallenge(t, s, "jane", "correct horse battery"), Code: totp.Code(time.Now().Add(30 * time.Second))}, RequestMeta{}); err != nil {
+		t.Errorf("code of the next step rejected: %v", err)
+	}
+}
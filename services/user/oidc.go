@@ -0,0 +1,320 @@
+package user
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"kube/internal/auth"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// oidcLoginState is kept between the redirect to the provider and the
+// callback. It never leaves the server; only the random state key does.
+type oidcLoginState struct {
+	Provider     string `json:"provider"`
+	CodeVerifier string `json:"code_verifier"`
+	Nonce        string `json:"nonce"`
+}
+
+// StartOIDCLogin begins an authorization code flow with PKCE and returns the
+// provider URL the user agent must be redirected to
+func (s *Service) StartOIDCLogin(providerName string) (string, error) {
+	provider, err := s.oidcProvider(providerName)
+	if err != nil {
+		return "", err
+	}
+
+	state, err := generateOpaqueToken(32)
+	if err != nil {
+		return "", apperrors.Wrap(err, apperrors.ErrCodeInternalError, "Failed to start login", err.Error())
+	}
+	nonce, err := generateOpaqueToken(32)
+	if err != nil {
+		return "", apperrors.Wrap(err, apperrors.ErrCodeInternalError, "Failed to start login", err.Error())
+	}
+	verifier, challenge, err := auth.GeneratePKCE()
+	if err != nil {
+		return "", apperrors.Wrap(err, apperrors.ErrCodeInternalError, "Failed to start login", err.Error())
+	}
+
+	data, err := json.Marshal(oidcLoginState{Provider: providerName, CodeVerifier: verifier, Nonce: nonce})
+	if err != nil {
+		return "", apperrors.Wrap(err, apperrors.ErrCodeInternalError, "Failed to start login", err.Error())
+	}
+
+	ctx := context.Background()
+	if err := s.oidcStates.Save(ctx, hashToken(state), data, s.oidcTTL); err != nil {
+		return "", apperrors.Wrap(err, apperrors.ErrCodeServiceUnavailable, "Failed to start login", err.Error())
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, state, nonce, challenge)
+	if err != nil {
+		return "", apperrors.Wrap(err, apperrors.ErrCodeExternalServiceError, "Identity provider unavailable", err.Error())
+	}
+	return authURL, nil
+}
+
+// CompleteOIDCLogin handles the provider callback. The user is found by the
+// linked identity, linked by verified email or created, and then logged in
+// like a password login.
+func (s *Service) CompleteOIDCLogin(providerName, code, state string, meta RequestMeta) (*models.LoginResponse, error) {
+	provider, err := s.oidcProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := context.Background()
+	data, ok, err := s.oidcStates.Consume(ctx, hashToken(state))
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeServiceUnavailable, "Failed to complete login", err.Error())
+	}
+
+	var loginState oidcLoginState
+	if !ok || json.Unmarshal(data, &loginState) != nil || loginState.Provider != providerName {
+		return nil, apperrors.New(apperrors.ErrCodeTokenInvalid, "Invalid login state", "The login request is unknown or has expired")
+	}
+
+	claims, err := provider.Exchange(ctx, code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeExternalServiceError, "Identity provider login failed", err.Error())
+	}
+
+	var user *models.User
+	err = s.WithTransaction(func(tx *gorm.DB) error {
+		var err error
+		user, err = s.resolveIdentity(tx, providerName, claims, meta)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsActive {
+		return nil, apperrors.New(apperrors.ErrCodeAccountDeactivated, "Account deactivated", "Your account has been deactivated")
+	}
+
+	return s.finishLogin(user, meta)
+}
+
+func (s *Service) oidcProvider(name string) (*auth.OIDCProvider, error) {
+	provider, ok := s.oidc[name]
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrCodeRecordNotFound, "Unknown identity provider", "Identity provider "+name+" is not configured")
+	}
+	return provider, nil
+}
+
+// resolveIdentity returns the user linked to the provider subject. Unknown
+// subjects are linked to the account with the same email, or a new account
+// is created, but only when the provider has verified the email.
+func (s *Service) resolveIdentity(tx *gorm.DB, provider string, claims *auth.OIDCClaims, meta RequestMeta) (*models.User, error) {
+	now := time.Now()
+
+	var identity models.Identity
+	err := tx.Where("provider = ? AND subject = ?", provider, claims.Subject).First(&identity).Error
+	if err == nil {
+		var user models.User
+		if err := tx.First(&user, identity.UserID).Error; err != nil {
+			return nil, apperrors.Wrap(err, apperrors.ErrCodeUserNotFound, "User not found", "The linked account no longer exists")
+		}
+
+		if err := tx.Model(&identity).Updates(map[string]interface{}{"email": claims.Email, "last_login_at": now}).Error; err != nil {
+			return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to update identity", err.Error())
+		}
+		return &user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to look up identity", err.Error())
+	}
+
+	if !claims.IsEmailVerified() {
+		return nil, apperrors.New(apperrors.ErrCodeEmailNotVerified, "Email not verified", "The identity provider has not verified your email address")
+	}
+
+	var user models.User
+	err = tx.Unscoped().Where("LOWER(email) = ?", normalizeIdentifier(claims.Email)).First(&user).Error
+	switch {
+	case err == nil && user.DeletedAt.Valid:
+		return nil, apperrors.New(apperrors.ErrCodeAccountDeactivated, "Account deleted", "The account with this email address has been deleted")
+	case err == nil:
+		if err := s.claimUnverifiedAccount(tx, &user); err != nil {
+			return nil, err
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		created, err := s.createOIDCUser(tx, claims, meta)
+		if err != nil {
+			return nil, err
+		}
+		user = *created
+	default:
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to look up user", err.Error())
+	}
+
+	identity = models.Identity{
+		UserID:      user.ID,
+		Provider:    provider,
+		Subject:     claims.Subject,
+		Email:       claims.Email,
+		LastLoginAt: &now,
+	}
+	if err := tx.Create(&identity).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to link identity", err.Error())
+	}
+
+	return &user, nil
+}
+
+// claimUnverifiedAccount prepares an existing account for linking. If its
+// email was never verified, whoever registered it did not prove ownership of
+// the address, so every credential they may hold is invalidated before the
+// verified owner takes the account over: the password, sessions and access
+// tokens, API keys, and the second factor with its recovery codes.
+func (s *Service) claimUnverifiedAccount(tx *gorm.DB, user *models.User) error {
+	if user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	password, err := s.unusablePasswordHash()
+	if err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeInternalError, "Password hashing failed", err.Error())
+	}
+
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	user.Password = password
+	user.MFAEnabled = false
+	user.MFASecret = ""
+	user.MFALastStep = 0
+	if err := tx.Model(user).Updates(map[string]interface{}{
+		"email_verified_at": now,
+		"password":          password,
+		"mfa_enabled":       false,
+		"mfa_secret":        "",
+		"mfa_last_step":     0,
+	}).Error; err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to link account", err.Error())
+	}
+
+	if err := tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to delete recovery codes", err.Error())
+	}
+	if err := tx.Where("user_id = ?", user.ID).Delete(&models.APIKey{}).Error; err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to delete API keys", err.Error())
+	}
+
+	if err := tx.Model(&models.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL", user.ID).
+		Update("revoked_at", now).Error; err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to revoke sessions", err.Error())
+	}
+	if err := tx.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", user.ID).
+		Update("revoked_at", now).Error; err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to revoke refresh tokens", err.Error())
+	}
+
+	// Access tokens are not stored, so they are cut off by issue time. This
+	// happens before the commit: a failure aborts the link rather than
+	// leaving the previous holder's tokens valid.
+	if err := s.revocations.RevokeUser(context.Background(), user.ID, now.Add(s.accessTTL)); err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeServiceUnavailable, "Failed to revoke tokens", err.Error())
+	}
+	return nil
+}
+
+// createOIDCUser provisions an account for a first-time provider login. The
+// account has no usable password until the user sets one via password reset.
+func (s *Service) createOIDCUser(tx *gorm.DB, claims *auth.OIDCClaims, meta RequestMeta) (*models.User, error) {
+	username, err := availableUsername(tx, claims.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := s.unusablePasswordHash()
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeInternalError, "Password hashing failed", err.Error())
+	}
+
+	now := time.Now()
+	user := &models.User{
+		Username:        username,
+		Email:           normalizeIdentifier(claims.Email),
+		Password:        password,
+		FirstName:       claims.GivenName,
+		LastName:        claims.FamilyName,
+		Avatar:          claims.Picture,
+		IsActive:        true,
+		EmailVerifiedAt: &now,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+
+	if err := tx.Create(user).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to create user", err.Error())
+	}
+
+	if err := assignDefaultRole(tx, user); err != nil {
+		return nil, err
+	}
+
+	event := newAuditEvent(models.AuditActionRegister, meta, user.ID, nil)
+	event.ActorID = optionalID(user.ID)
+	if err := appendAudit(tx, event); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// availableUsername derives a free username from the local part of an email
+func availableUsername(tx *gorm.DB, email string) (string, error) {
+	local, _, _ := strings.Cut(normalizeIdentifier(email), "@")
+
+	var b strings.Builder
+	for _, r := range local {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		}
+	}
+
+	base := b.String()
+	if len(base) > 20 {
+		base = base[:20]
+	}
+	if len(base) < minUsernameLength || isReservedUsername(base) {
+		base = "user"
+	}
+
+	candidate := base
+	for i := 0; i < 5; i++ {
+		var count int64
+		if err := tx.Model(&models.User{}).Unscoped().Where("LOWER(username) = ?", candidate).Count(&count).Error; err != nil {
+			return "", apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to create user", err.Error())
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+
+		suffix, err := generateOpaqueToken(3)
+		if err != nil {
+			return "", apperrors.Wrap(err, apperrors.ErrCodeInternalError, "Failed to create user", err.Error())
+		}
+		candidate = base + "_" + strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(suffix))
+	}
+
+	return "", apperrors.New(apperrors.ErrCodeUserAlreadyExists, "User already exists", "Could not find a free username")
+}
+
+// unusablePasswordHash returns a hash of a random secret nobody knows
+func (s *Service) unusablePasswordHash() (string, error) {
+	secret, err := generateOpaqueToken(32)
+	if err != nil {
+		return "", err
+	}
+	return s.passwords.Hash(secret)
+}
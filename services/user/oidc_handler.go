@@ -0,0 +1,61 @@
+package user
+
+import (
+	"kube/pkg/errors"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// StartOIDCLogin godoc
+// @Summary Start social login
+// @Description Redirect to the OpenID Connect provider to sign in. Uses the authorization code flow with PKCE.
+// @Tags oauth
+// @Param provider path string true "Provider name, as configured in OIDC_PROVIDERS"
+// @Success 302 "Redirect to the identity provider"
+// @Failure 404 {object} map[string]interface{} "Unknown provider"
+// @Failure 502 {object} map[string]interface{} "Identity provider unavailable"
+// @Router /api/v1/users/oauth/{provider}/authorize [get]
+func (h *Handler) StartOIDCLogin(c *app.RequestContext) {
+	authURL, err := h.service.StartOIDCLogin(c.Param("provider"))
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	c.Redirect(302, []byte(authURL))
+}
+
+// CompleteOIDCLogin godoc
+// @Summary Complete social login
+// @Description Callback from the OpenID Connect provider. Signs in the linked account, links an existing account with the same verified email or creates a new account. Accounts with MFA enabled receive an MFA token instead of tokens.
+// @Tags oauth
+// @Produce json
+// @Param provider path string true "Provider name"
+// @Param code query string true "Authorization code"
+// @Param state query string true "State from the authorization request"
+// @Success 200 {object} map[string]interface{} "Login successful"
+// @Failure 400 {object} map[string]interface{} "Missing code or state, or login denied at the provider"
+// @Failure 401 {object} map[string]interface{} "Unknown or expired login state"
+// @Failure 403 {object} map[string]interface{} "Email not verified by the provider or account deactivated"
+// @Failure 502 {object} map[string]interface{} "Identity provider login failed"
+// @Router /api/v1/users/oauth/{provider}/callback [get]
+func (h *Handler) CompleteOIDCLogin(c *app.RequestContext) {
+	if providerError := c.Query("error"); providerError != "" {
+		h.SendValidationError(c, "Login denied by the identity provider: "+providerError)
+		return
+	}
+
+	code, state := c.Query("code"), c.Query("state")
+	if code == "" || state == "" {
+		h.SendValidationError(c, "Authorization code and state are required")
+		return
+	}
+
+	response, err := h.service.CompleteOIDCLogin(c.Param("provider"), code, state, requestMeta(c))
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, response, "Login successful")
+}
@@ -0,0 +1,249 @@
+package user
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"kube/internal/auth"
+	"kube/internal/auth/oidctest"
+	"kube/internal/config"
+	"kube/internal/testutil"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+)
+
+const testProvider = "stub"
+
+// withProvider configures stub as the provider named testProvider
+func withProvider(stub *oidctest.Provider) func(*config.Config) {
+	return func(cfg *config.Config) {
+		cfg.OIDC.Providers = append(cfg.OIDC.Providers, config.OIDCProviderConfig{
+			Name:         testProvider,
+			IssuerURL:    stub.Issuer(),
+			ClientID:     stub.ClientID,
+			ClientSecret: stub.ClientSecret,
+		})
+	}
+}
+
+func newTestProvider(t *testing.T) *oidctest.Provider {
+	t.Helper()
+	stub := oidctest.NewProvider("client-id", "client-secret")
+	t.Cleanup(stub.Close)
+	return stub
+}
+
+// oidcLogin runs a complete provider login for identity
+func oidcLogin(t *testing.T, s *Service, stub *oidctest.Provider, identity oidctest.Identity) (*models.LoginResponse, error) {
+	t.Helper()
+
+	authURL, err := s.StartOIDCLogin(testProvider)
+	if err != nil {
+		t.Fatalf("StartOIDCLogin: %v", err)
+	}
+	code, state, err := stub.Authorize(authURL, identity)
+	if err != nil {
+		t.Fatalf("Authorize: %v", err)
+	}
+	return s.CompleteOIDCLogin(testProvider, code, state, RequestMeta{IP: "192.0.2.1"})
+}
+
+func TestOIDCLoginCreatesAccount(t *testing.T) {
+	stub := newTestProvider(t)
+	s := newTestService(t, withProvider(stub))
+
+	identity := oidctest.Identity{Subject: "subject-1", Email: "Jane.Doe@Example.com", EmailVerified: true, GivenName: "Jane"}
+	resp, err := oidcLogin(t, s, stub, identity)
+	if err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+	if resp.TokenResponse == nil || resp.AccessToken == "" {
+		t.Fatal("no tokens issued")
+	}
+	if resp.User.Email != "jane.doe@example.com" || resp.User.Username != "janedoe" || resp.User.EmailVerifiedAt == nil {
+		t.Errorf("unexpected user %+v", resp.User)
+	}
+
+	again, err := oidcLogin(t, s, stub, identity)
+	if err != nil {
+		t.Fatalf("second CompleteOIDCLogin: %v", err)
+	}
+	if again.User.ID != resp.User.ID {
+		t.Errorf("second login returned user %d, want %d", again.User.ID, resp.User.ID)
+	}
+	if n := testutil.CountRows(t, s.GetDB(), &models.Identity{}, "user_id = ?", resp.User.ID); n != 1 {
+		t.Errorf("%d identities linked, want 1", n)
+	}
+}
+
+func TestOIDCLoginRejectsReplayedState(t *testing.T) {
+	stub := newTestProvider(t)
+	s := newTestService(t, withProvider(stub))
+
+	authURL, err := s.StartOIDCLogin(testProvider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	identity := oidctest.Identity{Subject: "subject-1", Email: "jane@example.com", EmailVerified: true}
+	code, state, err := stub.Authorize(authURL, identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.CompleteOIDCLogin(testProvider, code, state, RequestMeta{}); err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+
+	// A fresh code does not help: the state and its PKCE verifier are gone
+	code, _, err = stub.Authorize(authURL, identity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = s.CompleteOIDCLogin(testProvider, code, state, RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeTokenInvalid)
+
+	_, err = s.CompleteOIDCLogin(testProvider, code, "unknown-state", RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeTokenInvalid)
+}
+
+func TestOIDCLoginRejectsInvalidIDToken(t *testing.T) {
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+	}{
+		{"wrong nonce", map[string]interface{}{"nonce": "other-nonce"}},
+		{"wrong issuer", map[string]interface{}{"iss": "https://attacker.example"}},
+		{"wrong audience", map[string]interface{}{"aud": "other-client"}},
+		{"expired", map[string]interface{}{"exp": time.Now().Add(-5 * time.Minute).Unix()}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := newTestProvider(t)
+			s := newTestService(t, withProvider(stub))
+
+			_, err := oidcLogin(t, s, stub, oidctest.Identity{
+				Subject:       "subject-1",
+				Email:         "jane@example.com",
+				EmailVerified: true,
+				Claims:        tt.claims,
+			})
+			testutil.AssertErrorCode(t, err, apperrors.ErrCodeExternalServiceError)
+
+			if n := testutil.CountRows(t, s.GetDB(), &models.User{}, "1 = 1"); n != 0 {
+				t.Errorf("%d users created", n)
+			}
+		})
+	}
+}
+
+func TestOIDCLoginRequiresVerifiedEmail(t *testing.T) {
+	stub := newTestProvider(t)
+	s := newTestService(t, withProvider(stub))
+
+	_, err := oidcLogin(t, s, stub, oidctest.Identity{Subject: "subject-1", Email: "jane@example.com"})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeEmailNotVerified)
+
+	if n := testutil.CountRows(t, s.GetDB(), &models.User{}, "1 = 1"); n != 0 {
+		t.Errorf("%d users created", n)
+	}
+}
+
+func TestOIDCLoginLinksVerifiedAccount(t *testing.T) {
+	stub := newTestProvider(t)
+	s := newTestService(t, withProvider(stub))
+
+	owner := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	now := time.Now()
+	if err := s.GetDB().Model(&models.User{}).Where("id = ?", owner.ID).Update("email_verified_at", now).Error; err != nil {
+		t.Fatal(err)
+	}
+	tokens := passwordLogin(t, s, "jane", "correct horse battery")
+	key := createAPIKey(t, s, owner.ID)
+
+	resp, err := oidcLogin(t, s, stub, oidctest.Identity{Subject: "subject-1", Email: "JANE@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+	if resp.User.ID != owner.ID {
+		t.Fatalf("logged in as user %d, want the existing account %d", resp.User.ID, owner.ID)
+	}
+
+	// The owner proved the address before, nothing of theirs is touched
+	passwordLogin(t, s, "jane", "correct horse battery")
+	if _, ok, err := s.ValidateAPIKey(context.Background(), key, ""); err != nil || !ok {
+		t.Errorf("API key no longer valid: ok=%v err=%v", ok, err)
+	}
+	if revoked := isRevoked(t, s, tokens.AccessToken); revoked {
+		t.Error("existing access token was revoked")
+	}
+}
+
+// TestOIDCLoginClaimsUnverifiedAccount covers account pre-takeover: someone
+// registers the victim's address first and sets up credentials, then the
+// owner logs in through the provider
+func TestOIDCLoginClaimsUnverifiedAccount(t *testing.T) {
+	stub := newTestProvider(t)
+	s := newTestService(t, withProvider(stub))
+
+	squatter := registerUser(t, s, "squatter", "jane@example.com", "purple monkey dishwasher")
+	tokens := passwordLogin(t, s, "squatter", "purple monkey dishwasher")
+	key := createAPIKey(t, s, squatter.ID)
+
+	enrollment, err := s.EnrollTOTP(squatter.ID)
+	if err != nil {
+		t.Fatalf("EnrollTOTP: %v", err)
+	}
+	secret, err := auth.DecodeTOTPSecret(enrollment.Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ConfirmTOTP(squatter.ID, auth.NewTOTP(secret).Code(time.Now())); err != nil {
+		t.Fatalf("ConfirmTOTP: %v", err)
+	}
+
+	// Issue times are compared at millisecond precision
+	time.Sleep(5 * time.Millisecond)
+
+	resp, err := oidcLogin(t, s, stub, oidctest.Identity{Subject: "subject-1", Email: "jane@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatalf("CompleteOIDCLogin: %v", err)
+	}
+	if resp.User.ID != squatter.ID {
+		t.Fatalf("logged in as user %d, want the existing account %d", resp.User.ID, squatter.ID)
+	}
+	if resp.MFARequired || resp.TokenResponse == nil {
+		t.Fatal("the squatter's second factor still guards the account")
+	}
+
+	_, err = s.Login(&models.UserLoginRequest{Identifier: "squatter", Password: "purple monkey dishwasher"}, RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeInvalidCredentials)
+
+	if !isRevoked(t, s, tokens.AccessToken) {
+		t.Error("the squatter's access token is still valid")
+	}
+	if isRevoked(t, s, resp.AccessToken) {
+		t.Error("the owner's new access token is revoked")
+	}
+	if _, err := s.RefreshTokens(&models.TokenRefreshRequest{RefreshToken: tokens.RefreshToken}, RequestMeta{}); err == nil {
+		t.Error("the squatter's refresh token still works")
+	}
+	if _, ok, err := s.ValidateAPIKey(context.Background(), key, ""); err != nil || ok {
+		t.Errorf("the squatter's API key is still valid: ok=%v err=%v", ok, err)
+	}
+
+	if n := testutil.CountRows(t, s.GetDB(), &models.Session{}, "user_id = ? AND revoked_at IS NULL", squatter.ID); n != 1 {
+		t.Errorf("%d active sessions, want only the owner's", n)
+	}
+	if n := testutil.CountRows(t, s.GetDB(), &models.RecoveryCode{}, "user_id = ?", squatter.ID); n != 0 {
+		t.Errorf("%d recovery codes left", n)
+	}
+
+	var user models.User
+	if err := s.GetDB().First(&user, squatter.ID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if user.MFAEnabled || user.MFASecret != "" || user.EmailVerifiedAt == nil {
+		t.Errorf("MFA enabled=%v secret set=%v verified=%v", user.MFAEnabled, user.MFASecret != "", user.EmailVerifiedAt != nil)
+	}
+}
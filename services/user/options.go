@@ -0,0 +1,65 @@
+package user
+
+import (
+	"kube/internal/auth"
+	"kube/internal/mailer"
+)
+
+// Option configures optional Service dependencies
+type Option func(*Service)
+
+// WithRevocationStore sets the store used to revoke access tokens on logout.
+// Defaults to an in-memory store.
+func WithRevocationStore(store auth.RevocationStore) Option {
+	return func(s *Service) {
+		s.revocations = store
+	}
+}
+
+// WithTokenSigner sets the signer used for access tokens. Defaults to HS256
+// with the configured JWT secret.
+func WithTokenSigner(signer auth.TokenSigner) Option {
+	return func(s *Service) {
+		s.signer = signer
+	}
+}
+
+// WithAttemptTracker sets the tracker used to throttle failed logins per
+// client IP. Defaults to an in-memory tracker.
+func WithAttemptTracker(tracker auth.AttemptTracker) Option {
+	return func(s *Service) {
+		s.attempts = tracker
+	}
+}
+
+// WithMailer sets the mailer used for account emails. Defaults to logging
+// emails instead of sending them.
+func WithMailer(m mailer.Mailer) Option {
+	return func(s *Service) {
+		s.mailer = m
+	}
+}
+
+// WithPasswordHasher sets the hasher used for new passwords. Defaults to
+// bcrypt with the default cost.
+func WithPasswordHasher(hasher auth.PasswordHasher) Option {
+	return func(s *Service) {
+		s.passwords = hasher
+	}
+}
+
+// WithPasswordPolicy sets the policy new passwords must satisfy. Defaults
+// to auth.DefaultPasswordPolicy.
+func WithPasswordPolicy(policy *auth.PasswordPolicy) Option {
+	return func(s *Service) {
+		s.passwordPolicy = policy
+	}
+}
+
+// WithOIDCStateStore sets the store that keeps OIDC login state between the
+// redirect and the callback. Defaults to an in-memory store.
+func WithOIDCStateStore(store auth.StateStore) Option {
+	return func(s *Service) {
+		s.oidcStates = store
+	}
+}
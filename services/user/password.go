@@ -0,0 +1,258 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"kube/internal/mailer"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const userTokenBytes = 32
+
+// ForgotPassword emails a password reset link to the user. It succeeds
+// whether or not the email is registered so that callers cannot probe for
+// accounts: the token is issued and mailed in the background, so known and
+// unknown addresses take the same time to answer. Each address gets at
+// most one email per PasswordResetInterval, and a client IP may make
+// PasswordResetIPLimit requests per window.
+func (s *Service) ForgotPassword(req *models.PasswordForgotRequest, meta RequestMeta) error {
+	if err := s.checkResetThrottle(meta.IP); err != nil {
+		return err
+	}
+
+	var user models.User
+	if err := s.GetDB().Where("LOWER(email) = ?", normalizeIdentifier(req.Email)).First(&user).Error; err != nil || !user.IsActive {
+		return nil
+	}
+
+	s.background.Add(1)
+	go func() {
+		defer s.background.Done()
+		s.sendPasswordReset(&user)
+	}()
+	return nil
+}
+
+// checkResetThrottle counts a reset request against the client IP. The
+// count does not depend on the address asked for, so the limit reveals
+// nothing about accounts.
+func (s *Service) checkResetThrottle(ip string) error {
+	if s.authCfg.PasswordResetIPLimit <= 0 || ip == "" {
+		return nil
+	}
+
+	key := "password-reset:ip:" + ip
+	window := time.Duration(s.authCfg.PasswordResetIPWindow) * time.Second
+	requests, err := s.attempts.RecordFailure(context.Background(), key, window)
+	if err != nil {
+		hlog.Warnf("Failed to record password reset request for %s: %v", ip, err)
+		return nil
+	}
+	if requests <= s.authCfg.PasswordResetIPLimit {
+		return nil
+	}
+
+	_, resetIn, err := s.attempts.Failures(context.Background(), key)
+	if err != nil {
+		resetIn = window
+	}
+	return apperrors.New(apperrors.ErrCodeRateLimitExceeded, "Too many password reset requests", "Too many password reset requests from this address").
+		AddMetadata("retry_after", int(resetIn.Seconds())+1)
+}
+
+// sendPasswordReset issues a reset token and emails it. The interval is
+// claimed with a conditional update, so concurrent requests for the same
+// address send at most one email. Failures are logged as the request has
+// already been answered.
+func (s *Service) sendPasswordReset(user *models.User) {
+	now := time.Now()
+	interval := time.Duration(s.authCfg.PasswordResetInterval) * time.Second
+	result := s.GetDB().Model(&models.User{}).
+		Where("id = ? AND (reset_sent_at IS NULL OR reset_sent_at <= ?)", user.ID, now.Add(-interval)).
+		UpdateColumn("reset_sent_at", now)
+	if result.Error != nil {
+		hlog.Errorf("Failed to send password reset email to user %d: %v", user.ID, result.Error)
+		return
+	}
+	if result.RowsAffected == 0 {
+		return
+	}
+
+	var token string
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		var err error
+		token, err = s.createUserToken(tx, user.ID, models.TokenPurposePasswordReset,
+			time.Duration(s.authCfg.PasswordResetExpiresIn)*time.Minute)
+		return err
+	})
+	if err != nil {
+		hlog.Errorf("Failed to issue password reset token for user %d: %v", user.ID, err)
+		return
+	}
+
+	link := s.appURL + "/reset-password?token=" + url.QueryEscape(token)
+	msg := &mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse the link below to choose a new password. It expires in %d minutes and can only be used once.\n\n%s\n\nIf you did not request a password reset, you can ignore this email.\n",
+			user.Username, s.authCfg.PasswordResetExpiresIn, link),
+	}
+
+	if err := s.mailer.Send(context.Background(), msg); err != nil {
+		hlog.Errorf("Failed to send password reset email to user %d: %v", user.ID, err)
+	}
+}
+
+// ResetPassword sets a new password using a reset token and ends every
+// session of the user
+func (s *Service) ResetPassword(req *models.PasswordResetRequest, meta RequestMeta) error {
+	var userID uint
+
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		token, err := consumeUserToken(tx, req.Token, models.TokenPurposePasswordReset)
+		if err != nil {
+			return err
+		}
+
+		var user models.User
+		if err := tx.Select("id", "username", "email").First(&user, token.UserID).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeUserNotFound, "User not found", "The account no longer exists")
+		}
+		if err := s.passwordPolicy.Validate(req.Password, user.Username, user.Email); err != nil {
+			return err
+		}
+
+		hashedPassword, err := s.passwords.Hash(req.Password)
+		if err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeInternalError, "Password hashing failed", err.Error())
+		}
+
+		if err := tx.Model(&models.User{}).Where("id = ?", token.UserID).Updates(map[string]interface{}{
+			"password":   hashedPassword,
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to update password", err.Error())
+		}
+
+		userID = token.UserID
+		if err := invalidateUserTokens(tx, userID, models.TokenPurposePasswordReset); err != nil {
+			return err
+		}
+
+		// The reset token proves the identity of the anonymous caller
+		event := newAuditEvent(models.AuditActionPasswordChange, meta, userID, nil)
+		event.ActorID = optionalID(userID)
+		return appendAudit(tx, event)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	return s.revokeAllSessions(userID)
+}
+
+// checkPassword verifies the password of the user. When it matches but the
+// stored hash was made with another algorithm or other parameters than the
+// current policy, the hash is upgraded, so that hashing can be strengthened
+// over time without forcing password resets.
+func (s *Service) checkPassword(user *models.User, password string) bool {
+	ok, err := s.passwords.Verify(user.Password, password)
+	if err != nil {
+		hlog.Errorf("Failed to verify password of user %d: %v", user.ID, err)
+		return false
+	}
+
+	if ok && s.passwords.NeedsRehash(user.Password) {
+		s.rehashPassword(user, password)
+	}
+	return ok
+}
+
+// rehashPassword stores a new hash of the password unless the password was
+// changed concurrently. Failures are logged; the old hash keeps working.
+func (s *Service) rehashPassword(user *models.User, password string) {
+	hashed, err := s.passwords.Hash(password)
+	if err != nil {
+		hlog.Errorf("Failed to rehash password of user %d: %v", user.ID, err)
+		return
+	}
+
+	if err := s.GetDB().Model(&models.User{}).
+		Where("id = ? AND password = ?", user.ID, user.Password).
+		Update("password", hashed).Error; err != nil {
+		hlog.Errorf("Failed to store rehashed password of user %d: %v", user.ID, err)
+		return
+	}
+	user.Password = hashed
+}
+
+// createUserToken stores a new single-use token and returns its plain value.
+// Outstanding tokens with the same purpose are invalidated.
+func (s *Service) createUserToken(tx *gorm.DB, userID uint, purpose string, ttl time.Duration) (string, error) {
+	token, err := generateOpaqueToken(userTokenBytes)
+	if err != nil {
+		return "", apperrors.Wrap(err, apperrors.ErrCodeInternalError, "Failed to generate token", err.Error())
+	}
+
+	if err := invalidateUserTokens(tx, userID, purpose); err != nil {
+		return "", err
+	}
+
+	record := &models.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(ttl),
+		CreatedAt: time.Now(),
+	}
+
+	if err := tx.Create(record).Error; err != nil {
+		return "", apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to store token", err.Error())
+	}
+
+	return token, nil
+}
+
+// consumeUserToken marks a valid token as used and returns it
+func consumeUserToken(tx *gorm.DB, token, purpose string) (*models.UserToken, error) {
+	var stored models.UserToken
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ? AND purpose = ?", hashToken(token), purpose).
+		First(&stored).Error; err != nil {
+		return nil, apperrors.New(apperrors.ErrCodeTokenInvalid, "Invalid token", "Token is invalid")
+	}
+
+	if stored.UsedAt != nil {
+		return nil, apperrors.New(apperrors.ErrCodeTokenInvalid, "Invalid token", "Token has already been used")
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return nil, apperrors.New(apperrors.ErrCodeTokenExpired, "Token expired", "Please request a new link")
+	}
+
+	now := time.Now()
+	if err := tx.Model(&stored).Update("used_at", now).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to consume token", err.Error())
+	}
+	stored.UsedAt = &now
+
+	return &stored, nil
+}
+
+func invalidateUserTokens(tx *gorm.DB, userID uint, purpose string) error {
+	if err := tx.Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error; err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to invalidate tokens", err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package user
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"kube/internal/auth"
+	"kube/internal/config"
+	"kube/internal/testutil"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+)
+
+func withResetThrottle(interval, ipLimit int) func(*config.Config) {
+	return func(cfg *config.Config) {
+		cfg.Auth.PasswordResetExpiresIn = 30
+		cfg.Auth.PasswordResetInterval = interval
+		cfg.Auth.PasswordResetIPLimit = ipLimit
+		cfg.Auth.PasswordResetIPWindow = 3600
+	}
+}
+
+func forgotPassword(s *Service, email, ip string) error {
+	err := s.ForgotPassword(&models.PasswordForgotRequest{Email: email}, RequestMeta{IP: ip})
+	s.background.Wait()
+	return err
+}
+
+func TestPasswordReset(t *testing.T) {
+	s := newTestService(t, withResetThrottle(60, 0))
+	registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	session := passwordLogin(t, s, "jane", "correct horse battery")
+	mail := withRecordingMailer(s)
+
+	if err := forgotPassword(s, " Jane@Example.com", "10.0.0.1"); err != nil {
+		t.Fatalf("ForgotPassword: %v", err)
+	}
+	sent := mail.messages()
+	if len(sent) != 1 || sent[0].To != "jane@example.com" {
+		t.Fatalf("sent %d emails, want one to jane@example.com", len(sent))
+	}
+	token := mailToken(t, sent[0])
+
+	err := s.ResetPassword(&models.PasswordResetRequest{Token: token, Password: "short"}, RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeWeakPassword)
+
+	if err := s.ResetPassword(&models.PasswordResetRequest{Token: token, Password: "purple monkey dishwasher"}, RequestMeta{}); err != nil {
+		t.Fatalf("ResetPassword: %v", err)
+	}
+	passwordLogin(t, s, "jane", "purple monkey dishwasher")
+	_, err = s.Login(&models.UserLoginRequest{Identifier: "jane", Password: "correct horse battery"}, RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeInvalidCredentials)
+
+	if !isRevoked(t, s, session.AccessToken) {
+		t.Error("session from before the reset is still valid")
+	}
+
+	err = s.ResetPassword(&models.PasswordResetRequest{Token: token, Password: "another fine password"}, RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeTokenInvalid)
+}
+
+func TestForgotPasswordIsUniformAndThrottledPerAddress(t *testing.T) {
+	s := newTestService(t, withResetThrottle(60, 0))
+	registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	mail := withRecordingMailer(s)
+
+	for _, email := range []string{"jane@example.com", "jane@example.com", "nobody@example.com"} {
+		if err := forgotPassword(s, email, "10.0.0.1"); err != nil {
+			t.Errorf("ForgotPassword(%q) = %v, want the same answer for every address", email, err)
+		}
+	}
+
+	if n := len(mail.messages()); n != 1 {
+		t.Errorf("sent %d emails, want 1 within the interval", n)
+	}
+	if n := testutil.CountRows(t, s.GetDB(), &models.UserToken{}, "purpose = ?", models.TokenPurposePasswordReset); n != 1 {
+		t.Errorf("issued %d reset tokens, want 1", n)
+	}
+}
+
+func TestForgotPasswordThrottlesClientIP(t *testing.T) {
+	s := newTestService(t, withResetThrottle(0, 2))
+	withRecordingMailer(s)
+
+	for i := 0; i < 2; i++ {
+		if err := forgotPassword(s, "nobody@example.com", "10.0.0.1"); err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+
+	// Unknown addresses count as well, the limit must not depend on them
+	err := forgotPassword(s, "other@example.com", "10.0.0.1")
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeRateLimitExceeded)
+
+	if err := forgotPassword(s, "nobody@example.com", "10.0.0.2"); err != nil {
+		t.Errorf("other client throttled: %v", err)
+	}
+}
+
+func TestLoginRehashesOutdatedPasswords(t *testing.T) {
+	s := newTestService(t)
+	registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	storedHash := func() string {
+		var user models.User
+		if err := s.GetDB().Where("username = ?", "jane").First(&user).Error; err != nil {
+			t.Fatal(err)
+		}
+		return user.Password
+	}
+	bcryptHash := storedHash()
+
+	// A failed login leaves the hash alone
+	s.passwords = auth.NewArgon2idHasher(64, 1, 1)
+	login(s, "jane", "wrong password", "")
+	if storedHash() != bcryptHash {
+		t.Fatal("hash changed by a failed login")
+	}
+
+	passwordLogin(t, s, "jane", "correct horse battery")
+	upgraded := storedHash()
+	if !strings.HasPrefix(upgraded, "$argon2id$") {
+		t.Fatalf("hash not upgraded to argon2id: %s", upgraded)
+	}
+
+	// Up to date hashes are kept, and the upgraded one still verifies
+	passwordLogin(t, s, "jane", "correct horse battery")
+	if storedHash() != upgraded {
+		t.Error("up to date hash replaced")
+	}
+}
+
+func TestRegisterEnforcesPasswordPolicy(t *testing.T) {
+	s := newTestService(t)
+	router := newTestRouter(s)
+
+	// Similarity is checked against the normalized identifiers
+	_, err := s.CreateUser(&models.UserCreateRequest{Username: "JaneDoe", Email: "jane@example.com", Password: "janedoe-rocks"}, RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeWeakPassword)
+
+	resp := performRequest(router, "POST", "/api/v1/users/register", `{"username":"jane","email":"jane@example.com","password":"q7"}`, "")
+	if resp.StatusCode() != 400 {
+		t.Fatalf("status %d, want 400: %s", resp.StatusCode(), resp.Body())
+	}
+	var body struct {
+		Error struct {
+			Code     string `json:"code"`
+			Metadata struct {
+				Violations []apperrors.RuleViolation `json:"violations"`
+			} `json:"metadata"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(resp.Body(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Error.Code != apperrors.ErrCodeWeakPassword || len(body.Error.Metadata.Violations) != 1 || body.Error.Metadata.Violations[0].Rule != auth.PasswordRuleMinLength {
+		t.Errorf("unexpected error response %s", resp.Body())
+	}
+	if n := testutil.CountRows(t, s.GetDB(), &models.User{}, "1 = 1"); n != 0 {
+		t.Errorf("%d users created with weak passwords", n)
+	}
+}
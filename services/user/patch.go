@@ -0,0 +1,223 @@
+package user
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	maxNameLength      = 100
+	maxAvatarURLLength = 2048
+)
+
+// patchableUserFields lists the profile fields a merge patch may change
+var patchableUserFields = map[string]bool{
+	"first_name": true,
+	"last_name":  true,
+	"avatar":     true,
+}
+
+// PatchUser applies a JSON merge patch (RFC 7396) to the editable profile
+// fields: members set to a string replace the field, members set to null
+// clear it and omitted members are left unchanged. See updateProfile for the
+// meaning of expectedVersions.
+func (s *Service) PatchUser(id uint, patch []byte, expectedVersions []int, meta RequestMeta) (*models.UserResponse, error) {
+	changes, err := parseUserPatch(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	var dropped models.AvatarURLs
+	user, err := s.updateProfile(id, expectedVersions, meta, func(user *models.User) error {
+		for field, value := range changes {
+			switch field {
+			case "first_name":
+				user.FirstName = value
+			case "last_name":
+				user.LastName = value
+			case "avatar":
+				dropped = setAvatar(user, value)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.deleteAvatarURLs(id, dropped)
+	return user, nil
+}
+
+// updateProfile applies a profile change under a row lock and bumps the
+// version. When expectedVersions is non-nil, the change is only applied if
+// the current version is one of them, so that concurrent editors get a
+// precondition failure instead of silently overwriting each other. The
+// changed fields are recorded in the audit log.
+func (s *Service) updateProfile(id uint, expectedVersions []int, meta RequestMeta, apply func(*models.User) error) (*models.UserResponse, error) {
+	var user models.User
+
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, id).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeUserNotFound, "User not found", "User with ID "+strconv.FormatUint(uint64(id), 10)+" not found")
+		}
+
+		if expectedVersions != nil && !containsVersion(expectedVersions, user.Version) {
+			return apperrors.New(apperrors.ErrCodePreconditionFailed, "Precondition failed", "The user has been modified since it was fetched").
+				AddMetadata("etag", userETag(user.Version))
+		}
+
+		before := user
+		if err := apply(&user); err != nil {
+			return err
+		}
+
+		user.Version++
+		user.UpdatedAt = time.Now()
+		if err := tx.Model(&user).Select("first_name", "last_name", "avatar", "avatar_urls", "version", "updated_at").Updates(&user).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to update user", err.Error())
+		}
+
+		if err := appendAudit(tx, newAuditEvent(models.AuditActionUpdate, meta, id, diffUser(&before, &user))); err != nil {
+			return err
+		}
+
+		return tx.Preload("Roles").First(&user, id).Error
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toUserResponse(&user), nil
+}
+
+// parseUserPatch decodes and validates a merge patch document into the new
+// field values. null becomes an empty string.
+func parseUserPatch(patch []byte) (map[string]string, error) {
+	var document map[string]json.RawMessage
+	if err := json.Unmarshal(patch, &document); err != nil || document == nil {
+		return nil, apperrors.New(apperrors.ErrCodeInvalidFormat, "Invalid merge patch", "The request body must be a JSON object")
+	}
+
+	changes := make(map[string]string, len(document))
+	fieldErrors := make(map[string]string)
+	for field, raw := range document {
+		if !patchableUserFields[field] {
+			fieldErrors[field] = "field cannot be changed"
+			continue
+		}
+
+		if bytes.Equal(bytes.TrimSpace(raw), []byte("null")) {
+			changes[field] = ""
+			continue
+		}
+
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			fieldErrors[field] = "must be a string or null"
+			continue
+		}
+		changes[field] = value
+	}
+
+	for field, message := range profileFieldErrors(changes) {
+		fieldErrors[field] = message
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, fieldValidationError(fieldErrors)
+	}
+	return changes, nil
+}
+
+// validateProfile checks profile field values keyed by their JSON name
+func validateProfile(fields map[string]string) error {
+	if fieldErrors := profileFieldErrors(fields); len(fieldErrors) > 0 {
+		return fieldValidationError(fieldErrors)
+	}
+	return nil
+}
+
+func profileFieldErrors(fields map[string]string) map[string]string {
+	fieldErrors := make(map[string]string)
+
+	for field, value := range fields {
+		switch field {
+		case "first_name", "last_name":
+			if len([]rune(value)) > maxNameLength {
+				fieldErrors[field] = "must be at most 100 characters"
+			} else if strings.IndexFunc(value, unicode.IsControl) >= 0 {
+				fieldErrors[field] = "must not contain control characters"
+			}
+		case "avatar":
+			if value == "" {
+				continue
+			}
+			parsed, err := url.Parse(value)
+			if err != nil || len(value) > maxAvatarURLLength || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+				fieldErrors[field] = "must be an http or https URL of at most 2048 characters"
+			}
+		}
+	}
+	return fieldErrors
+}
+
+// fieldValidationError reports per-field problems in the "fields" metadata
+func fieldValidationError(fieldErrors map[string]string) error {
+	names := make([]string, 0, len(fieldErrors))
+	for name := range fieldErrors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return apperrors.New(apperrors.ErrCodeValidationFailed, "Validation failed", "Invalid fields: "+strings.Join(names, ", ")).
+		AddMetadata("fields", fieldErrors)
+}
+
+// userETag returns the strong entity tag of a user version
+func userETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// parseIfMatch returns the versions listed in an If-Match header. It
+// returns nil when the header is absent or "*", meaning any version is
+// acceptable. Tags that are weak or not a version never match.
+func parseIfMatch(header string) []int {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil
+	}
+
+	versions := []int{}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		version, err := strconv.Atoi(strings.Trim(tag, `"`))
+		if err != nil || !strings.HasPrefix(tag, `"`) || !strings.HasSuffix(tag, `"`) || len(tag) < 2 {
+			version = -1
+		}
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+func containsVersion(versions []int, version int) bool {
+	for _, v := range versions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
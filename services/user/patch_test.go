@@ -0,0 +1,117 @@
+package user
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"kube/internal/testutil"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+)
+
+func patchUser(s *Service, id uint, patch string, ifMatch string) (*models.UserResponse, error) {
+	return s.PatchUser(id, []byte(patch), parseIfMatch(ifMatch), RequestMeta{})
+}
+
+func TestParseIfMatch(t *testing.T) {
+	for header, want := range map[string]string{
+		"":              "[]",
+		"*":             "[]",
+		`"3"`:           "[3]",
+		` "3" , "4" `:   "[3 4]",
+		`W/"3"`:         "[-1]",
+		`3`:             "[-1]",
+		`"3", "latest"`: "[3 -1]",
+	} {
+		if got := fmt.Sprint(parseIfMatch(header)); got != want {
+			t.Errorf("parseIfMatch(%q) = %s, want %s", header, got, want)
+		}
+	}
+	if parseIfMatch("*") != nil {
+		t.Error(`parseIfMatch("*") should accept any version`)
+	}
+}
+
+func TestPatchUserMergesFields(t *testing.T) {
+	s := newTestService(t)
+	user := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+
+	updated, err := patchUser(s, user.ID, `{"first_name":"Jane","last_name":"Doe"}`, "")
+	if err != nil {
+		t.Fatalf("PatchUser: %v", err)
+	}
+	if updated.FirstName != "Jane" || updated.LastName != "Doe" || updated.Version != user.Version+1 {
+		t.Fatalf("unexpected user %+v", updated)
+	}
+
+	// Omitted fields stay, null clears
+	updated, err = patchUser(s, user.ID, `{"last_name":null}`, "")
+	if err != nil {
+		t.Fatalf("PatchUser: %v", err)
+	}
+	if updated.FirstName != "Jane" || updated.LastName != "" {
+		t.Errorf("first name %q, last name %q after clearing the last name", updated.FirstName, updated.LastName)
+	}
+
+	for _, patch := range []string{`[]`, `"Jane"`, `null`, `{"email":"x@example.com"}`, `{"first_name":1}`, `{"avatar":"javascript:alert(1)"}`} {
+		_, err := patchUser(s, user.ID, patch, "")
+		var appErr *apperrors.AppError
+		if !errors.As(err, &appErr) || (appErr.Code != apperrors.ErrCodeInvalidFormat && appErr.Code != apperrors.ErrCodeValidationFailed) {
+			t.Errorf("patch %s: %v, want it rejected", patch, err)
+		}
+	}
+}
+
+func TestPatchUserChecksVersion(t *testing.T) {
+	s := newTestService(t)
+	user := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	fetched := userETag(user.Version)
+
+	if _, err := patchUser(s, user.ID, `{"first_name":"Jane"}`, fetched); err != nil {
+		t.Fatalf("PatchUser with the current ETag: %v", err)
+	}
+
+	// A second editor holding the same ETag loses
+	_, err := patchUser(s, user.ID, `{"first_name":"Janet"}`, fetched)
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodePreconditionFailed)
+	var appErr *apperrors.AppError
+	if errors.As(err, &appErr) && appErr.Metadata["etag"] != userETag(user.Version+1) {
+		t.Errorf("etag metadata = %v, want the current ETag", appErr.Metadata["etag"])
+	}
+
+	_, err = s.UpdateUser(user.ID, &models.UserUpdateRequest{FirstName: "Janet"}, parseIfMatch(fetched), RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodePreconditionFailed)
+
+	if _, err := patchUser(s, user.ID, `{"first_name":"Janet"}`, `"0", `+userETag(user.Version+1)); err != nil {
+		t.Errorf("PatchUser with one matching ETag: %v", err)
+	}
+}
+
+func TestUserRoutesUseETags(t *testing.T) {
+	s := newTestService(t)
+	router := newTestRouter(s)
+	user := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	auth := bearer(passwordLogin(t, s, "jane", "correct horse battery"))
+	path := fmt.Sprintf("/api/v1/users/%d", user.ID)
+
+	etag := string(performRequest(router, "GET", path, "", auth).Header.Peek("ETag"))
+	if etag != userETag(user.Version) {
+		t.Fatalf("ETag = %q, want %q", etag, userETag(user.Version))
+	}
+
+	resp := performRequest(router, "PATCH", path, `{"first_name":"Jane"}`, auth, "Content-Type", "application/merge-patch+json", "If-Match", etag)
+	if resp.StatusCode() != 200 {
+		t.Fatalf("PATCH: status %d: %s", resp.StatusCode(), resp.Body())
+	}
+	if next := string(resp.Header.Peek("ETag")); next != userETag(user.Version+1) {
+		t.Errorf("ETag after PATCH = %q, want %q", next, userETag(user.Version+1))
+	}
+
+	if got := performRequest(router, "PUT", path, `{"first_name":"Janet"}`, auth, "If-Match", etag).StatusCode(); got != 412 {
+		t.Errorf("PUT with a stale ETag: status %d, want 412", got)
+	}
+	if got := performRequest(router, "PATCH", path, `first_name=Janet`, auth, "Content-Type", "application/x-www-form-urlencoded").StatusCode(); got != 415 {
+		t.Errorf("PATCH with a form body: status %d, want 415", got)
+	}
+}
@@ -0,0 +1,46 @@
+package user
+
+import (
+	"kube/internal/middleware"
+	apperrors "kube/pkg/errors"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// authorizeUserAccess allows only the account owner or a holder of the given
+// permission to act on the user with the given ID. API keys act only through
+// their scopes: owning the account is not enough, otherwise a key scoped to
+// uploads could rewrite or delete its owner's account.
+func authorizeUserAccess(c *app.RequestContext, targetID uint, permission string) error {
+	userID, err := currentUserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := requireAPIKeyScope(c, permission); err != nil {
+		return err
+	}
+	if userID == targetID || middleware.HasPermission(c, permission) {
+		return nil
+	}
+
+	return apperrors.New(apperrors.ErrCodeForbidden, "Forbidden", "You can only manage your own account")
+}
+
+// requireAPIKeyScope rejects requests authenticated with an API key that
+// does not carry the given scope. Login sessions are not affected.
+func requireAPIKeyScope(c *app.RequestContext, permission string) error {
+	if middleware.GetAuthMethod(c) == middleware.AuthMethodAPIKey && !middleware.HasPermission(c, permission) {
+		return apperrors.New(apperrors.ErrCodeForbidden, "Forbidden", "This API key needs the "+permission+" scope")
+	}
+	return nil
+}
+
+// currentUserID returns the authenticated user ID
+func currentUserID(c *app.RequestContext) (uint, error) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return 0, apperrors.New(apperrors.ErrCodeUnauthorized, "Unauthorized", "Authentication required")
+	}
+	return userID, nil
+}
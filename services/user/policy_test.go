@@ -0,0 +1,54 @@
+package user
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestUserRoutesRequireOwnerOrPermission(t *testing.T) {
+	s := newTestService(t)
+	router := newTestRouter(s)
+
+	jane := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	bob := registerUser(t, s, "bob", "bob@example.com", "correct horse battery")
+	admin := registerUser(t, s, "alice", "alice@example.com", "correct horse battery")
+	makeAdmin(t, s, admin.ID)
+
+	janeAuth := bearer(passwordLogin(t, s, "jane", "correct horse battery"))
+	adminAuth := bearer(passwordLogin(t, s, "alice", "correct horse battery"))
+	keyAuth := "ApiKey " + createAPIKey(t, s, jane.ID)
+
+	janePath := fmt.Sprintf("/api/v1/users/%d", jane.ID)
+	bobPath := fmt.Sprintf("/api/v1/users/%d", bob.ID)
+	update := `{"first_name":"Jane"}`
+
+	for _, tt := range []struct {
+		name          string
+		method, path  string
+		authorization string
+		want          int
+	}{
+		{"anonymous read", "GET", janePath, "", 401},
+		{"anonymous update", "PUT", janePath, "", 401},
+		{"owner reads", "GET", janePath, janeAuth, 200},
+		{"owner updates", "PUT", janePath, janeAuth, 200},
+		{"owner patches", "PATCH", janePath, janeAuth, 200},
+		{"other user updates", "PUT", bobPath, janeAuth, 403},
+		{"other user patches", "PATCH", bobPath, janeAuth, 403},
+		{"other user deletes", "DELETE", bobPath, janeAuth, 403},
+		{"admin updates", "PUT", bobPath, adminAuth, 200},
+		{"upload key reads", "GET", janePath, keyAuth, 403},
+		{"upload key updates its owner", "PUT", janePath, keyAuth, 403},
+		{"upload key deletes its owner", "DELETE", janePath, keyAuth, 403},
+		{"admin deletes", "DELETE", bobPath, adminAuth, 200},
+	} {
+		body := ""
+		if tt.method == "PUT" || tt.method == "PATCH" {
+			body = update
+		}
+		resp := performRequest(router, tt.method, tt.path, body, tt.authorization)
+		if got := resp.StatusCode(); got != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, got, tt.want, resp.Body())
+		}
+	}
+}
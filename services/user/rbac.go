@@ -0,0 +1,153 @@
+package user
+
+import (
+	"sort"
+
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+
+	"gorm.io/gorm"
+)
+
+// SeedRoles makes sure the built-in roles and their permissions exist.
+// Permissions added to existing roles by an operator are left untouched.
+func (s *Service) SeedRoles() error {
+	return s.WithTransaction(func(tx *gorm.DB) error {
+		for roleName, permissionNames := range models.DefaultRolePermissions {
+			role := models.Role{Name: roleName}
+			if err := tx.Where(models.Role{Name: roleName}).FirstOrCreate(&role).Error; err != nil {
+				return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to seed role", err.Error())
+			}
+
+			permissions := make([]models.Permission, 0, len(permissionNames))
+			for _, name := range permissionNames {
+				permission := models.Permission{Name: name}
+				if err := tx.Where(models.Permission{Name: name}).FirstOrCreate(&permission).Error; err != nil {
+					return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to seed permission", err.Error())
+				}
+				permissions = append(permissions, permission)
+			}
+
+			if err := tx.Model(&role).Association("Permissions").Append(&permissions); err != nil {
+				return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to seed role permissions", err.Error())
+			}
+		}
+		return nil
+	})
+}
+
+func (s *Service) ListRoles() ([]models.RoleResponse, error) {
+	var roles []models.Role
+	if err := s.GetDB().Preload("Permissions").Order("name").Find(&roles).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to list roles", err.Error())
+	}
+
+	responses := make([]models.RoleResponse, 0, len(roles))
+	for i := range roles {
+		responses = append(responses, toRoleResponse(&roles[i]))
+	}
+	return responses, nil
+}
+
+func (s *Service) GetUserRoles(userID uint) ([]models.RoleResponse, error) {
+	var user models.User
+	if err := s.GetDB().Preload("Roles.Permissions").First(&user, userID).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeUserNotFound, "User not found", "User does not exist")
+	}
+
+	responses := make([]models.RoleResponse, 0, len(user.Roles))
+	for i := range user.Roles {
+		responses = append(responses, toRoleResponse(&user.Roles[i]))
+	}
+	return responses, nil
+}
+
+// AssignRole grants a role to a user. Granting a role the user already holds
+// is a no-op. The change is reflected in the next issued access token.
+func (s *Service) AssignRole(userID uint, roleName string) error {
+	return s.WithTransaction(func(tx *gorm.DB) error {
+		user, role, err := findUserAndRole(tx, userID, roleName)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(user).Association("Roles").Append(role); err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to assign role", err.Error())
+		}
+		return nil
+	})
+}
+
+// RevokeRole removes a role from a user. The change is reflected in the next
+// issued access token.
+func (s *Service) RevokeRole(userID uint, roleName string) error {
+	return s.WithTransaction(func(tx *gorm.DB) error {
+		user, role, err := findUserAndRole(tx, userID, roleName)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(user).Association("Roles").Delete(role); err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to revoke role", err.Error())
+		}
+		return nil
+	})
+}
+
+// loadAuthorization returns the sorted role and permission names of a user
+// for embedding into access tokens
+func loadAuthorization(tx *gorm.DB, userID uint) ([]string, []string, error) {
+	var roles []models.Role
+	if err := tx.Preload("Permissions").
+		Joins("JOIN user_roles ON user_roles.role_id = roles.id").
+		Where("user_roles.user_id = ?", userID).
+		Find(&roles).Error; err != nil {
+		return nil, nil, err
+	}
+
+	roleNames := make([]string, 0, len(roles))
+	seen := make(map[string]bool)
+	var permissionNames []string
+	for _, role := range roles {
+		roleNames = append(roleNames, role.Name)
+		for _, permission := range role.Permissions {
+			if !seen[permission.Name] {
+				seen[permission.Name] = true
+				permissionNames = append(permissionNames, permission.Name)
+			}
+		}
+	}
+
+	sort.Strings(roleNames)
+	sort.Strings(permissionNames)
+	return roleNames, permissionNames, nil
+}
+
+func findUserAndRole(tx *gorm.DB, userID uint, roleName string) (*models.User, *models.Role, error) {
+	var user models.User
+	if err := tx.First(&user, userID).Error; err != nil {
+		return nil, nil, apperrors.Wrap(err, apperrors.ErrCodeUserNotFound, "User not found", "User does not exist")
+	}
+
+	var role models.Role
+	if err := tx.Where("name = ?", roleName).First(&role).Error; err != nil {
+		return nil, nil, apperrors.Wrap(err, apperrors.ErrCodeRecordNotFound, "Role not found", "Role "+roleName+" does not exist")
+	}
+
+	return &user, &role, nil
+}
+
+func toRoleResponse(role *models.Role) models.RoleResponse {
+	permissions := make([]string, 0, len(role.Permissions))
+	for _, permission := range role.Permissions {
+		permissions = append(permissions, permission.Name)
+	}
+	sort.Strings(permissions)
+
+	return models.RoleResponse{
+		ID:          role.ID,
+		Name:        role.Name,
+		Description: role.Description,
+		Permissions: permissions,
+	}
+}
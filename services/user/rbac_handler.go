@@ -0,0 +1,119 @@
+package user
+
+import (
+	"kube/pkg/errors"
+	"kube/pkg/models"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// ListRoles godoc
+// @Summary List roles
+// @Description List every role together with the permissions it grants
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Roles retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Missing roles:read permission"
+// @Router /api/v1/roles [get]
+func (h *Handler) ListRoles(c *app.RequestContext) {
+	roles, err := h.service.ListRoles()
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, roles, "Roles retrieved successfully")
+}
+
+// GetUserRoles godoc
+// @Summary Get user roles
+// @Description List the roles granted to a user
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Success 200 {object} map[string]interface{} "User roles retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID"
+// @Failure 403 {object} map[string]interface{} "Missing roles:read permission"
+// @Failure 404 {object} map[string]interface{} "User not found"
+// @Router /api/v1/users/{id}/roles [get]
+func (h *Handler) GetUserRoles(c *app.RequestContext) {
+	id, err := h.GetParamUint(c, "id")
+	if err != nil {
+		h.SendValidationError(c, "Invalid user ID format")
+		return
+	}
+
+	roles, err := h.service.GetUserRoles(id)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, roles, "User roles retrieved successfully")
+}
+
+// AssignRole godoc
+// @Summary Grant role
+// @Description Grant a role to a user. The change applies to access tokens issued afterwards.
+// @Tags roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param request body models.RoleAssignRequest true "Role to grant"
+// @Success 200 {object} map[string]interface{} "Role granted successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid request data or user ID"
+// @Failure 403 {object} map[string]interface{} "Missing roles:manage permission"
+// @Failure 404 {object} map[string]interface{} "User or role not found"
+// @Router /api/v1/users/{id}/roles [post]
+func (h *Handler) AssignRole(c *app.RequestContext) {
+	id, err := h.GetParamUint(c, "id")
+	if err != nil {
+		h.SendValidationError(c, "Invalid user ID format")
+		return
+	}
+
+	var req models.RoleAssignRequest
+	if err := c.BindJSON(&req); err != nil || req.Role == "" {
+		h.SendValidationError(c, "Invalid request data format")
+		return
+	}
+
+	if err := h.service.AssignRole(id, req.Role); err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, nil, "Role granted successfully")
+}
+
+// RevokeRole godoc
+// @Summary Revoke role
+// @Description Remove a role from a user. The change applies to access tokens issued afterwards.
+// @Tags roles
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "User ID"
+// @Param role path string true "Role name"
+// @Success 200 {object} map[string]interface{} "Role revoked successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid user ID"
+// @Failure 403 {object} map[string]interface{} "Missing roles:manage permission"
+// @Failure 404 {object} map[string]interface{} "User or role not found"
+// @Router /api/v1/users/{id}/roles/{role} [delete]
+func (h *Handler) RevokeRole(c *app.RequestContext) {
+	id, err := h.GetParamUint(c, "id")
+	if err != nil {
+		h.SendValidationError(c, "Invalid user ID format")
+		return
+	}
+
+	if err := h.service.RevokeRole(id, c.Param("role")); err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, nil, "Role revoked successfully")
+}
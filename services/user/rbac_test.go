@@ -0,0 +1,95 @@
+package user
+
+import (
+	"fmt"
+	"testing"
+
+	"kube/internal/testutil"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+)
+
+func TestRolesAreReflectedInTheNextToken(t *testing.T) {
+	s := newTestService(t)
+	jane := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+
+	claims := parseAccessToken(t, s, passwordLogin(t, s, "jane", "correct horse battery").AccessToken)
+	if len(claims.Roles) != 1 || claims.Roles[0] != models.RoleUser {
+		t.Fatalf("roles of a new user = %v, want [%s]", claims.Roles, models.RoleUser)
+	}
+
+	if err := s.AssignRole(jane.ID, models.RoleModerator); err != nil {
+		t.Fatalf("AssignRole: %v", err)
+	}
+	// Granting twice is a no-op
+	if err := s.AssignRole(jane.ID, models.RoleModerator); err != nil {
+		t.Fatalf("AssignRole again: %v", err)
+	}
+	claims = parseAccessToken(t, s, passwordLogin(t, s, "jane", "correct horse battery").AccessToken)
+	if fmt.Sprint(claims.Roles) != "[moderator user]" || !contains(claims.Permissions, models.PermissionUsersRead) {
+		t.Errorf("roles = %v, permissions = %v after granting moderator", claims.Roles, claims.Permissions)
+	}
+
+	if err := s.RevokeRole(jane.ID, models.RoleModerator); err != nil {
+		t.Fatalf("RevokeRole: %v", err)
+	}
+	claims = parseAccessToken(t, s, passwordLogin(t, s, "jane", "correct horse battery").AccessToken)
+	if contains(claims.Permissions, models.PermissionUsersRead) {
+		t.Errorf("permissions = %v after revoking moderator", claims.Permissions)
+	}
+
+	testutil.AssertErrorCode(t, s.AssignRole(jane.ID, "superuser"), apperrors.ErrCodeRecordNotFound)
+	testutil.AssertErrorCode(t, s.AssignRole(jane.ID+100, models.RoleModerator), apperrors.ErrCodeUserNotFound)
+}
+
+func TestRBACRouteGuards(t *testing.T) {
+	s := newTestService(t)
+	router := newTestRouter(s)
+
+	jane := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	moderator := registerUser(t, s, "mod", "mod@example.com", "correct horse battery")
+	admin := registerUser(t, s, "alice", "alice@example.com", "correct horse battery")
+	if err := s.AssignRole(moderator.ID, models.RoleModerator); err != nil {
+		t.Fatal(err)
+	}
+	makeAdmin(t, s, admin.ID)
+
+	userAuth := bearer(passwordLogin(t, s, "jane", "correct horse battery"))
+	moderatorAuth := bearer(passwordLogin(t, s, "mod", "correct horse battery"))
+	adminAuth := bearer(passwordLogin(t, s, "alice", "correct horse battery"))
+	rolesPath := fmt.Sprintf("/api/v1/users/%d/roles", jane.ID)
+	grant := `{"role":"moderator"}`
+
+	for _, tt := range []struct {
+		name          string
+		method, path  string
+		body          string
+		authorization string
+		want          int
+	}{
+		{"user lists users", "GET", "/api/v1/users", "", userAuth, 403},
+		{"moderator lists users", "GET", "/api/v1/users", "", moderatorAuth, 200},
+		{"user lists roles", "GET", "/api/v1/roles", "", userAuth, 403},
+		{"admin lists roles", "GET", "/api/v1/roles", "", adminAuth, 200},
+		{"user reads roles", "GET", rolesPath, "", userAuth, 403},
+		{"user grants a role", "POST", rolesPath, grant, userAuth, 403},
+		{"moderator grants a role", "POST", rolesPath, grant, moderatorAuth, 403},
+		{"admin grants a role", "POST", rolesPath, grant, adminAuth, 200},
+		{"user reads the audit log", "GET", "/api/v1/audit-events", "", userAuth, 403},
+		{"admin revokes a role", "DELETE", rolesPath + "/moderator", "", adminAuth, 200},
+	} {
+		resp := performRequest(router, tt.method, tt.path, tt.body, tt.authorization)
+		if got := resp.StatusCode(); got != tt.want {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, got, tt.want, resp.Body())
+		}
+	}
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,28 @@
+package user
+
+import (
+	"kube/internal/middleware"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// RequestMeta describes the client behind a request. It feeds security
+// decisions such as login throttling and the audit log.
+type RequestMeta struct {
+	IP        string
+	UserAgent string
+	RequestID string
+	ActorID   uint // authenticated user, zero for anonymous requests
+}
+
+func requestMeta(c *app.RequestContext) RequestMeta {
+	meta := RequestMeta{
+		IP:        c.ClientIP(),
+		UserAgent: string(c.UserAgent()),
+		RequestID: c.GetString("request_id"),
+	}
+	if userID, ok := middleware.GetUserID(c); ok {
+		meta.ActorID = userID
+	}
+	return meta
+}
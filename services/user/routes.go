@@ -3,20 +3,81 @@ package user
 import (
 	"context"
 
+	"kube/internal/middleware"
+	"kube/pkg/models"
+
 	"github.com/cloudwego/hertz/pkg/app"
 	"github.com/cloudwego/hertz/pkg/app/server"
 )
 
-func RegisterRoutes(h *server.Hertz, service *Service) {
+func RegisterRoutes(h *server.Hertz, service *Service, authMiddleware app.HandlerFunc) {
 	handler := NewHandler(service)
 
+	// Public keys for services verifying access tokens
+	h.GET("/.well-known/jwks.json", func(ctx context.Context, c *app.RequestContext) { handler.JWKS(c) })
+
 	// User routes
 	api := h.Group("/api/v1/users")
 	{
+		// Credential management is not available to API keys
+		sessionOnly := middleware.RequireTokenAuth()
+
 		api.POST("/register", func(ctx context.Context, c *app.RequestContext) { handler.Register(c) })
 		api.POST("/login", func(ctx context.Context, c *app.RequestContext) { handler.Login(c) })
-		api.GET("/:id", func(ctx context.Context, c *app.RequestContext) { handler.GetUser(c) })
-		api.PUT("/:id", func(ctx context.Context, c *app.RequestContext) { handler.UpdateUser(c) })
-		api.DELETE("/:id", func(ctx context.Context, c *app.RequestContext) { handler.DeleteUser(c) })
+		api.POST("/login/mfa", func(ctx context.Context, c *app.RequestContext) { handler.CompleteMFALogin(c) })
+		api.GET("/oauth/:provider/authorize", func(ctx context.Context, c *app.RequestContext) { handler.StartOIDCLogin(c) })
+		api.GET("/oauth/:provider/callback", func(ctx context.Context, c *app.RequestContext) { handler.CompleteOIDCLogin(c) })
+		api.POST("/token/refresh", func(ctx context.Context, c *app.RequestContext) { handler.RefreshToken(c) })
+		api.GET("/verify", func(ctx context.Context, c *app.RequestContext) { handler.VerifyEmail(c) })
+		api.POST("/verify/resend", func(ctx context.Context, c *app.RequestContext) { handler.ResendVerification(c) })
+		api.POST("/password/forgot", func(ctx context.Context, c *app.RequestContext) { handler.ForgotPassword(c) })
+		api.POST("/password/reset", func(ctx context.Context, c *app.RequestContext) { handler.ResetPassword(c) })
+		api.POST("/logout", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.Logout(c) })
+		api.POST("/me/mfa/totp", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.EnrollTOTP(c) })
+		api.POST("/me/mfa/totp/confirm", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.ConfirmTOTP(c) })
+		api.DELETE("/me/mfa/totp", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.DisableTOTP(c) })
+		api.POST("/me/mfa/recovery-codes", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.RegenerateRecoveryCodes(c) })
+		api.GET("/me/sessions", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.ListSessions(c) })
+		api.DELETE("/me/sessions/:id", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.RevokeSession(c) })
+		api.PUT("/me/avatar", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.UploadAvatar(c) })
+		api.GET("/me/export", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.ExportUserData(c) })
+		api.POST("/me/erasure", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.EraseUser(c) })
+		api.GET("/me/api-keys", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.ListAPIKeys(c) })
+		api.POST("/me/api-keys", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.CreateAPIKey(c) })
+		api.GET("/me/api-keys/:keyId", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.GetAPIKey(c) })
+		api.PUT("/me/api-keys/:keyId", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.UpdateAPIKey(c) })
+		api.DELETE("/me/api-keys/:keyId", authMiddleware, sessionOnly, func(ctx context.Context, c *app.RequestContext) { handler.DeleteAPIKey(c) })
+
+		api.GET("", authMiddleware, middleware.RequirePermission(models.PermissionUsersRead), func(ctx context.Context, c *app.RequestContext) { handler.ListUsers(c) })
+		api.GET("/:id", authMiddleware, func(ctx context.Context, c *app.RequestContext) { handler.GetUser(c) })
+		api.PUT("/:id", authMiddleware, func(ctx context.Context, c *app.RequestContext) { handler.UpdateUser(c) })
+		api.PATCH("/:id", authMiddleware, func(ctx context.Context, c *app.RequestContext) { handler.PatchUser(c) })
+		api.DELETE("/:id", authMiddleware, func(ctx context.Context, c *app.RequestContext) { handler.DeleteUser(c) })
+		api.POST("/:id/restore", authMiddleware, middleware.RequirePermission(models.PermissionUsersRestore), func(ctx context.Context, c *app.RequestContext) { handler.RestoreUser(c) })
+		api.POST("/:id/unlock", authMiddleware, middleware.RequirePermission(models.PermissionUsersUnlock), func(ctx context.Context, c *app.RequestContext) { handler.UnlockUser(c) })
+
+		canReadRoles := middleware.RequirePermission(models.PermissionRolesRead)
+		canManageRoles := middleware.RequirePermission(models.PermissionRolesManage)
+		api.GET("/:id/roles", authMiddleware, canReadRoles, func(ctx context.Context, c *app.RequestContext) { handler.GetUserRoles(c) })
+		api.POST("/:id/roles", authMiddleware, canManageRoles, func(ctx context.Context, c *app.RequestContext) { handler.AssignRole(c) })
+		api.DELETE("/:id/roles/:role", authMiddleware, canManageRoles, func(ctx context.Context, c *app.RequestContext) { handler.RevokeRole(c) })
+	}
+
+	// Role routes
+	roles := h.Group("/api/v1/roles", authMiddleware)
+	{
+		roles.GET("", middleware.RequirePermission(models.PermissionRolesRead), func(ctx context.Context, c *app.RequestContext) { handler.ListRoles(c) })
+	}
+
+	// Internal routes called by the other services, not through the gateway
+	internal := h.Group("/api/v1/internal")
+	{
+		internal.POST("/api-keys/introspect", func(ctx context.Context, c *app.RequestContext) { handler.IntrospectAPIKey(c) })
+	}
+
+	// Audit log routes
+	audit := h.Group("/api/v1/audit-events", authMiddleware)
+	{
+		audit.GET("", middleware.RequirePermission(models.PermissionAuditRead), func(ctx context.Context, c *app.RequestContext) { handler.ListAuditEvents(c) })
 	}
 }
@@ -1,59 +1,135 @@
 package user
 
 import (
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
+	"kube/internal/auth"
+	"kube/internal/config"
+	"kube/internal/mailer"
+	"kube/internal/storage"
 	apperrors "kube/pkg/errors"
 	"kube/pkg/models"
 	"kube/pkg/services"
 
-	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
 type Service struct {
 	*services.BaseService
-	jwtSecret string
+	signer         auth.TokenSigner
+	passwords      auth.PasswordHasher
+	passwordPolicy *auth.PasswordPolicy
+	accessTTL      time.Duration
+	refreshTTL     time.Duration
+	authCfg        config.AuthConfig
+	appURL         string
+	revocations    auth.RevocationStore
+	attempts       auth.AttemptTracker
+	mailer         mailer.Mailer
+	oidc           map[string]*auth.OIDCProvider
+	oidcStates     auth.StateStore
+	oidcTTL        time.Duration
+	accountCfg     config.AccountConfig
+	avatarCfg      config.AvatarConfig
+	storage        *storage.Client
+	// background tracks work that outlives the request starting it, such
+	// as password reset emails
+	background sync.WaitGroup
 }
 
-func NewService(db *gorm.DB, jwtSecret string) *Service {
-	return &Service{
-		BaseService: services.NewBaseService(db),
-		jwtSecret:   jwtSecret,
+func NewService(db *gorm.DB, cfg *config.Config, opts ...Option) *Service {
+	s := &Service{
+		BaseService:    services.NewBaseService(db),
+		signer:         auth.NewHMACSigner(cfg.JWT.SecretKey),
+		passwords:      auth.NewBcryptHasher(bcrypt.DefaultCost),
+		passwordPolicy: auth.DefaultPasswordPolicy(),
+		accessTTL:      time.Duration(cfg.JWT.ExpiresIn) * time.Hour,
+		refreshTTL:     time.Duration(cfg.JWT.RefreshExpiresIn) * time.Hour,
+		authCfg:        cfg.Auth,
+		appURL:         cfg.Mail.AppURL,
+		revocations:    auth.NewMemoryRevocationStore(),
+		attempts:       auth.NewMemoryAttemptTracker(),
+		mailer:         mailer.NewLogMailer(cfg.Mail.From),
+		oidc:           make(map[string]*auth.OIDCProvider),
+		oidcStates:     auth.NewMemoryStateStore(),
+		oidcTTL:        time.Duration(cfg.OIDC.StateExpiresIn) * time.Minute,
+		accountCfg:     cfg.Account,
+		avatarCfg:      cfg.Avatar,
+		storage:        storage.Init(cfg),
 	}
+
+	for _, provider := range cfg.OIDC.Providers {
+		redirectURL := strings.TrimSuffix(cfg.Mail.AppURL, "/") + "/api/v1/users/oauth/" + provider.Name + "/callback"
+		s.oidc[provider.Name] = auth.NewOIDCProvider(provider, redirectURL)
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
-func (s *Service) CreateUser(req *models.UserCreateRequest) (*models.UserResponse, error) {
+func (s *Service) CreateUser(req *models.UserCreateRequest, meta RequestMeta) (*models.UserResponse, error) {
+	username := normalizeIdentifier(req.Username)
+	email := normalizeIdentifier(req.Email)
+	if err := validateUsername(username); err != nil {
+		return nil, err
+	}
+	if err := validateEmail(email); err != nil {
+		return nil, err
+	}
+
+	if err := s.passwordPolicy.Validate(req.Password, username, email); err != nil {
+		return nil, err
+	}
+
 	var user *models.User
 
 	err := s.WithTransaction(func(tx *gorm.DB) error {
+		// Deleted accounts keep their email and username until purged
 		var existingUser models.User
-		if err := tx.Where("email = ? OR username = ?", req.Email, req.Username).First(&existingUser).Error; err == nil {
+		if err := tx.Unscoped().Where("LOWER(email) = ? OR LOWER(username) = ?", email, username).First(&existingUser).Error; err == nil {
 			return apperrors.New(apperrors.ErrCodeUserAlreadyExists, "User already exists", "Email or username already registered")
 		}
 
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+		hashedPassword, err := s.passwords.Hash(req.Password)
 		if err != nil {
 			return apperrors.Wrap(err, apperrors.ErrCodeInternalError, "Password hashing failed", err.Error())
 		}
 
+		now := time.Now()
 		user = &models.User{
-			Username:  req.Username,
-			Email:     req.Email,
-			Password:  string(hashedPassword),
-			FirstName: req.FirstName,
-			LastName:  req.LastName,
-			IsActive:  true,
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+			Username:           username,
+			Email:              email,
+			Password:           hashedPassword,
+			FirstName:          req.FirstName,
+			LastName:           req.LastName,
+			IsActive:           true,
+			VerificationSentAt: &now,
+			CreatedAt:          now,
+			UpdatedAt:          now,
 		}
 
 		if err := tx.Create(user).Error; err != nil {
 			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to create user", err.Error())
 		}
 
-		return nil
+		if err := assignDefaultRole(tx, user); err != nil {
+			return err
+		}
+
+		event := newAuditEvent(models.AuditActionRegister, meta, user.ID, nil)
+		event.ActorID = optionalID(user.ID)
+		if err := appendAudit(tx, event); err != nil {
+			return err
+		}
+
+		return s.sendVerificationEmail(tx, user)
 	})
 
 	if err != nil {
@@ -63,87 +139,183 @@ func (s *Service) CreateUser(req *models.UserCreateRequest) (*models.UserRespons
 	return s.toUserResponse(user), nil
 }
 
+// assignDefaultRole grants the built-in user role to a new account
+func assignDefaultRole(tx *gorm.DB, user *models.User) error {
+	var defaultRole models.Role
+	if err := tx.Where("name = ?", models.RoleUser).First(&defaultRole).Error; err == nil {
+		if err := tx.Model(user).Association("Roles").Append(&defaultRole); err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to assign default role", err.Error())
+		}
+	}
+	return nil
+}
+
 func (s *Service) GetUserByID(id uint) (*models.UserResponse, error) {
 	var user models.User
-	if err := s.GetDB().First(&user, id).Error; err != nil {
+	if err := s.GetDB().Preload("Roles").First(&user, id).Error; err != nil {
 		return nil, apperrors.Wrap(err, apperrors.ErrCodeUserNotFound, "User not found", "User with ID "+string(rune(id))+" not found")
 	}
 	return s.toUserResponse(&user), nil
 }
 
-func (s *Service) UpdateUser(id uint, req *models.UserUpdateRequest) (*models.UserResponse, error) {
-	var user *models.User
-
-	err := s.WithTransaction(func(tx *gorm.DB) error {
-		if err := tx.First(&user, id).Error; err != nil {
-			return apperrors.Wrap(err, apperrors.ErrCodeUserNotFound, "User not found", "User with ID "+string(rune(id))+" not found")
+// UpdateUser replaces the editable profile fields. See updateProfile for
+// the meaning of expectedVersions.
+func (s *Service) UpdateUser(id uint, req *models.UserUpdateRequest, expectedVersions []int, meta RequestMeta) (*models.UserResponse, error) {
+	var dropped models.AvatarURLs
+	user, err := s.updateProfile(id, expectedVersions, meta, func(user *models.User) error {
+		if err := validateProfile(map[string]string{
+			"first_name": req.FirstName,
+			"last_name":  req.LastName,
+			"avatar":     req.Avatar,
+		}); err != nil {
+			return err
 		}
 
 		user.FirstName = req.FirstName
 		user.LastName = req.LastName
-		user.Avatar = req.Avatar
-		user.UpdatedAt = time.Now()
-
-		if err := tx.Save(user).Error; err != nil {
-			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to update user", err.Error())
-		}
-
+		dropped = setAvatar(user, req.Avatar)
 		return nil
 	})
-
 	if err != nil {
 		return nil, err
 	}
 
-	return s.toUserResponse(user), nil
+	s.deleteAvatarURLs(id, dropped)
+	return user, nil
 }
 
-func (s *Service) DeleteUser(id uint) error {
-	if err := s.GetDB().Delete(&models.User{}, id).Error; err != nil {
-		return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to delete user", err.Error())
+// DeleteUser soft-deletes the user and ends all their sessions. The account
+// can be restored until the grace period ends and is purged afterwards.
+func (s *Service) DeleteUser(id uint, meta RequestMeta) error {
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		result := tx.Delete(&models.User{}, id)
+		if result.Error != nil {
+			return apperrors.Wrap(result.Error, apperrors.ErrCodeDatabaseError, "Failed to delete user", result.Error.Error())
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.New(apperrors.ErrCodeUserNotFound, "User not found", "User with ID "+strconv.FormatUint(uint64(id), 10)+" not found")
+		}
+		return appendAudit(tx, newAuditEvent(models.AuditActionDelete, meta, id, nil))
+	})
+	if err != nil {
+		return err
 	}
-	return nil
+	return s.revokeAllSessions(id)
 }
 
-func (s *Service) Login(req *models.UserLoginRequest) (*models.UserResponse, string, error) {
+func (s *Service) Login(req *models.UserLoginRequest, meta RequestMeta) (*models.LoginResponse, error) {
+	if err := s.checkClientThrottle(meta.IP); err != nil {
+		return nil, err
+	}
+
+	identifier := req.Identifier
+	if identifier == "" {
+		identifier = req.Email
+	}
+	identifier = normalizeIdentifier(identifier)
+	if identifier == "" {
+		return nil, apperrors.New(apperrors.ErrCodeMissingRequired, "Validation failed", "Username or email is required")
+	}
+
+	// Usernames cannot contain "@", so the identifier is unambiguous
+	column := "LOWER(username)"
+	if strings.Contains(identifier, "@") {
+		column = "LOWER(email)"
+	}
+
 	var user models.User
-	if err := s.GetDB().Where("email = ?", req.Email).First(&user).Error; err != nil {
-		return nil, "", apperrors.New(apperrors.ErrCodeInvalidCredentials, "Invalid credentials", "Email or password is incorrect")
+	if err := s.GetDB().Where(column+" = ?", identifier).First(&user).Error; err != nil {
+		s.recordClientFailure(0, meta)
+		return nil, apperrors.New(apperrors.ErrCodeInvalidCredentials, "Invalid credentials", "Username, email or password is incorrect")
+	}
+
+	// Without the correct password a locked account answers like an unknown
+	// one, so that the lock does not reveal that the account exists. Failures
+	// while locked are not counted against the account, they would only
+	// extend the lock.
+	lockErr := checkAccountLock(&user)
+	if !s.checkPassword(&user, req.Password) {
+		if lockErr != nil {
+			s.recordClientFailure(user.ID, meta)
+		} else {
+			s.recordLoginFailure(user.ID, meta)
+		}
+		return nil, apperrors.New(apperrors.ErrCodeInvalidCredentials, "Invalid credentials", "Username, email or password is incorrect")
 	}
 
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
-		return nil, "", apperrors.New(apperrors.ErrCodeInvalidCredentials, "Invalid credentials", "Email or password is incorrect")
+	if lockErr != nil {
+		return nil, lockErr
 	}
 
 	if !user.IsActive {
-		return nil, "", apperrors.New(apperrors.ErrCodeAccountDeactivated, "Account deactivated", "Your account has been deactivated")
+		return nil, apperrors.New(apperrors.ErrCodeAccountDeactivated, "Account deactivated", "Your account has been deactivated")
 	}
 
-	// Generate JWT token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"user_id": user.ID,
-		"email":   user.Email,
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
-	})
+	if s.authCfg.RequireEmailVerification && user.EmailVerifiedAt == nil {
+		return nil, apperrors.New(apperrors.ErrCodeEmailNotVerified, "Email not verified", "Please verify your email address before logging in")
+	}
+
+	return s.finishLogin(&user, meta)
+}
+
+// finishLogin completes a login after the first factor has been verified:
+// accounts with MFA get a challenge, all others get a new session
+func (s *Service) finishLogin(user *models.User, meta RequestMeta) (*models.LoginResponse, error) {
+	if user.MFAEnabled {
+		challenge, err := s.issueMFAChallenge(user)
+		if err != nil {
+			return nil, apperrors.Wrap(err, apperrors.ErrCodeInternalError, "Failed to issue MFA challenge", err.Error())
+		}
+
+		return &models.LoginResponse{
+			User:        s.toUserResponse(user),
+			MFARequired: true,
+			MFAToken:    challenge,
+		}, nil
+	}
+
+	var tokens *models.TokenResponse
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		if err := resetLoginFailures(tx, user); err != nil {
+			return err
+		}
 
-	tokenString, err := token.SignedString([]byte(s.jwtSecret))
+		var err error
+		tokens, err = s.startSession(tx, user, meta)
+		return err
+	})
 	if err != nil {
-		return nil, "", err
+		return nil, err
 	}
 
-	return s.toUserResponse(&user), tokenString, nil
+	return &models.LoginResponse{
+		User:          s.toUserResponse(user),
+		TokenResponse: tokens,
+	}, nil
 }
 
 func (s *Service) toUserResponse(user *models.User) *models.UserResponse {
+	var roles []string
+	for _, role := range user.Roles {
+		roles = append(roles, role.Name)
+	}
+
 	return &models.UserResponse{
-		ID:        user.ID,
-		Username:  user.Username,
-		Email:     user.Email,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Avatar:    user.Avatar,
-		IsActive:  user.IsActive,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:              user.ID,
+		Username:        user.Username,
+		Email:           user.Email,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Avatar:          user.Avatar,
+		AvatarURLs:      user.AvatarURLs,
+		IsActive:        user.IsActive,
+		IsAdmin:         user.IsAdmin,
+		Roles:           roles,
+		EmailVerified:   user.EmailVerifiedAt != nil,
+		EmailVerifiedAt: user.EmailVerifiedAt,
+		MFAEnabled:      user.MFAEnabled,
+		Version:         user.Version,
+		CreatedAt:       user.CreatedAt,
+		UpdatedAt:       user.UpdatedAt,
 	}
 }
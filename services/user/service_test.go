@@ -0,0 +1,170 @@
+package user
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"kube/internal/auth"
+	"kube/internal/config"
+	"kube/internal/mailer"
+	"kube/internal/middleware"
+	"kube/internal/testutil"
+	"kube/pkg/models"
+
+	"github.com/cloudwego/hertz/pkg/app/server"
+	"github.com/cloudwego/hertz/pkg/common/ut"
+	"github.com/cloudwego/hertz/pkg/protocol"
+	"github.com/cloudwego/hertz/pkg/route"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// newTestService returns a service backed by a fresh SQLite database and
+// local storage in a temporary directory. opts adjust the configuration
+// before the service is built.
+func newTestService(t *testing.T, opts ...func(*config.Config)) *Service {
+	t.Helper()
+
+	cfg := &config.Config{
+		JWT:     config.JWTConfig{SecretKey: "test-secret", ExpiresIn: 1, RefreshExpiresIn: 24},
+		Mail:    config.MailConfig{AppURL: "http://app.test"},
+		Storage: testutil.StorageConfig(t.TempDir()),
+		Avatar:  config.AvatarConfig{MaxSize: 1 << 20, MaxDimension: 1024},
+		Auth:    config.AuthConfig{MFAChallengeExpiresIn: 5},
+		OIDC:    config.OIDCConfig{StateExpiresIn: 10},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	s := NewService(testutil.NewDB(t), cfg, WithPasswordHasher(auth.NewBcryptHasher(bcrypt.MinCost)))
+	if err := s.SeedRoles(); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func registerUser(t *testing.T, s *Service, username, email, password string) *models.UserResponse {
+	t.Helper()
+	user, err := s.CreateUser(&models.UserCreateRequest{Username: username, Email: email, Password: password}, RequestMeta{})
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	return user
+}
+
+func passwordLogin(t *testing.T, s *Service, identifier, password string) *models.TokenResponse {
+	t.Helper()
+	resp, err := s.Login(&models.UserLoginRequest{Identifier: identifier, Password: password}, RequestMeta{})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	return resp.TokenResponse
+}
+
+func createAPIKey(t *testing.T, s *Service, userID uint) string {
+	t.Helper()
+	key, err := s.CreateAPIKey(userID, &models.APIKeyCreateRequest{Name: "ci", Scopes: []string{models.PermissionVideosUpload}})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+	return key.Key
+}
+
+// isRevoked checks an access token the way the auth middleware does
+func isRevoked(t *testing.T, s *Service, accessToken string) bool {
+	t.Helper()
+
+	claims := parseAccessToken(t, s, accessToken)
+	revoked, err := auth.IsClaimsRevoked(context.Background(), s.revocations, claims.ID, claims.SessionID, claims.UserID, claims.IssueTime())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return revoked
+}
+
+// recordingMailer keeps sent emails for inspection
+type recordingMailer struct {
+	mu   sync.Mutex
+	sent []*mailer.Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg *mailer.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func (m *recordingMailer) messages() []*mailer.Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*mailer.Message(nil), m.sent...)
+}
+
+// withRecordingMailer replaces the mailer of s and returns the recorder
+func withRecordingMailer(s *Service) *recordingMailer {
+	m := &recordingMailer{}
+	s.mailer = m
+	return m
+}
+
+var mailTokenPattern = regexp.MustCompile(`token=([^\s]+)`)
+
+// mailToken returns the token of the link in msg
+func mailToken(t *testing.T, msg *mailer.Message) string {
+	t.Helper()
+	match := mailTokenPattern.FindStringSubmatch(msg.Body)
+	if match == nil {
+		t.Fatalf("no token in email %q", msg.Body)
+	}
+	token, err := url.QueryUnescape(match[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// newTestRouter serves the user routes of s behind the auth middleware the
+// user service runs with
+func newTestRouter(s *Service) *route.Engine {
+	h := server.New()
+	RegisterRoutes(h, s, middleware.AuthMiddleware("",
+		middleware.WithTokenVerifier(s.signer),
+		middleware.WithRevocationStore(s.revocations),
+		middleware.WithAPIKeyValidator(s),
+	))
+	return h.Engine
+}
+
+// performRequest sends a JSON request with the given Authorization header
+// value and extra header pairs
+func performRequest(engine *route.Engine, method, path, body, authorization string, headers ...string) *protocol.Response {
+	list := []ut.Header{{Key: "Content-Type", Value: "application/json"}}
+	if authorization != "" {
+		list = append(list, ut.Header{Key: "Authorization", Value: authorization})
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		list = append(list, ut.Header{Key: headers[i], Value: headers[i+1]})
+	}
+	var reqBody *ut.Body
+	if body != "" {
+		reqBody = &ut.Body{Body: strings.NewReader(body), Len: len(body)}
+	}
+	return ut.PerformRequest(engine, method, path, reqBody, list...).Result()
+}
+
+func bearer(tokens *models.TokenResponse) string {
+	return "Bearer " + tokens.AccessToken
+}
+
+// makeAdmin grants the admin role; tokens issued afterwards carry it
+func makeAdmin(t *testing.T, s *Service, userID uint) {
+	t.Helper()
+	if err := s.AssignRole(userID, models.RoleAdmin); err != nil {
+		t.Fatalf("AssignRole: %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ListSessions returns the active sessions of the user, most recently used
+// first. currentSessionID marks the session making the request.
+func (s *Service) ListSessions(userID uint, currentSessionID string) ([]models.SessionResponse, error) {
+	var sessions []models.Session
+	if err := s.GetDB().
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to list sessions", err.Error())
+	}
+
+	responses := make([]models.SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		responses = append(responses, models.SessionResponse{
+			ID:         session.ID,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == currentSessionID,
+		})
+	}
+	return responses, nil
+}
+
+// RevokeSession ends one of the user's sessions. Its refresh tokens stop
+// working and its access tokens are rejected from now on.
+func (s *Service) RevokeSession(userID uint, sessionID string) error {
+	var session models.Session
+	if err := s.GetDB().Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).First(&session).Error; err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeRecordNotFound, "Session not found", "Session does not exist or has already ended")
+	}
+
+	return s.endSession(userID, sessionID)
+}
+
+// endSession revokes the session and its refresh token family and puts the
+// session ID on the revocation list for the lifetime of an access token
+func (s *Service) endSession(userID uint, sessionID string) error {
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		return revokeSession(tx.Where("user_id = ?", userID), sessionID)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.revocations.Revoke(context.Background(), sessionID, time.Now().Add(s.accessTTL)); err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeServiceUnavailable, "Failed to revoke session", err.Error())
+	}
+	return nil
+}
+
+// startSession records a new session for a successful login, logs the login
+// in the audit log and issues the first token pair of its refresh token
+// family
+func (s *Service) startSession(tx *gorm.DB, user *models.User, meta RequestMeta) (*models.TokenResponse, error) {
+	now := time.Now()
+	session := &models.Session{
+		ID:         uuid.New().String(),
+		UserID:     user.ID,
+		UserAgent:  truncate(meta.UserAgent, 512),
+		IP:         meta.IP,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(s.refreshTTL),
+	}
+
+	if err := tx.Create(session).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to create session", err.Error())
+	}
+
+	event := newAuditEvent(models.AuditActionLoginSuccess, meta, user.ID, nil)
+	event.ActorID = optionalID(user.ID)
+	if err := appendAudit(tx, event); err != nil {
+		return nil, err
+	}
+
+	return s.issueTokens(tx, user, session.ID)
+}
+
+// touchSession records activity on a session when its tokens are refreshed
+func touchSession(tx *gorm.DB, sessionID string, meta RequestMeta, expiresAt time.Time) error {
+	if err := tx.Model(&models.Session{}).Where("id = ?", sessionID).Updates(map[string]interface{}{
+		"last_seen_at": time.Now(),
+		"ip":           meta.IP,
+		"user_agent":   truncate(meta.UserAgent, 512),
+		"expires_at":   expiresAt,
+	}).Error; err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to update session", err.Error())
+	}
+	return nil
+}
+
+// revokeSession marks the session as revoked and revokes its refresh tokens.
+// tx may carry extra conditions, such as the owning user.
+func revokeSession(tx *gorm.DB, sessionID string) error {
+	if err := tx.Session(&gorm.Session{}).Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", sessionID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to revoke session", err.Error())
+	}
+
+	return revokeTokenFamily(tx.Session(&gorm.Session{}), sessionID)
+}
+
+func truncate(value string, max int) string {
+	if len(value) > max {
+		return value[:max]
+	}
+	return value
+}
@@ -0,0 +1,61 @@
+package user
+
+import (
+	"kube/internal/middleware"
+	"kube/pkg/errors"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// ListSessions godoc
+// @Summary List active sessions
+// @Description List the devices the authenticated user is logged in on. The session of the current request is marked as current.
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} map[string]interface{} "Sessions retrieved successfully"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Called with an API key"
+// @Router /api/v1/users/me/sessions [get]
+func (h *Handler) ListSessions(c *app.RequestContext) {
+	claims, ok := middleware.GetClaims(c)
+	if !ok {
+		h.SendUnauthorized(c, "Authentication required")
+		return
+	}
+
+	sessions, err := h.service.ListSessions(claims.UserID, claims.SessionID)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, sessions, "Sessions retrieved successfully")
+}
+
+// RevokeSession godoc
+// @Summary Revoke session
+// @Description Log out a device. Its refresh token stops working and its access tokens are rejected immediately.
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} map[string]interface{} "Session revoked successfully"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Called with an API key"
+// @Failure 404 {object} map[string]interface{} "Session not found"
+// @Router /api/v1/users/me/sessions/{id} [delete]
+func (h *Handler) RevokeSession(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	if err := h.service.RevokeSession(userID, c.Param("id")); err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, nil, "Session revoked successfully")
+}
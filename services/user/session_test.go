@@ -0,0 +1,102 @@
+package user
+
+import (
+	"testing"
+
+	"kube/internal/testutil"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+)
+
+func loginFrom(t *testing.T, s *Service, identifier, userAgent string) *models.TokenResponse {
+	t.Helper()
+	resp, err := s.Login(&models.UserLoginRequest{Identifier: identifier, Password: "correct horse battery"}, RequestMeta{IP: "10.0.0.1", UserAgent: userAgent})
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	return resp.TokenResponse
+}
+
+func TestListSessions(t *testing.T) {
+	s := newTestService(t)
+	jane := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	registerUser(t, s, "bob", "bob@example.com", "correct horse battery")
+
+	laptop := loginFrom(t, s, "jane", "laptop")
+	phone := loginFrom(t, s, "jane", "phone")
+	loginFrom(t, s, "bob", "desktop")
+	// Refreshing records the latest client of the session
+	if _, err := s.RefreshTokens(&models.TokenRefreshRequest{RefreshToken: phone.RefreshToken}, RequestMeta{IP: "10.0.0.2", UserAgent: "phone"}); err != nil {
+		t.Fatal(err)
+	}
+
+	current := parseAccessToken(t, s, laptop.AccessToken).SessionID
+	sessions, err := s.ListSessions(jane.ID, current)
+	if err != nil {
+		t.Fatalf("ListSessions: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("listed %d sessions, want 2", len(sessions))
+	}
+	// Most recently used first
+	if sessions[0].UserAgent != "phone" || sessions[0].Current || sessions[1].UserAgent != "laptop" || !sessions[1].Current {
+		t.Errorf("unexpected sessions %+v", sessions)
+	}
+	if sessions[0].IP != "10.0.0.2" {
+		t.Errorf("IP = %q, want 10.0.0.2", sessions[0].IP)
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	s := newTestService(t)
+	jane := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	bob := registerUser(t, s, "bob", "bob@example.com", "correct horse battery")
+
+	laptop := loginFrom(t, s, "jane", "laptop")
+	phone := loginFrom(t, s, "jane", "phone")
+	bobs := loginFrom(t, s, "bob", "desktop")
+	phoneSession := parseAccessToken(t, s, phone.AccessToken).SessionID
+
+	if err := s.RevokeSession(jane.ID, phoneSession); err != nil {
+		t.Fatalf("RevokeSession: %v", err)
+	}
+	if !isRevoked(t, s, phone.AccessToken) {
+		t.Error("access token of the revoked session is still valid")
+	}
+	_, err := refresh(s, phone.RefreshToken)
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeTokenInvalid)
+	if isRevoked(t, s, laptop.AccessToken) {
+		t.Error("access token of the remaining session revoked")
+	}
+
+	sessions, err := s.ListSessions(jane.ID, "")
+	if err != nil || len(sessions) != 1 || sessions[0].UserAgent != "laptop" {
+		t.Errorf("sessions after revoking = %+v, %v", sessions, err)
+	}
+
+	testutil.AssertErrorCode(t, s.RevokeSession(jane.ID, phoneSession), apperrors.ErrCodeRecordNotFound)
+
+	// Sessions of other users cannot be ended
+	bobSession := parseAccessToken(t, s, bobs.AccessToken).SessionID
+	testutil.AssertErrorCode(t, s.RevokeSession(jane.ID, bobSession), apperrors.ErrCodeRecordNotFound)
+	if isRevoked(t, s, bobs.AccessToken) {
+		t.Error("session of another user revoked")
+	}
+	if sessions, _ := s.ListSessions(bob.ID, ""); len(sessions) != 1 {
+		t.Errorf("other user has %d sessions, want 1", len(sessions))
+	}
+}
+
+func TestSessionRoutesRejectAPIKeys(t *testing.T) {
+	s := newTestService(t)
+	router := newTestRouter(s)
+	jane := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	login := passwordLogin(t, s, "jane", "correct horse battery")
+
+	if got := performRequest(router, "GET", "/api/v1/users/me/sessions", "", bearer(login)).StatusCode(); got != 200 {
+		t.Errorf("listing with a login session: status %d, want 200", got)
+	}
+	if got := performRequest(router, "GET", "/api/v1/users/me/sessions", "", "ApiKey "+createAPIKey(t, s, jane.ID)).StatusCode(); got != 403 {
+		t.Errorf("listing with an API key: status %d, want 403", got)
+	}
+}
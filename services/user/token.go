@@ -0,0 +1,227 @@
+package user
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"kube/internal/auth"
+	"kube/internal/middleware"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const refreshTokenBytes = 32
+
+// RefreshTokens exchanges a refresh token for a new token pair. The presented
+// token is consumed; presenting it again revokes every token in its family.
+func (s *Service) RefreshTokens(req *models.TokenRefreshRequest, meta RequestMeta) (*models.TokenResponse, error) {
+	var tokens *models.TokenResponse
+	var reusedSession string
+
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		var stored models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", hashToken(req.RefreshToken)).
+			First(&stored).Error; err != nil {
+			return apperrors.New(apperrors.ErrCodeTokenInvalid, "Invalid refresh token", "Refresh token is invalid")
+		}
+
+		// A consumed or revoked token being presented again means it has
+		// leaked; the family is revoked but the transaction still commits.
+		if stored.UsedAt != nil || stored.RevokedAt != nil {
+			reusedSession = stored.FamilyID
+			return revokeSession(tx, stored.FamilyID)
+		}
+
+		if time.Now().After(stored.ExpiresAt) {
+			return apperrors.New(apperrors.ErrCodeTokenExpired, "Refresh token expired", "Please log in again")
+		}
+
+		var user models.User
+		if err := tx.First(&user, stored.UserID).Error; err != nil {
+			return apperrors.New(apperrors.ErrCodeTokenInvalid, "Invalid refresh token", "Refresh token owner no longer exists")
+		}
+
+		if !user.IsActive {
+			return apperrors.New(apperrors.ErrCodeAccountDeactivated, "Account deactivated", "Your account has been deactivated")
+		}
+
+		if err := tx.Model(&stored).Update("used_at", time.Now()).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to rotate refresh token", err.Error())
+		}
+
+		if err := touchSession(tx, stored.FamilyID, meta, time.Now().Add(s.refreshTTL)); err != nil {
+			return err
+		}
+
+		var err error
+		tokens, err = s.issueTokens(tx, &user, stored.FamilyID)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if reusedSession != "" {
+		// The refresh tokens are revoked, but access tokens of the stolen
+		// family stay valid until this succeeds
+		if err := s.revocations.Revoke(context.Background(), reusedSession, time.Now().Add(s.accessTTL)); err != nil {
+			return nil, apperrors.Wrap(err, apperrors.ErrCodeServiceUnavailable, "Failed to revoke session", err.Error())
+		}
+		return nil, apperrors.New(apperrors.ErrCodeTokenInvalid, "Refresh token reuse detected", "All sessions issued from this login have been revoked")
+	}
+
+	return tokens, nil
+}
+
+// issueTokens creates a signed access token and a new refresh token in the
+// given family
+func (s *Service) issueTokens(tx *gorm.DB, user *models.User, familyID string) (*models.TokenResponse, error) {
+	roles, permissions, err := loadAuthorization(tx, user.ID)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to load user roles", err.Error())
+	}
+
+	accessToken, err := s.generateAccessToken(user, familyID, roles, permissions)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeInternalError, "Failed to sign access token", err.Error())
+	}
+
+	refreshToken, err := generateOpaqueToken(refreshTokenBytes)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeInternalError, "Failed to generate refresh token", err.Error())
+	}
+
+	record := &models.RefreshToken{
+		UserID:    user.ID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: time.Now().Add(s.refreshTTL),
+		CreatedAt: time.Now(),
+	}
+
+	if err := tx.Create(record).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to store refresh token", err.Error())
+	}
+
+	return &models.TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(s.accessTTL.Seconds()),
+	}, nil
+}
+
+// generateAccessToken signs a JWT for the user. The refresh token family
+// doubles as the session ID so that logout can end the whole session.
+func (s *Service) generateAccessToken(user *models.User, sessionID string, roles, permissions []string) (string, error) {
+	now := time.Now()
+	return s.signer.Sign(middleware.Claims{
+		UserID:        user.ID,
+		Email:         user.Email,
+		SessionID:     sessionID,
+		IsAdmin:       user.IsAdmin,
+		EmailVerified: user.EmailVerifiedAt != nil,
+		Roles:         roles,
+		Permissions:   permissions,
+		IssuedAtMilli: now.UnixMilli(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(s.accessTTL)),
+		},
+	})
+}
+
+// JWKS returns the public keys that verify access tokens. It is empty when
+// tokens are signed with a shared HS256 secret.
+func (s *Service) JWKS() *auth.JWKS {
+	if keys, ok := s.signer.(*auth.KeySet); ok {
+		return keys.JWKS()
+	}
+	return &auth.JWKS{Keys: []auth.JWK{}}
+}
+
+// Logout ends the session the access token belongs to: its refresh tokens
+// are revoked and every access token carrying the session ID is rejected.
+// When all is set, every session of the user is ended instead.
+func (s *Service) Logout(claims *middleware.Claims, all bool) error {
+	if all {
+		return s.revokeAllSessions(claims.UserID)
+	}
+
+	if claims.SessionID != "" {
+		return s.endSession(claims.UserID, claims.SessionID)
+	}
+
+	if claims.ID == "" {
+		return nil
+	}
+
+	if err := s.revocations.Revoke(context.Background(), claims.ID, time.Now().Add(s.accessTTL)); err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeServiceUnavailable, "Failed to revoke token", err.Error())
+	}
+	return nil
+}
+
+// revokeAllSessions revokes every session and refresh token of the user and
+// rejects all access tokens issued to them so far
+func (s *Service) revokeAllSessions(userID uint) error {
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(&models.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to revoke sessions", err.Error())
+		}
+
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", now).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to revoke refresh tokens", err.Error())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := s.revocations.RevokeUser(context.Background(), userID, time.Now().Add(s.accessTTL)); err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeServiceUnavailable, "Failed to revoke tokens", err.Error())
+	}
+	return nil
+}
+
+func revokeTokenFamily(tx *gorm.DB, familyID string) error {
+	if err := tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to revoke refresh tokens", err.Error())
+	}
+	return nil
+}
+
+// generateOpaqueToken returns a URL-safe random token of n bytes of entropy
+func generateOpaqueToken(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashToken returns the hex SHA-256 digest used to store opaque tokens
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,173 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"kube/internal/auth"
+	"kube/internal/middleware"
+	"kube/internal/testutil"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// unavailableRevocationStore fails every write, like Redis being down
+type unavailableRevocationStore struct {
+	*auth.MemoryRevocationStore
+}
+
+func (unavailableRevocationStore) Revoke(ctx context.Context, tokenID string, expiresAt time.Time) error {
+	return errors.New("connection refused")
+}
+
+func (unavailableRevocationStore) RevokeUser(ctx context.Context, userID uint, expiresAt time.Time) error {
+	return errors.New("connection refused")
+}
+
+// parseAccessToken returns the claims of an access token issued by s
+func parseAccessToken(t *testing.T, s *Service, accessToken string) *middleware.Claims {
+	t.Helper()
+	claims := &middleware.Claims{}
+	if _, err := jwt.ParseWithClaims(accessToken, claims, s.signer.Keyfunc, jwt.WithValidMethods(s.signer.Algorithms())); err != nil {
+		t.Fatalf("parse access token: %v", err)
+	}
+	return claims
+}
+
+func refresh(s *Service, refreshToken string) (*models.TokenResponse, error) {
+	return s.RefreshTokens(&models.TokenRefreshRequest{RefreshToken: refreshToken}, RequestMeta{})
+}
+
+func TestRefreshReuseFailsWhenRevocationFails(t *testing.T) {
+	s := newTestService(t)
+	registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	stolen := passwordLogin(t, s, "jane", "correct horse battery")
+
+	if _, err := refresh(s, stolen.RefreshToken); err != nil {
+		t.Fatalf("RefreshTokens: %v", err)
+	}
+
+	s.revocations = unavailableRevocationStore{auth.NewMemoryRevocationStore()}
+	_, err := refresh(s, stolen.RefreshToken)
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeServiceUnavailable)
+}
+
+func TestLogoutAllKeepsTokensIssuedAfterwards(t *testing.T) {
+	s := newTestService(t)
+	registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	before := passwordLogin(t, s, "jane", "correct horse battery")
+	time.Sleep(2 * time.Millisecond)
+
+	if err := s.Logout(parseAccessToken(t, s, before.AccessToken), true); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	// Most likely within the same second as the cut-off
+	after := passwordLogin(t, s, "jane", "correct horse battery")
+
+	if !isRevoked(t, s, before.AccessToken) {
+		t.Error("token issued before logging out everywhere is still valid")
+	}
+	if isRevoked(t, s, after.AccessToken) {
+		t.Error("token issued after logging out everywhere is revoked")
+	}
+
+	claims := parseAccessToken(t, s, after.AccessToken)
+	if claims.IssuedAtMilli == 0 || claims.IssuedAt.Unix() != claims.IssuedAtMilli/1000 {
+		t.Errorf("iat = %v, iat_ms = %d, want matching issue times", claims.IssuedAt, claims.IssuedAtMilli)
+	}
+}
+
+func TestRefreshTokensRotates(t *testing.T) {
+	s := newTestService(t)
+	registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	login := passwordLogin(t, s, "jane", "correct horse battery")
+
+	rotated, err := refresh(s, login.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshTokens: %v", err)
+	}
+	if rotated.RefreshToken == login.RefreshToken || rotated.AccessToken == login.AccessToken {
+		t.Fatal("refresh returned the same tokens")
+	}
+	if before, after := parseAccessToken(t, s, login.AccessToken), parseAccessToken(t, s, rotated.AccessToken); before.SessionID != after.SessionID {
+		t.Errorf("session changed from %q to %q on refresh", before.SessionID, after.SessionID)
+	}
+
+	if _, err := refresh(s, rotated.RefreshToken); err != nil {
+		t.Errorf("rotated refresh token rejected: %v", err)
+	}
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	s := newTestService(t)
+	registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	stolen := passwordLogin(t, s, "jane", "correct horse battery")
+	other := passwordLogin(t, s, "jane", "correct horse battery")
+
+	rotated, err := refresh(s, stolen.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshTokens: %v", err)
+	}
+
+	_, err = refresh(s, stolen.RefreshToken)
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeTokenInvalid)
+
+	// The legitimate holder is logged out as well
+	_, err = refresh(s, rotated.RefreshToken)
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeTokenInvalid)
+	if !isRevoked(t, s, rotated.AccessToken) {
+		t.Error("access token of the reused family is still valid")
+	}
+
+	// Other logins are untouched
+	if isRevoked(t, s, other.AccessToken) {
+		t.Error("access token of another session revoked")
+	}
+	if _, err := refresh(s, other.RefreshToken); err != nil {
+		t.Errorf("refresh token of another session rejected: %v", err)
+	}
+}
+
+func TestRefreshTokensRejectsExpiredAndUnknownTokens(t *testing.T) {
+	s := newTestService(t)
+	registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	login := passwordLogin(t, s, "jane", "correct horse battery")
+
+	_, err := refresh(s, "not-a-refresh-token")
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeTokenInvalid)
+
+	if err := s.GetDB().Model(&models.RefreshToken{}).Where("token_hash = ?", hashToken(login.RefreshToken)).
+		Update("expires_at", time.Now().Add(-time.Minute)).Error; err != nil {
+		t.Fatal(err)
+	}
+	_, err = refresh(s, login.RefreshToken)
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeTokenExpired)
+}
+
+func TestLogoutEndsOnlyTheCurrentSession(t *testing.T) {
+	s := newTestService(t)
+	registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+	current := passwordLogin(t, s, "jane", "correct horse battery")
+	other := passwordLogin(t, s, "jane", "correct horse battery")
+
+	if err := s.Logout(parseAccessToken(t, s, current.AccessToken), false); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+
+	if !isRevoked(t, s, current.AccessToken) {
+		t.Error("access token still valid after logout")
+	}
+	_, err := refresh(s, current.RefreshToken)
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeTokenInvalid)
+
+	if isRevoked(t, s, other.AccessToken) {
+		t.Error("access token of another session revoked")
+	}
+	if _, err := refresh(s, other.RefreshToken); err != nil {
+		t.Errorf("refresh token of another session rejected: %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"kube/internal/mailer"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"gorm.io/gorm"
+)
+
+// VerifyEmail marks the email of the token owner as verified
+func (s *Service) VerifyEmail(token string) (*models.UserResponse, error) {
+	var user models.User
+
+	err := s.WithTransaction(func(tx *gorm.DB) error {
+		stored, err := consumeUserToken(tx, token, models.TokenPurposeEmailVerification)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.First(&user, stored.UserID).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeUserNotFound, "User not found", "User does not exist")
+		}
+
+		if user.EmailVerifiedAt != nil {
+			return nil
+		}
+
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		if err := tx.Model(&user).Update("email_verified_at", now).Error; err != nil {
+			return apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to verify email", err.Error())
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return s.toUserResponse(&user), nil
+}
+
+// ResendVerification emails a new verification link. Unknown, already
+// verified and throttled addresses are ignored silently, so that callers
+// cannot probe for accounts. The throttle claims the send slot with a
+// conditional update, so concurrent requests send at most one email.
+func (s *Service) ResendVerification(req *models.EmailVerificationResendRequest) error {
+	var user models.User
+	if err := s.GetDB().Where("LOWER(email) = ?", normalizeIdentifier(req.Email)).First(&user).Error; err != nil || user.EmailVerifiedAt != nil {
+		return nil
+	}
+
+	now := time.Now()
+	interval := time.Duration(s.authCfg.VerificationResendInterval) * time.Second
+	result := s.GetDB().Model(&models.User{}).
+		Where("id = ? AND email_verified_at IS NULL AND (verification_sent_at IS NULL OR verification_sent_at <= ?)", user.ID, now.Add(-interval)).
+		UpdateColumn("verification_sent_at", now)
+	if result.Error != nil {
+		return apperrors.Wrap(result.Error, apperrors.ErrCodeDatabaseError, "Failed to send verification email", result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return nil
+	}
+
+	return s.sendVerificationEmail(s.GetDB(), &user)
+}
+
+// sendVerificationEmail issues a verification token in tx and emails it.
+// Delivery failures are logged so that registration is not rolled back.
+func (s *Service) sendVerificationEmail(tx *gorm.DB, user *models.User) error {
+	ttlHours := s.authCfg.EmailVerificationExpiresIn
+	token, err := s.createUserToken(tx, user.ID, models.TokenPurposeEmailVerification, time.Duration(ttlHours)*time.Hour)
+	if err != nil {
+		return err
+	}
+
+	link := s.appURL + "/api/v1/users/verify?token=" + url.QueryEscape(token)
+	msg := &mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening the link below. It expires in %d hours.\n\n%s\n",
+			user.Username, ttlHours, link),
+	}
+
+	if err := s.mailer.Send(context.Background(), msg); err != nil {
+		hlog.Errorf("Failed to send verification email to user %d: %v", user.ID, err)
+	}
+	return nil
+}
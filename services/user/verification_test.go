@@ -0,0 +1,90 @@
+package user
+
+import (
+	"testing"
+	"time"
+
+	"kube/internal/config"
+	"kube/internal/testutil"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+)
+
+func withRequiredVerification(cfg *config.Config) {
+	cfg.Auth.RequireEmailVerification = true
+	cfg.Auth.EmailVerificationExpiresIn = 48
+	cfg.Auth.VerificationResendInterval = 60
+}
+
+func TestEmailVerification(t *testing.T) {
+	s := newTestService(t, withRequiredVerification)
+	mail := withRecordingMailer(s)
+	registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+
+	sent := mail.messages()
+	if len(sent) != 1 || sent[0].To != "jane@example.com" {
+		t.Fatalf("sent %d emails on registration, want one to jane@example.com", len(sent))
+	}
+
+	_, err := s.Login(&models.UserLoginRequest{Identifier: "jane", Password: "correct horse battery"}, RequestMeta{})
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeEmailNotVerified)
+
+	token := mailToken(t, sent[0])
+	user, err := s.VerifyEmail(token)
+	if err != nil {
+		t.Fatalf("VerifyEmail: %v", err)
+	}
+	if user.EmailVerifiedAt == nil {
+		t.Error("email not marked as verified")
+	}
+
+	claims := parseAccessToken(t, s, passwordLogin(t, s, "jane", "correct horse battery").AccessToken)
+	if !claims.EmailVerified {
+		t.Error("access token does not carry the verified email")
+	}
+
+	_, err = s.VerifyEmail(token)
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeTokenInvalid)
+}
+
+func TestResendVerification(t *testing.T) {
+	s := newTestService(t, withRequiredVerification)
+	mail := withRecordingMailer(s)
+	jane := registerUser(t, s, "jane", "jane@example.com", "correct horse battery")
+
+	resend := func(email string) {
+		t.Helper()
+		if err := s.ResendVerification(&models.EmailVerificationResendRequest{Email: email}); err != nil {
+			t.Fatalf("ResendVerification(%q) = %v, want the same answer for every address", email, err)
+		}
+	}
+
+	// Within the interval of the registration email, and unknown addresses
+	resend("jane@example.com")
+	resend("nobody@example.com")
+	if n := len(mail.messages()); n != 1 {
+		t.Fatalf("sent %d emails, want only the registration email", n)
+	}
+
+	if err := s.GetDB().Model(&models.User{}).Where("id = ?", jane.ID).
+		Update("verification_sent_at", time.Now().Add(-time.Hour)).Error; err != nil {
+		t.Fatal(err)
+	}
+	resend("JANE@example.com")
+	sent := mail.messages()
+	if len(sent) != 2 {
+		t.Fatalf("sent %d emails, want a second one after the interval", len(sent))
+	}
+
+	if _, err := s.VerifyEmail(mailToken(t, sent[1])); err != nil {
+		t.Fatalf("VerifyEmail with the resent link: %v", err)
+	}
+	if err := s.GetDB().Model(&models.User{}).Where("id = ?", jane.ID).
+		Update("verification_sent_at", time.Now().Add(-time.Hour)).Error; err != nil {
+		t.Fatal(err)
+	}
+	resend("jane@example.com")
+	if n := len(mail.messages()); n != 2 {
+		t.Errorf("sent %d emails, want none to a verified address", n)
+	}
+}
@@ -0,0 +1,244 @@
+package videoupload
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"strconv"
+
+	"kube/internal/middleware"
+	"kube/pkg/errors"
+	"kube/pkg/handlers"
+	"kube/pkg/models"
+
+	"github.com/cloudwego/hertz/pkg/app"
+)
+
+// maxFieldSize limits the text fields of an upload form
+const maxFieldSize = 64 << 10
+
+// maxFormOverhead is the room left for form fields and multipart headers
+// when checking the declared size of an upload against the video size limit
+const maxFormOverhead = 1 << 20
+
+type Handler struct {
+	*handlers.BaseHandler
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		BaseHandler: handlers.NewBaseHandler(),
+		service:     service,
+	}
+}
+
+// videoListSpec whitelists the sort fields and filters of video listings
+var videoListSpec = handlers.ListSpec{
+	SortFields:  []string{"created_at"},
+	DefaultDesc: true,
+	Filters: map[string]handlers.FilterType{
+		"channel_id": handlers.FilterUint,
+		"status":     handlers.FilterString,
+	},
+}
+
+// UploadVideo godoc
+// @Summary Upload a video
+// @Description Upload an MP4, WebM or AVI video to a channel. The body is streamed to storage as it arrives, so channel_id and title must come before the file in the form. Requires the videos:upload permission and the videos:manage channel permission (owner, manager or editor). The type is detected from the file content. The video is returned with status uploaded, its size and SHA-256 checksum.
+// @Tags videos
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param channel_id formData int true "Channel ID"
+// @Param title formData string true "Title, at most 100 characters"
+// @Param description formData string false "Description, at most 5000 characters"
+// @Param file formData file true "Video file, sent after the other fields"
+// @Success 201 {object} map[string]interface{} "Video uploaded successfully"
+// @Failure 400 {object} map[string]interface{} "Missing or invalid fields, fields after the file, or interrupted upload"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "Missing permission or channel role does not allow uploads"
+// @Failure 404 {object} map[string]interface{} "Channel not found"
+// @Failure 408 {object} map[string]interface{} "Upload did not complete within VIDEO_UPLOAD_TIMEOUT minutes"
+// @Failure 413 {object} map[string]interface{} "File too large"
+// @Failure 415 {object} map[string]interface{} "Not a multipart form, or not an MP4, WebM or AVI video"
+// @Router /api/v1/videos/upload [post]
+func (h *Handler) UploadVideo(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	maxSize := h.service.videoCfg.MaxSize
+	if c.Request.Header.ContentLength() > maxSize+maxFormOverhead {
+		errors.SendError(c, errors.New(errors.ErrCodePayloadTooLarge, "Video too large", fmt.Sprintf("The video must not be larger than %d bytes", maxSize)))
+		return
+	}
+
+	mediaType, params, err := mime.ParseMediaType(string(c.ContentType()))
+	if err != nil || mediaType != "multipart/form-data" || params["boundary"] == "" {
+		errors.SendError(c, errors.New(errors.ErrCodeUnsupportedMediaType, "Unsupported media type", "The upload must be sent as multipart/form-data"))
+		return
+	}
+
+	// Read the form part by part from the body stream, so that the file is
+	// never held in memory
+	var body io.Reader
+	if c.Request.IsBodyStream() {
+		body = c.Request.BodyStream()
+	} else {
+		body = bytes.NewReader(c.Request.Body())
+	}
+	form := multipart.NewReader(body, params["boundary"])
+
+	var req models.VideoUploadRequest
+	for {
+		part, err := form.NextPart()
+		if err == io.EOF {
+			h.SendValidationError(c, "The video file is required")
+			return
+		}
+		if err != nil {
+			h.SendValidationError(c, "Invalid multipart form")
+			return
+		}
+
+		switch part.FormName() {
+		case "file":
+			if req.ChannelID == 0 {
+				h.SendValidationError(c, "channel_id must be sent before the file")
+				return
+			}
+			req.Filename = part.FileName()
+
+			video, err := h.service.UploadVideo(userID, &req, part)
+			if err != nil {
+				errors.SendError(c, err)
+				return
+			}
+
+			h.SendSuccess(c, 201, video, "Video uploaded successfully")
+			return
+		case "channel_id":
+			value, err := readField(part)
+			if err != nil {
+				h.SendValidationError(c, err.Error())
+				return
+			}
+			channelID, err := strconv.ParseUint(value, 10, 64)
+			if err != nil || channelID == 0 {
+				h.SendValidationError(c, "Invalid channel ID format")
+				return
+			}
+			req.ChannelID = uint(channelID)
+		case "title":
+			if req.Title, err = readField(part); err != nil {
+				h.SendValidationError(c, err.Error())
+				return
+			}
+		case "description":
+			if req.Description, err = readField(part); err != nil {
+				h.SendValidationError(c, err.Error())
+				return
+			}
+		}
+	}
+}
+
+// GetVideo godoc
+// @Summary Get video
+// @Description Return a video with its upload status. Visible to the uploader and to channel members with the videos:manage channel permission. API keys need the videos:upload scope.
+// @Tags videos
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Video ID"
+// @Success 200 {object} map[string]interface{} "Video retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid video ID"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "API key without the videos:upload scope"
+// @Failure 404 {object} map[string]interface{} "Video not found"
+// @Router /api/v1/videos/{id} [get]
+func (h *Handler) GetVideo(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	videoID, err := h.GetParamUint(c, "id")
+	if err != nil {
+		h.SendValidationError(c, "Invalid video ID format")
+		return
+	}
+
+	video, err := h.service.GetVideo(videoID, userID)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, video, "Video retrieved successfully")
+}
+
+// ListVideos godoc
+// @Summary List videos
+// @Description List videos with their upload status, most recent first, with cursor pagination. Without channel_id these are the uploads of the authenticated user; with channel_id, all videos of that channel, which requires the videos:manage channel permission. API keys need the videos:upload scope.
+// @Tags videos
+// @Produce json
+// @Security BearerAuth
+// @Param channel_id query int false "Only videos of this channel"
+// @Param status query string false "uploading, uploaded or failed"
+// @Param limit query int false "Page size, 1-100" default(20)
+// @Param cursor query string false "Cursor from the previous page"
+// @Param sort query string false "created_at; prefix with - for descending" default(-created_at)
+// @Success 200 {object} map[string]interface{} "Videos retrieved successfully"
+// @Failure 400 {object} map[string]interface{} "Invalid query parameters or cursor"
+// @Failure 401 {object} map[string]interface{} "Missing or invalid token"
+// @Failure 403 {object} map[string]interface{} "API key without the videos:upload scope, or channel role does not allow managing videos"
+// @Failure 404 {object} map[string]interface{} "Channel not found"
+// @Router /api/v1/videos [get]
+func (h *Handler) ListVideos(c *app.RequestContext) {
+	userID, err := currentUserID(c)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	opts, err := h.ParseListOptions(c, videoListSpec)
+	if err != nil {
+		h.SendValidationError(c, err.Error())
+		return
+	}
+
+	page, err := h.service.ListVideos(userID, opts)
+	if err != nil {
+		errors.SendError(c, err)
+		return
+	}
+
+	h.SendSuccess(c, 200, page, "Videos retrieved successfully")
+}
+
+// readField reads a text field of the upload form
+func readField(part *multipart.Part) (string, error) {
+	data, err := io.ReadAll(io.LimitReader(part, maxFieldSize+1))
+	if err != nil {
+		return "", fmt.Errorf("Failed to read %s", part.FormName())
+	}
+	if len(data) > maxFieldSize {
+		return "", fmt.Errorf("%s is too long", part.FormName())
+	}
+	return string(data), nil
+}
+
+// currentUserID returns the authenticated user ID
+func currentUserID(c *app.RequestContext) (uint, error) {
+	userID, ok := middleware.GetUserID(c)
+	if !ok {
+		return 0, errors.New(errors.ErrCodeUnauthorized, "Unauthorized", "Authentication required")
+	}
+	return userID, nil
+}
@@ -0,0 +1,25 @@
+package videoupload
+
+import (
+	"context"
+
+	"kube/internal/middleware"
+	"kube/pkg/models"
+
+	"github.com/cloudwego/hertz/pkg/app"
+	"github.com/cloudwego/hertz/pkg/app/server"
+)
+
+func RegisterRoutes(h *server.Hertz, service *Service, authMiddleware app.HandlerFunc) {
+	handler := NewHandler(service)
+
+	// Video routes; uploads need a server with streamed request bodies
+	api := h.Group("/api/v1/videos", authMiddleware)
+	{
+		api.POST("/upload", middleware.RequirePermission(models.PermissionVideosUpload), func(ctx context.Context, c *app.RequestContext) { handler.UploadVideo(c) })
+		// API keys need the upload scope to follow their uploads
+		canReadUploads := middleware.RequireAPIKeyScope(models.PermissionVideosUpload)
+		api.GET("", canReadUploads, func(ctx context.Context, c *app.RequestContext) { handler.ListVideos(c) })
+		api.GET("/:id", canReadUploads, func(ctx context.Context, c *app.RequestContext) { handler.GetVideo(c) })
+	}
+}
@@ -0,0 +1,362 @@
+package videoupload
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"kube/internal/config"
+	"kube/internal/storage"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+	"kube/pkg/services"
+
+	"github.com/cloudwego/hertz/pkg/common/hlog"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// sniffLen is the number of leading bytes used to detect the content type
+const sniffLen = 512
+
+// videoContentTypes maps the sniffed content types accepted as videos to
+// the extension of the stored file
+var videoContentTypes = map[string]string{
+	"video/mp4":  ".mp4",
+	"video/webm": ".webm",
+	"video/avi":  ".avi",
+}
+
+// Errors returned by uploadReader once the size limit or the upload
+// deadline is exceeded
+var (
+	errFileTooLarge  = errors.New("file too large")
+	errUploadTimeout = errors.New("upload timed out")
+)
+
+// staleUploadReason is recorded for uploads failed by the sweeper
+const staleUploadReason = "The upload did not complete"
+
+type Service struct {
+	*services.BaseService
+	storage  *storage.Client
+	videoCfg config.VideoConfig
+}
+
+func NewService(db *gorm.DB, cfg *config.Config) *Service {
+	return &Service{
+		BaseService: services.NewBaseService(db),
+		storage:     storage.Init(cfg),
+		videoCfg:    cfg.Video,
+	}
+}
+
+// UploadVideo streams file to storage as a new video of the channel in req.
+// The uploader needs the videos:manage permission on the channel. The video
+// is recorded as uploading before the transfer starts and ends up uploaded
+// with its size and checksum, or failed with a reason and without a stored
+// file. Uploads that outlive the upload timeout fail; rows left uploading by
+// a crash are failed by the sweeper. The content type is sniffed from the
+// data, the declared one is not trusted.
+func (s *Service) UploadVideo(userID uint, req *models.VideoUploadRequest, file io.Reader) (*models.VideoResponse, error) {
+	if err := validateVideo(req.Title, req.Description); err != nil {
+		return nil, err
+	}
+
+	channel, err := s.AuthorizeChannel(req.ChannelID, userID, models.ChannelPermissionVideosManage)
+	if err != nil {
+		return nil, err
+	}
+	if !channel.IsActive {
+		return nil, apperrors.New(apperrors.ErrCodeInvalidOperation, "Cannot upload", "The channel is not active")
+	}
+
+	body := bufio.NewReaderSize(file, sniffLen)
+	head, err := body.Peek(sniffLen)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeInvalidInput, "Upload interrupted", err.Error())
+	}
+	if len(head) == 0 {
+		return nil, fieldValidationError(map[string]string{"file": "is empty"})
+	}
+	contentType := http.DetectContentType(head)
+	ext, ok := videoContentTypes[contentType]
+	if !ok {
+		return nil, apperrors.New(apperrors.ErrCodeUnsupportedMediaType, "Unsupported video format", "The video must be an MP4, WebM or AVI file").
+			AddMetadata("content_type", contentType)
+	}
+
+	// The key is random, so stored files cannot be guessed from video IDs
+	video := &models.Video{
+		UserID:      &userID,
+		ChannelID:   channel.ID,
+		Title:       strings.TrimSpace(req.Title),
+		Description: req.Description,
+		Status:      models.VideoStatusUploading,
+		Filename:    cleanFilename(req.Filename),
+		ContentType: contentType,
+		StorageKey:  "videos/" + uuid.NewString() + "/source" + ext,
+	}
+	if err := s.GetDB().Create(video).Error; err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to create video", err.Error())
+	}
+
+	hash := sha256.New()
+	limited := &uploadReader{
+		r:         body,
+		remaining: int64(s.videoCfg.MaxSize),
+		deadline:  video.CreatedAt.Add(s.uploadTimeout()),
+	}
+	size, err := s.storage.Upload(video.StorageKey, io.TeeReader(limited, hash))
+	switch {
+	case errors.Is(err, errFileTooLarge):
+		return nil, s.failUpload(video, "The file exceeds the maximum size",
+			apperrors.New(apperrors.ErrCodePayloadTooLarge, "Video too large", fmt.Sprintf("The video must not be larger than %d bytes", s.videoCfg.MaxSize)))
+	case errors.Is(err, errUploadTimeout):
+		return nil, s.failUpload(video, "The upload took too long", uploadTimedOut(s.videoCfg.UploadTimeout))
+	case err != nil && limited.err != nil:
+		return nil, s.failUpload(video, "The upload was interrupted",
+			apperrors.Wrap(err, apperrors.ErrCodeInvalidInput, "Upload interrupted", err.Error()))
+	case err != nil:
+		return nil, s.failUpload(video, "The file could not be stored",
+			apperrors.Wrap(err, apperrors.ErrCodeExternalServiceError, "Failed to store video", err.Error()))
+	}
+
+	video.Status = models.VideoStatusUploaded
+	video.Size = size
+	video.Checksum = hex.EncodeToString(hash.Sum(nil))
+	video.UpdatedAt = time.Now()
+	// The sweeper may have failed the video meanwhile, only complete it if
+	// it is still uploading
+	result := s.GetDB().Model(video).Where("status = ?", models.VideoStatusUploading).
+		Select("status", "size", "checksum", "updated_at").Updates(video)
+	if result.Error != nil {
+		return nil, s.failUpload(video, "The video could not be saved",
+			apperrors.Wrap(result.Error, apperrors.ErrCodeDatabaseError, "Failed to save video", result.Error.Error()))
+	}
+	if result.RowsAffected == 0 {
+		s.deleteStoredVideo(video)
+		return nil, uploadTimedOut(s.videoCfg.UploadTimeout)
+	}
+
+	return toVideoResponse(video), nil
+}
+
+// GetVideo returns a video to its uploader or to members of its channel who
+// manage videos. Other users get a not found error, so that unpublished
+// uploads are not revealed.
+func (s *Service) GetVideo(videoID, userID uint) (*models.VideoResponse, error) {
+	var video models.Video
+	if err := s.GetDB().First(&video, videoID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, videoNotFound(videoID)
+		}
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to load video", err.Error())
+	}
+
+	if video.UserID == nil || *video.UserID != userID {
+		if _, err := s.AuthorizeChannel(video.ChannelID, userID, models.ChannelPermissionVideosManage); err != nil {
+			if appErr := apperrors.GetAppError(err); appErr != nil && appErr.Code == apperrors.ErrCodeForbidden {
+				return nil, videoNotFound(videoID)
+			}
+			return nil, err
+		}
+	}
+
+	return toVideoResponse(&video), nil
+}
+
+// ListVideos returns one page of videos, most recent first. Without a
+// channel_id filter these are the uploads of the user; with one, all videos
+// of that channel, which needs the videos:manage permission on it.
+func (s *Service) ListVideos(userID uint, opts *models.ListOptions) (*models.Page, error) {
+	db := s.GetDB()
+	query := db.Model(&models.Video{})
+
+	if channelID, ok := opts.Filters["channel_id"].(uint); ok {
+		if _, err := s.AuthorizeChannel(channelID, userID, models.ChannelPermissionVideosManage); err != nil {
+			return nil, err
+		}
+		query = query.Where("channel_id = ?", channelID)
+	} else {
+		query = query.Where("user_id = ?", userID)
+	}
+	if status, ok := opts.Filters["status"].(string); ok {
+		query = query.Where("status = ?", status)
+	}
+
+	page, videos, err := services.Paginate(query, opts, opts.Sort, func(video *models.Video) (interface{}, uint) {
+		return video.CreatedAt, video.ID
+	})
+	if errors.Is(err, services.ErrInvalidCursor) {
+		return nil, apperrors.New(apperrors.ErrCodeInvalidInput, "Invalid cursor", "The cursor is malformed or does not match the sort order")
+	}
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.ErrCodeDatabaseError, "Failed to list videos", err.Error())
+	}
+
+	items := make([]*models.VideoResponse, 0, len(videos))
+	for i := range videos {
+		items = append(items, toVideoResponse(&videos[i]))
+	}
+	page.Items = items
+	return page, nil
+}
+
+// SweepStaleUploads fails the videos still uploading after the upload
+// timeout and deletes whatever was stored for them. Uploads in progress fail
+// themselves at the timeout, so these were abandoned by a crashed process.
+// It returns the number of videos failed.
+func (s *Service) SweepStaleUploads(ctx context.Context) (int64, error) {
+	db := s.GetDB().WithContext(ctx)
+
+	var videos []models.Video
+	cutoff := time.Now().Add(-s.uploadTimeout())
+	if err := db.Where("status = ? AND created_at < ?", models.VideoStatusUploading, cutoff).Find(&videos).Error; err != nil {
+		return 0, err
+	}
+
+	var swept int64
+	for i := range videos {
+		video := &videos[i]
+		result := db.Model(video).Where("status = ?", models.VideoStatusUploading).
+			Updates(map[string]interface{}{
+				"status":         models.VideoStatusFailed,
+				"failure_reason": staleUploadReason,
+				"updated_at":     time.Now(),
+			})
+		if result.Error != nil {
+			return swept, result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		s.deleteStoredVideo(video)
+		swept++
+	}
+	return swept, nil
+}
+
+// RunSweeper sweeps stale uploads every SweepInterval minutes until ctx is
+// cancelled. It returns immediately when the interval is zero.
+func (s *Service) RunSweeper(ctx context.Context) {
+	if s.videoCfg.SweepInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(s.videoCfg.SweepInterval) * time.Minute)
+	defer ticker.Stop()
+
+	for {
+		swept, err := s.SweepStaleUploads(ctx)
+		if err != nil {
+			hlog.Errorf("Failed to sweep stale uploads: %v", err)
+		} else if swept > 0 {
+			hlog.Infof("Failed %d stale uploads", swept)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Service) uploadTimeout() time.Duration {
+	return time.Duration(s.videoCfg.UploadTimeout) * time.Minute
+}
+
+// failUpload marks the video as failed, deletes anything stored for it and
+// returns cause
+func (s *Service) failUpload(video *models.Video, reason string, cause error) error {
+	video.Status = models.VideoStatusFailed
+	video.FailureReason = reason
+	video.UpdatedAt = time.Now()
+	if err := s.GetDB().Model(video).Select("status", "failure_reason", "updated_at").Updates(video).Error; err != nil {
+		hlog.Errorf("Failed to mark video %d as failed: %v", video.ID, err)
+	}
+	s.deleteStoredVideo(video)
+	return cause
+}
+
+// deleteStoredVideo removes the directory of a video that did not complete.
+// Each video has its own, so this also reaps the partial file of an upload
+// interrupted by a crash.
+func (s *Service) deleteStoredVideo(video *models.Video) {
+	if err := s.storage.DeleteDir(path.Dir(video.StorageKey)); err != nil {
+		hlog.Errorf("Failed to delete stored video %d: %v", video.ID, err)
+	}
+}
+
+// uploadReader fails with errFileTooLarge once more than remaining bytes
+// were read, and with errUploadTimeout after deadline. It records errors of
+// the underlying reader, which tell an interrupted upload apart from a
+// storage failure.
+type uploadReader struct {
+	r         io.Reader
+	remaining int64
+	deadline  time.Time
+	err       error
+}
+
+func (l *uploadReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.remaining -= int64(n)
+	if l.remaining < 0 {
+		return n, errFileTooLarge
+	}
+	if time.Now().After(l.deadline) {
+		return n, errUploadTimeout
+	}
+	if err != nil && !errors.Is(err, io.EOF) {
+		l.err = err
+	}
+	return n, err
+}
+
+// cleanFilename keeps only the base name of a client supplied file name
+func cleanFilename(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	if name == "." || name == "/" {
+		return ""
+	}
+	if runes := []rune(name); len(runes) > maxFilenameLength {
+		name = string(runes[:maxFilenameLength])
+	}
+	return name
+}
+
+func uploadTimedOut(minutes int) error {
+	return apperrors.New(apperrors.ErrCodeTimeout, "Upload timed out", fmt.Sprintf("The upload must complete within %d minutes", minutes))
+}
+
+func videoNotFound(videoID uint) error {
+	return apperrors.New(apperrors.ErrCodeVideoNotFound, "Video not found", fmt.Sprintf("Video %d does not exist", videoID))
+}
+
+func toVideoResponse(video *models.Video) *models.VideoResponse {
+	return &models.VideoResponse{
+		ID:            video.ID,
+		UserID:        video.UserID,
+		ChannelID:     video.ChannelID,
+		Title:         video.Title,
+		Description:   video.Description,
+		Status:        video.Status,
+		FailureReason: video.FailureReason,
+		Filename:      video.Filename,
+		ContentType:   video.ContentType,
+		Size:          video.Size,
+		Checksum:      video.Checksum,
+		CreatedAt:     video.CreatedAt,
+		UpdatedAt:     video.UpdatedAt,
+	}
+}
@@ -0,0 +1,261 @@
+package videoupload
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"kube/internal/config"
+	"kube/internal/testutil"
+	apperrors "kube/pkg/errors"
+	"kube/pkg/models"
+)
+
+// testMP4 is sniffed as video/mp4
+var testMP4 = append([]byte("\x00\x00\x00\x18ftypmp42\x00\x00\x00\x00mp42isom"), bytes.Repeat([]byte{1}, 1000)...)
+
+// newTestService returns a service backed by a fresh SQLite database and
+// local storage in storageDir, together with the ID of a channel owned by
+// the returned user
+func newTestService(t *testing.T, videoCfg config.VideoConfig) (s *Service, storageDir string, userID, channelID uint) {
+	t.Helper()
+
+	db := testutil.NewDB(t)
+	storageDir = t.TempDir()
+	s = NewService(db, &config.Config{
+		Storage: testutil.StorageConfig(storageDir),
+		Video:   videoCfg,
+	})
+
+	user := models.User{Username: "uploader", Email: "uploader@example.com", Password: "x", IsActive: true}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	channel := models.Channel{UserID: user.ID, Handle: "uploads", Name: "Uploads", IsActive: true}
+	if err := db.Create(&channel).Error; err != nil {
+		t.Fatal(err)
+	}
+	return s, storageDir, user.ID, channel.ID
+}
+
+func defaultVideoConfig() config.VideoConfig {
+	return config.VideoConfig{MaxSize: 1 << 20, UploadTimeout: 60}
+}
+
+// storedFiles returns the files below the videos directory of storage,
+// including partial uploads
+func storedFiles(t *testing.T, storageDir string) []string {
+	t.Helper()
+	var files []string
+	err := filepath.WalkDir(filepath.Join(storageDir, "videos"), func(path string, d os.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if path != filepath.Join(storageDir, "videos") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func loadVideo(t *testing.T, s *Service, id uint) models.Video {
+	t.Helper()
+	var video models.Video
+	if err := s.GetDB().First(&video, id).Error; err != nil {
+		t.Fatal(err)
+	}
+	return video
+}
+
+func onlyVideo(t *testing.T, s *Service) models.Video {
+	t.Helper()
+	var videos []models.Video
+	if err := s.GetDB().Find(&videos).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(videos) != 1 {
+		t.Fatalf("%d videos recorded, want 1", len(videos))
+	}
+	return videos[0]
+}
+
+// interruptedReader returns data, then fails like a dropped connection
+type interruptedReader struct {
+	data []byte
+}
+
+func (r *interruptedReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, errors.New("connection reset by peer")
+	}
+	n := copy(p, r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+// hookReader calls onEOF once r is exhausted
+type hookReader struct {
+	r     io.Reader
+	onEOF func()
+}
+
+func (r *hookReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if errors.Is(err, io.EOF) && r.onEOF != nil {
+		r.onEOF()
+		r.onEOF = nil
+	}
+	return n, err
+}
+
+func TestUploadVideo(t *testing.T) {
+	s, _, userID, channelID := newTestService(t, defaultVideoConfig())
+
+	resp, err := s.UploadVideo(userID, &models.VideoUploadRequest{ChannelID: channelID, Title: "First", Filename: "first.mp4"}, bytes.NewReader(testMP4))
+	if err != nil {
+		t.Fatalf("UploadVideo: %v", err)
+	}
+
+	sum := sha256.Sum256(testMP4)
+	if resp.Status != models.VideoStatusUploaded || resp.Size != int64(len(testMP4)) || resp.Checksum != hex.EncodeToString(sum[:]) {
+		t.Errorf("unexpected response %+v", resp)
+	}
+	video := loadVideo(t, s, resp.ID)
+	data, err := s.storage.DownloadFile(video.StorageKey)
+	if err != nil || !bytes.Equal(data, testMP4) {
+		t.Errorf("stored file = %d bytes, %v", len(data), err)
+	}
+}
+
+func TestUploadVideoFailuresLeaveNoFiles(t *testing.T) {
+	tests := []struct {
+		name     string
+		videoCfg config.VideoConfig
+		file     io.Reader
+		code     string
+	}{
+		{"too large", config.VideoConfig{MaxSize: 100, UploadTimeout: 60}, bytes.NewReader(testMP4), apperrors.ErrCodePayloadTooLarge},
+		{"interrupted", defaultVideoConfig(), &interruptedReader{data: testMP4}, apperrors.ErrCodeInvalidInput},
+		{"timed out", config.VideoConfig{MaxSize: 1 << 20}, bytes.NewReader(testMP4), apperrors.ErrCodeTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, storageDir, userID, channelID := newTestService(t, tt.videoCfg)
+
+			_, err := s.UploadVideo(userID, &models.VideoUploadRequest{ChannelID: channelID, Title: "Broken"}, tt.file)
+			testutil.AssertErrorCode(t, err, tt.code)
+
+			if video := onlyVideo(t, s); video.Status != models.VideoStatusFailed || video.FailureReason == "" {
+				t.Errorf("video status = %s (%q), want failed with a reason", video.Status, video.FailureReason)
+			}
+			if files := storedFiles(t, storageDir); len(files) != 0 {
+				t.Errorf("files left in storage: %v", files)
+			}
+		})
+	}
+}
+
+func TestUploadVideoSweptDuringUpload(t *testing.T) {
+	s, storageDir, userID, channelID := newTestService(t, defaultVideoConfig())
+
+	// The sweeper fails the video while the last bytes are stored
+	file := &hookReader{r: bytes.NewReader(testMP4), onEOF: func() {
+		err := s.GetDB().Model(&models.Video{}).Where("status = ?", models.VideoStatusUploading).
+			Updates(map[string]interface{}{"status": models.VideoStatusFailed, "failure_reason": staleUploadReason}).Error
+		if err != nil {
+			t.Error(err)
+		}
+	}}
+
+	_, err := s.UploadVideo(userID, &models.VideoUploadRequest{ChannelID: channelID, Title: "Swept"}, file)
+	testutil.AssertErrorCode(t, err, apperrors.ErrCodeTimeout)
+
+	if video := onlyVideo(t, s); video.Status != models.VideoStatusFailed || video.FailureReason != staleUploadReason {
+		t.Errorf("video status = %s (%q), want failed by the sweeper", video.Status, video.FailureReason)
+	}
+	if files := storedFiles(t, storageDir); len(files) != 0 {
+		t.Errorf("files left in storage: %v", files)
+	}
+}
+
+func TestSweepStaleUploads(t *testing.T) {
+	s, storageDir, userID, channelID := newTestService(t, defaultVideoConfig())
+
+	create := func(key, status string, createdAt time.Time) uint {
+		t.Helper()
+		video := models.Video{UserID: &userID, ChannelID: channelID, Title: key, Status: status, StorageKey: key, CreatedAt: createdAt}
+		if err := s.GetDB().Create(&video).Error; err != nil {
+			t.Fatal(err)
+		}
+		return video.ID
+	}
+	old := time.Now().Add(-2 * time.Hour)
+
+	// A crash mid-upload leaves the row and a partial temporary file
+	staleID := create("videos/stale/source.mp4", models.VideoStatusUploading, old)
+	if err := s.storage.UploadFile("videos/stale/.upload-123", []byte("partial")); err != nil {
+		t.Fatal(err)
+	}
+	activeID := create("videos/active/source.mp4", models.VideoStatusUploading, time.Now())
+	if err := s.storage.UploadFile("videos/active/.upload-456", []byte("partial")); err != nil {
+		t.Fatal(err)
+	}
+	uploadedID := create("videos/uploaded/source.mp4", models.VideoStatusUploaded, old)
+	if err := s.storage.UploadFile("videos/uploaded/source.mp4", testMP4); err != nil {
+		t.Fatal(err)
+	}
+
+	swept, err := s.SweepStaleUploads(context.Background())
+	if err != nil {
+		t.Fatalf("SweepStaleUploads: %v", err)
+	}
+	if swept != 1 {
+		t.Errorf("swept %d uploads, want 1", swept)
+	}
+
+	if video := loadVideo(t, s, staleID); video.Status != models.VideoStatusFailed || video.FailureReason != staleUploadReason {
+		t.Errorf("stale video status = %s (%q), want failed", video.Status, video.FailureReason)
+	}
+	if video := loadVideo(t, s, activeID); video.Status != models.VideoStatusUploading {
+		t.Errorf("active video status = %s, want uploading", video.Status)
+	}
+	if video := loadVideo(t, s, uploadedID); video.Status != models.VideoStatusUploaded {
+		t.Errorf("uploaded video status = %s, want uploaded", video.Status)
+	}
+
+	want := []string{
+		filepath.Join(storageDir, "videos", "active"),
+		filepath.Join(storageDir, "videos", "active", ".upload-456"),
+		filepath.Join(storageDir, "videos", "uploaded"),
+		filepath.Join(storageDir, "videos", "uploaded", "source.mp4"),
+	}
+	if files := storedFiles(t, storageDir); len(files) != len(want) {
+		t.Errorf("files in storage = %v, want %v", files, want)
+	} else {
+		for i := range want {
+			if files[i] != want[i] {
+				t.Errorf("files in storage = %v, want %v", files, want)
+				break
+			}
+		}
+	}
+
+	if swept, err := s.SweepStaleUploads(context.Background()); err != nil || swept != 0 {
+		t.Errorf("second sweep = (%d, %v), want (0, nil)", swept, err)
+	}
+}
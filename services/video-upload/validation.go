@@ -0,0 +1,48 @@
+package videoupload
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	apperrors "kube/pkg/errors"
+)
+
+const (
+	maxTitleLength       = 100
+	maxDescriptionLength = 5000
+	maxFilenameLength    = 255
+)
+
+// validateVideo checks the text fields sent with an upload
+func validateVideo(title, description string) error {
+	fieldErrors := make(map[string]string)
+
+	if strings.TrimSpace(title) == "" {
+		fieldErrors["title"] = "is required"
+	} else if len([]rune(title)) > maxTitleLength {
+		fieldErrors["title"] = "must be at most 100 characters"
+	} else if strings.IndexFunc(title, unicode.IsControl) >= 0 {
+		fieldErrors["title"] = "must not contain control characters"
+	}
+
+	if len([]rune(description)) > maxDescriptionLength {
+		fieldErrors["description"] = "must be at most 5000 characters"
+	}
+
+	if len(fieldErrors) > 0 {
+		return fieldValidationError(fieldErrors)
+	}
+	return nil
+}
+
+func fieldValidationError(fieldErrors map[string]string) error {
+	names := make([]string, 0, len(fieldErrors))
+	for name := range fieldErrors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return apperrors.New(apperrors.ErrCodeValidationFailed, "Validation failed", "Invalid fields: "+strings.Join(names, ", ")).
+		AddMetadata("fields", fieldErrors)
+}